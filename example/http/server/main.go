@@ -167,14 +167,14 @@ func main() {
 		}
 		gtkresp.RespSucc(w, fileInfos)
 	})
-	// 删除OSS文件
-	http.HandleFunc("/ossDelete", func(w http.ResponseWriter, r *http.Request) {
+	// 删除OSS文件，单独设置超时时间
+	http.Handle("/ossDelete", gtkhttp.NewTimeoutHandler(10*time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if _, err := aliyunOSS.DeleteObjects([]string{"test_upload/text.xlsx"}); err != nil {
 			gtkresp.RespFail(w, -1, err.Error())
 			return
 		}
 		gtkresp.RespSucc(w, "OK")
-	})
+	})))
 	// 重定向
 	http.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
 		gtkresp.Redirect(w, "https://www.baidu.com")
@@ -200,9 +200,18 @@ func main() {
 		gtkresp.Writefln(w, "I am test: %s", "Writefln")
 		gtkresp.WriteStatus(w, http.StatusOK, "WriteStatus")
 	})
+	// 服务端中间件：请求ID、客户端真实IP、访问日志、异常恢复、跨域、请求体大小限制
+	handler := gtkhttp.ChainHandler(http.DefaultServeMux,
+		gtkhttp.NewRequestIDHandler(gtkhttp.RequestIDHandlerConfig{}),
+		gtkhttp.NewRealIPHandler(gtkhttp.RealIPHandlerConfig{}),
+		gtkhttp.NewAccessLogHandler(gtkhttp.AccessLogHandlerConfig{}),
+		gtkhttp.NewRecoveryHandler(gtkhttp.RecoveryHandlerConfig{}),
+		gtkhttp.NewCORSHandler(gtkhttp.CORSHandlerConfig{}),
+		gtkhttp.NewBodyLimitHandler(100*1024*1024),
+	)
 	// 启动HTTP服务器
 	fmt.Println("start server")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
+	if err := http.ListenAndServe(":8080", handler); err != nil {
 		fmt.Println("Failed to start server: ", err)
 	}
 }
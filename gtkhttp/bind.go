@@ -0,0 +1,491 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-22 18:05:36
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-22 18:05:36
+ * @Description: 请求参数绑定与校验
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/gtkconv"
+	"github.com/liusuxian/go-toolkit/gtkresp"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultMaxMultipartMemory = 32 << 20 // 解析 multipart 表单时默认使用的最大内存
+)
+
+var (
+	ErrBindFailed = errors.New("bind request failed") // 请求参数绑定失败
+)
+
+var (
+	emailRegexp = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`) // 邮箱
+	phoneRegexp = regexp.MustCompile(`^(\+?86)?1[3-9]\d{9}$`)                              // 中国大陆手机号
+	regexpCache sync.Map                                                                   // 正则表达式缓存 pattern -> *regexp.Regexp
+	bindCache   sync.Map                                                                   // 结构体绑定信息缓存 reflect.Type -> []*bindField
+	timeType    = reflect.TypeFor[time.Time]()
+)
+
+// FieldError 字段校验错误
+type FieldError struct {
+	Field   string `json:"field"`           // 字段名，嵌套字段使用 a.b、items[0].name 形式
+	Rule    string `json:"rule"`            // 未通过的规则
+	Param   string `json:"param,omitempty"` // 规则参数
+	Message string `json:"message"`         // 错误消息
+}
+
+// Error 实现 error 接口
+func (e *FieldError) Error() (msg string) {
+	return e.Field + " " + e.Message
+}
+
+// ValidationErrors 校验错误，按字段顺序排列
+type ValidationErrors []*FieldError
+
+// Error 实现 error 接口
+func (e ValidationErrors) Error() (msg string) {
+	msgs := make([]string, 0, len(e))
+	for _, v := range e {
+		msgs = append(msgs, v.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// bindField 结构体字段的绑定信息
+type bindField struct {
+	key     string // 字段在合并结果中的键，即 json 标签名
+	query   string // 查询参数名
+	form    string // 表单字段名
+	path    string // 路径参数名
+	isSlice bool   // 是否是切片字段
+}
+
+// Bind 将请求参数绑定到结构体并校验，out 必须是结构体指针
+//
+//	参数来源按以下顺序合并，后者覆盖前者：JSON 请求体、表单（form 标签，缺省使用 json 标签名）、查询参数（query 标签）、路径参数（path 标签，需要 Go 1.22+ 的 http.ServeMux 路由）。
+//	合并结果使用 gtkconv.ToStructE 解码（按 json 标签匹配，支持弱类型转换），然后根据 validate 标签校验，校验失败时返回 ValidationErrors，
+//	可以使用 BindFail 转换为 gtkresp.Response。请求体会被读取，调用后不能再次读取
+func Bind(r *http.Request, out any) (err error) {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: out must be a non-nil pointer to struct", ErrBindFailed)
+	}
+	var (
+		fields = getBindFields(rv.Elem().Type())
+		merged = make(map[string]any)
+	)
+	// 请求体
+	if err = bindBody(r, fields, merged); err != nil {
+		return fmt.Errorf("%w: %w", ErrBindFailed, err)
+	}
+	// 查询参数
+	query := r.URL.Query()
+	for _, field := range fields {
+		if field.query != "" {
+			setBindValue(merged, field, query[field.query])
+		}
+	}
+	// 路径参数
+	for _, field := range fields {
+		if field.path != "" {
+			if value := r.PathValue(field.path); value != "" {
+				merged[field.key] = value
+			}
+		}
+	}
+	// 解码
+	if len(merged) > 0 {
+		if err = gtkconv.ToStructE(merged, out, squashEmbedded); err != nil {
+			return fmt.Errorf("%w: %w", ErrBindFailed, err)
+		}
+	}
+	return Validate(out)
+}
+
+// squashEmbedded 解码时平铺匿名嵌入的结构体字段
+func squashEmbedded(dc *gtkconv.DecoderConfig) {
+	dc.Squash = true
+}
+
+// bindBody 解析请求体
+func bindBody(r *http.Request, fields []*bindField, merged map[string]any) (err error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var data []byte
+		if data, err = io.ReadAll(r.Body); err != nil {
+			return
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			return
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var body map[string]any
+		if err = decoder.Decode(&body); err != nil {
+			return
+		}
+		for k, v := range body {
+			merged[k] = v
+		}
+	case mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data":
+		if mediaType == "multipart/form-data" {
+			err = r.ParseMultipartForm(defaultMaxMultipartMemory)
+		} else {
+			err = r.ParseForm()
+		}
+		if err != nil {
+			return
+		}
+		for _, field := range fields {
+			if values, ok := r.PostForm[field.form]; ok {
+				setBindValue(merged, field, values)
+			}
+		}
+	}
+	return
+}
+
+// setBindValue 设置字段的值，切片字段保留所有值，其他字段使用第一个值
+func setBindValue(merged map[string]any, field *bindField, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	if field.isSlice {
+		merged[field.key] = values
+		return
+	}
+	merged[field.key] = values[0]
+}
+
+// getBindFields 获取结构体字段的绑定信息
+func getBindFields(t reflect.Type) (fields []*bindField) {
+	if v, ok := bindCache.Load(t); ok {
+		return v.([]*bindField)
+	}
+	fields = appendBindFields(nil, t)
+	v, _ := bindCache.LoadOrStore(t, fields)
+	return v.([]*bindField)
+}
+
+// appendBindFields 追加结构体字段的绑定信息，匿名嵌入的结构体字段与外层字段平铺
+func appendBindFields(fields []*bindField, t reflect.Type) (result []*bindField) {
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if isEmbeddedStruct(sf) {
+			fields = appendBindFields(fields, indirectType(sf.Type))
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		key := tagName(sf, "json")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = sf.Name
+		}
+		field := &bindField{
+			key:     key,
+			query:   tagName(sf, "query"),
+			form:    tagName(sf, "form"),
+			path:    tagName(sf, "path"),
+			isSlice: indirectType(sf.Type).Kind() == reflect.Slice,
+		}
+		if field.form == "" {
+			field.form = key
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// isEmbeddedStruct 判断是否是需要平铺的匿名嵌入结构体字段，与 encoding/json 一致，设置了 json 标签名的嵌入字段不平铺
+//
+//	嵌入的结构体类型需要是导出的，未导出类型的字段无法被解码和读取
+func isEmbeddedStruct(sf reflect.StructField) (ok bool) {
+	if !sf.Anonymous || !sf.IsExported() || tagName(sf, "json") != "" || sf.Tag.Get("json") == "-" {
+		return false
+	}
+	t := indirectType(sf.Type)
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// tagName 获取结构体标签中的名称部分
+func tagName(sf reflect.StructField, key string) (name string) {
+	name, _, _ = strings.Cut(sf.Tag.Get(key), ",")
+	return
+}
+
+// indirectType 获取指针指向的类型
+func indirectType(t reflect.Type) (elem reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Validate 根据 validate 标签校验结构体，v 必须是结构体或结构体指针，校验失败时返回 ValidationErrors
+//
+//	支持的规则，多个规则使用逗号分隔：
+//	required     必填，不能是零值
+//	omitempty    可选，零值时跳过其他规则
+//	min=n/max=n  数字的取值范围，字符串（按字符数）、切片、映射的长度范围
+//	len=n        字符串（按字符数）、切片、映射的长度
+//	enum=a|b|c   取值必须是其中之一
+//	email        邮箱格式
+//	phone        中国大陆手机号格式
+//	regex=expr   匹配正则表达式，必须是最后一个规则，表达式中可以包含逗号
+//	字段为零值时仍然校验 min/max/len，跳过其他规则；字段为 nil 指针，或设置了 omitempty 且为零值时跳过所有规则
+//	匿名嵌入的结构体字段与外层字段平铺校验；结构体字段以及结构体切片会被递归校验
+func Validate(v any) (err error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: validate target must be a struct", ErrBindFailed)
+	}
+	var errs ValidationErrors
+	validateStruct(rv, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateStruct 递归校验结构体
+func validateStruct(rv reflect.Value, prefix string, errs *ValidationErrors) {
+	t := rv.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if isEmbeddedStruct(sf) {
+			if fv := reflect.Indirect(rv.Field(i)); fv.IsValid() {
+				validateStruct(fv, prefix, errs)
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		name := tagName(sf, "json")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		fv := rv.Field(i)
+		if rules := sf.Tag.Get("validate"); rules != "" {
+			if fe := validateField(fv, name, rules); fe != nil {
+				*errs = append(*errs, fe)
+				continue
+			}
+		}
+		validateNested(fv, name, errs)
+	}
+}
+
+// validateNested 校验嵌套的结构体和结构体切片
+func validateNested(fv reflect.Value, name string, errs *ValidationErrors) {
+	for fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return
+		}
+		fv = fv.Elem()
+	}
+	switch fv.Kind() {
+	case reflect.Struct:
+		if fv.Type() != timeType {
+			validateStruct(fv, name, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		if indirectType(fv.Type().Elem()).Kind() != reflect.Struct {
+			return
+		}
+		for i := range fv.Len() {
+			validateNested(fv.Index(i), fmt.Sprintf("%s[%d]", name, i), errs)
+		}
+	}
+}
+
+// validateField 校验字段，返回第一个未通过的规则
+func validateField(fv reflect.Value, name, rules string) (fe *FieldError) {
+	// 解引用指针，nil 视为零值
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			break
+		}
+		fv = fv.Elem()
+	}
+	var list []string
+	for rules != "" {
+		var rule string
+		if strings.HasPrefix(rules, "regex=") {
+			rule, rules = rules, ""
+		} else {
+			rule, rules, _ = strings.Cut(rules, ",")
+		}
+		list = append(list, strings.TrimSpace(rule))
+	}
+	var (
+		isZero   = fv.IsZero()
+		optional = fv.Kind() == reflect.Pointer || isZero && slices.Contains(list, "omitempty")
+	)
+	for _, rule := range list {
+		ruleName, param, _ := strings.Cut(rule, "=")
+		if ruleName == "required" {
+			if isZero {
+				return &FieldError{Field: name, Rule: ruleName, Message: "is required"}
+			}
+			continue
+		}
+		if optional || ruleName == "" || ruleName == "omitempty" {
+			continue
+		}
+		// 零值仍然校验取值范围和长度，如 min=1 不允许 0
+		if isZero && ruleName != "min" && ruleName != "max" && ruleName != "len" {
+			continue
+		}
+		if msg := checkRule(fv, ruleName, param); msg != "" {
+			return &FieldError{Field: name, Rule: ruleName, Param: param, Message: msg}
+		}
+	}
+	return nil
+}
+
+// checkRule 校验规则，通过时返回空字符串，否则返回错误消息
+func checkRule(fv reflect.Value, rule, param string) (msg string) {
+	switch rule {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "has invalid rule " + rule + "=" + param
+		}
+		value, isNumber := ruleNumber(fv)
+		switch {
+		case rule == "min" && value < limit:
+			if isNumber {
+				return "must be at least " + param
+			}
+			return "length must be at least " + param
+		case rule == "max" && value > limit:
+			if isNumber {
+				return "must be at most " + param
+			}
+			return "length must be at most " + param
+		}
+	case "len":
+		n, err := strconv.Atoi(param)
+		if err != nil {
+			return "has invalid rule len=" + param
+		}
+		if length, ok := ruleLength(fv); ok && length != n {
+			return "length must be " + param
+		}
+	case "enum":
+		value := gtkconv.ToString(fv.Interface())
+		for item := range strings.SplitSeq(param, "|") {
+			if item == value {
+				return ""
+			}
+		}
+		return "must be one of " + strings.ReplaceAll(param, "|", ", ")
+	case "email":
+		if !emailRegexp.MatchString(gtkconv.ToString(fv.Interface())) {
+			return "must be a valid email"
+		}
+	case "phone":
+		if !phoneRegexp.MatchString(gtkconv.ToString(fv.Interface())) {
+			return "must be a valid phone number"
+		}
+	case "regex":
+		re, err := compileRegexp(param)
+		if err != nil {
+			return "has invalid rule regex=" + param
+		}
+		if !re.MatchString(gtkconv.ToString(fv.Interface())) {
+			return "has invalid format"
+		}
+	default:
+		return "has unknown rule " + rule
+	}
+	return ""
+}
+
+// ruleNumber 获取 min/max 规则比较的值，数字返回数值，其他类型返回长度
+func ruleNumber(fv reflect.Value) (value float64, isNumber bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(fv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), true
+	}
+	length, _ := ruleLength(fv)
+	return float64(length), false
+}
+
+// ruleLength 获取字符串（按字符数）、切片、映射的长度
+func ruleLength(fv reflect.Value) (length int, ok bool) {
+	switch fv.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(fv.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fv.Len(), true
+	}
+	return 0, false
+}
+
+// compileRegexp 编译正则表达式并缓存
+func compileRegexp(pattern string) (re *regexp.Regexp, err error) {
+	if v, ok := regexpCache.Load(pattern); ok {
+		return v.(*regexp.Regexp), nil
+	}
+	if re, err = regexp.Compile(pattern); err != nil {
+		return
+	}
+	regexpCache.Store(pattern, re)
+	return
+}
+
+// BindFail 将 Bind/Validate 返回的错误转换为失败响应，错误码为 gtkresp.CodeInvalidParams
+//
+//	校验错误的 Data 为 []*FieldError，逐个字段说明错误原因；其他错误的 Message 为错误信息
+func BindFail(err error) (resp gtkresp.Response) {
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return gtkresp.Fail(gtkresp.CodeInvalidParams, "invalid params", []*FieldError(validationErrs))
+	}
+	return gtkresp.Fail(gtkresp.CodeInvalidParams, err.Error())
+}
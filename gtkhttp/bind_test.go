@@ -0,0 +1,160 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-22 18:05:36
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-22 18:05:36
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/liusuxian/go-toolkit/gtkresp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type bindItem struct {
+	Name  string `json:"name" validate:"required"`
+	Count int    `json:"count" validate:"min=1,max=10"`
+}
+
+type bindRequest struct {
+	ID     int64      `json:"id" path:"id" validate:"required"`
+	Page   int        `json:"page" query:"page" validate:"omitempty,min=1"`
+	Tags   []string   `json:"tags" query:"tag" validate:"max=3"`
+	Name   string     `json:"name" validate:"required,min=2,max=8"`
+	Code   string     `json:"code" validate:"omitempty,len=4,regex=^[A-Z]{2}\\d{2}$"`
+	Status string     `json:"status" validate:"enum=on|off"`
+	Email  string     `json:"email" validate:"email"`
+	Phone  string     `json:"phone" validate:"phone"`
+	Items  []bindItem `json:"items"`
+	Secret string     `json:"-"`
+}
+
+type BindPaging struct {
+	Page int `json:"page" query:"page" validate:"min=1"`
+	Size int `json:"size" query:"size" validate:"omitempty,max=100"`
+}
+
+type bindListRequest struct {
+	BindPaging
+	Keyword string `json:"keyword" query:"keyword" validate:"max=8"`
+}
+
+func TestBind(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		mux    = http.NewServeMux()
+		req    bindRequest
+		err    error
+	)
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		req = bindRequest{}
+		err = gtkhttp.Bind(r, &req)
+	})
+	// JSON 请求体 + 查询参数 + 路径参数
+	body := `{"name":"张三","code":"AB12","status":"on","email":"a@b.com","phone":"13800138000","page":9,"items":[{"name":"x","count":2}]}`
+	r := httptest.NewRequest(http.MethodPost, "/users/42?page=2&tag=a&tag=b", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	serve(mux, r)
+	assert.NoError(err)
+	assert.Equal(bindRequest{
+		ID: 42, Page: 2, Tags: []string{"a", "b"}, Name: "张三", Code: "AB12", Status: "on",
+		Email: "a@b.com", Phone: "13800138000", Items: []bindItem{{Name: "x", Count: 2}},
+	}, req)
+	// 表单
+	form := url.Values{"name": {"lisi"}, "status": {"off"}}
+	r = httptest.NewRequest(http.MethodPost, "/users/7", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	serve(mux, r)
+	assert.NoError(err)
+	assert.Equal(bindRequest{ID: 7, Name: "lisi", Status: "off"}, req)
+	// 请求体格式错误
+	r = httptest.NewRequest(http.MethodPost, "/users/7", strings.NewReader(`{"name":`))
+	r.Header.Set("Content-Type", "application/json")
+	serve(mux, r)
+	assert.ErrorIs(err, gtkhttp.ErrBindFailed)
+	assert.Equal(gtkresp.CodeInvalidParams, gtkhttp.BindFail(err).Code)
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+	err := gtkhttp.Validate(&bindRequest{
+		Page:   -1,
+		Tags:   []string{"a", "b", "c", "d"},
+		Name:   "张",
+		Code:   "ab12",
+		Status: "unknown",
+		Email:  "a@b",
+		Phone:  "12345",
+		Items:  []bindItem{{Name: "x", Count: 1}, {Count: 11}},
+	})
+	var errs gtkhttp.ValidationErrors
+	if assert.ErrorAs(err, &errs) {
+		fields := make(map[string]string)
+		for _, fe := range errs {
+			fields[fe.Field] = fe.Rule
+		}
+		assert.Equal(map[string]string{
+			"id":             "required",
+			"page":           "min",
+			"tags":           "max",
+			"name":           "min",
+			"code":           "regex",
+			"status":         "enum",
+			"email":          "email",
+			"phone":          "phone",
+			"items[1].name":  "required",
+			"items[1].count": "max",
+		}, fields)
+	}
+	// 转换为失败响应
+	resp := gtkhttp.BindFail(err)
+	assert.Equal(gtkresp.CodeInvalidParams, resp.Code)
+	assert.Equal("invalid params", resp.Message)
+	if data, ok := resp.Data.([]*gtkhttp.FieldError); assert.True(ok) {
+		assert.Equal(&gtkhttp.FieldError{Field: "id", Rule: "required", Message: "is required"}, data[0])
+	}
+	// 零值仍然校验 min/max/len，设置 omitempty 或为 nil 指针时跳过
+	err = gtkhttp.Validate(bindItem{Name: "x"})
+	if assert.ErrorAs(err, &errs) {
+		assert.Equal(&gtkhttp.FieldError{Field: "count", Rule: "min", Param: "1", Message: "must be at least 1"}, errs[0])
+	}
+	assert.Error(gtkhttp.Validate(bindItem{Name: "x", Count: 11}))
+	assert.NoError(gtkhttp.Validate(struct {
+		Count *int   `json:"count" validate:"min=1"`
+		Name  string `json:"name" validate:"omitempty,min=2"`
+		Email string `json:"email" validate:"email"`
+	}{}))
+}
+
+func TestBindEmbedded(t *testing.T) {
+	assert := assert.New(t)
+	// 匿名嵌入的结构体字段与外层字段平铺绑定
+	var req bindListRequest
+	r := httptest.NewRequest(http.MethodGet, "/list?page=2&size=20&keyword=go", nil)
+	assert.NoError(gtkhttp.Bind(r, &req))
+	assert.Equal(bindListRequest{BindPaging: BindPaging{Page: 2, Size: 20}, Keyword: "go"}, req)
+	req = bindListRequest{}
+	r = httptest.NewRequest(http.MethodPost, "/list", strings.NewReader(`{"page":3,"keyword":"k"}`))
+	r.Header.Set("Content-Type", "application/json")
+	assert.NoError(gtkhttp.Bind(r, &req))
+	assert.Equal(bindListRequest{BindPaging: BindPaging{Page: 3}, Keyword: "k"}, req)
+	// 嵌入字段的规则同样生效，字段名不带嵌入类型的前缀
+	err := gtkhttp.Validate(&bindListRequest{BindPaging: BindPaging{Size: 101}, Keyword: "too long keyword"})
+	var errs gtkhttp.ValidationErrors
+	if assert.ErrorAs(err, &errs) {
+		fields := make(map[string]string)
+		for _, fe := range errs {
+			fields[fe.Field] = fe.Rule
+		}
+		assert.Equal(map[string]string{"page": "min", "size": "max", "keyword": "max"}, fields)
+	}
+}
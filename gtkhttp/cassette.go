@@ -0,0 +1,510 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-22 14:25:07
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-22 14:25:07
+ * @Description: 录制/回放 HTTP 请求执行器
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.yaml.in/yaml/v3"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrCassetteNoMatch = errors.New("no matching interaction in cassette") // 磁带中没有匹配的请求
+)
+
+// CassetteMode 录制/回放模式
+type CassetteMode int
+
+const (
+	CassetteModeAuto   CassetteMode = iota // 磁带文件存在时回放，不存在时录制
+	CassetteModeReplay                     // 只回放，没有匹配的请求时返回 ErrCassetteNoMatch
+	CassetteModeRecord                     // 总是发送真实请求并重新录制，覆盖已有的磁带文件
+)
+
+// CassetteConfig 录制/回放配置
+type CassetteConfig struct {
+	Path            string       // 磁带文件路径，扩展名为 .yaml 或 .yml 时使用 YAML 格式，否则使用 JSON 格式
+	Mode            CassetteMode // 录制/回放模式，默认 CassetteModeAuto
+	HTTPClient      HTTPDoer     // 录制时发送真实请求的执行器，默认 NewDefaultHTTPDoer(0)
+	MatchHeaders    []string     // 匹配请求时额外比较的请求头，默认只比较请求方法、URL 和请求体
+	SensitiveFields []string     // 敏感字段，与 LoggingMiddlewareConfig.SensitiveFields 规则相同，录制时请求头、响应头、URL 查询参数和 JSON 格式的请求体/响应体（包括流式响应中 NDJSON 的每行和 SSE 的 data 字段）中的值会被替换为 ***
+}
+
+// Cassette 磁带，保存录制的请求/响应
+type Cassette struct {
+	Interactions []*CassetteInteraction `json:"interactions" yaml:"interactions"` // 请求/响应记录
+}
+
+// CassetteInteraction 一次请求/响应记录
+type CassetteInteraction struct {
+	Request  CassetteRequest  `json:"request" yaml:"request"`   // 请求
+	Response CassetteResponse `json:"response" yaml:"response"` // 响应
+}
+
+// CassetteRequest 录制的请求
+type CassetteRequest struct {
+	Method string      `json:"method" yaml:"method"`                     // 请求方法
+	URL    string      `json:"url" yaml:"url"`                           // 请求地址
+	Header http.Header `json:"header,omitempty" yaml:"header,omitempty"` // 请求头
+	Body   string      `json:"body,omitempty" yaml:"body,omitempty"`     // 请求体
+}
+
+// CassetteResponse 录制的响应
+type CassetteResponse struct {
+	StatusCode int         `json:"status_code" yaml:"status_code"`               // 状态码
+	Header     http.Header `json:"header,omitempty" yaml:"header,omitempty"`     // 响应头
+	Body       string      `json:"body,omitempty" yaml:"body,omitempty"`         // 响应体
+	Chunks     []string    `json:"chunks,omitempty" yaml:"chunks,omitempty"`     // 流式响应按读取顺序录制的数据块，回放时逐块返回
+	Encoding   string      `json:"encoding,omitempty" yaml:"encoding,omitempty"` // 响应体编码，非 UTF-8 数据使用 base64
+	Duration   string      `json:"duration,omitempty" yaml:"duration,omitempty"` // 录制时的请求耗时，仅供参考
+}
+
+// CassetteDoer 录制/回放 HTTP 请求执行器，实现了 HTTPDoer，用于编写不依赖网络的确定性测试
+//
+//	录制时每完成一次请求/响应（流式响应在响应体读取结束或关闭时）就会写入磁带文件；并发安全
+type CassetteDoer struct {
+	config   CassetteConfig
+	cassette *Cassette
+	replay   bool         // 是否回放
+	used     map[int]bool // 已回放的记录
+	mu       sync.Mutex
+}
+
+// NewCassetteDoer 新建录制/回放 HTTP 请求执行器
+func NewCassetteDoer(config CassetteConfig) (doer *CassetteDoer, err error) {
+	if config.Path == "" {
+		return nil, errors.New("cassette path is empty")
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = NewDefaultHTTPDoer(0)
+	}
+	doer = &CassetteDoer{
+		config:   config,
+		cassette: &Cassette{},
+		used:     make(map[int]bool),
+	}
+	// 确定模式
+	switch config.Mode {
+	case CassetteModeReplay:
+		doer.replay = true
+	case CassetteModeRecord:
+		doer.replay = false
+	default:
+		_, e := os.Stat(config.Path)
+		doer.replay = e == nil
+	}
+	// 加载磁带
+	if doer.replay {
+		if doer.cassette, err = loadCassette(config.Path); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// SetTimeout 设置请求超时时间，只对录制时的真实请求生效
+func (doer *CassetteDoer) SetTimeout(timeout time.Duration) {
+	doer.config.HTTPClient.SetTimeout(timeout)
+}
+
+// Do 发送请求，回放时返回匹配的录制响应，录制时发送真实请求并记录
+func (doer *CassetteDoer) Do(req *http.Request) (resp *http.Response, err error) {
+	// 读取请求体，并恢复以便发送真实请求
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	request := doer.newCassetteRequest(req, body)
+	if doer.replay {
+		return doer.replayResponse(req, request)
+	}
+	return doer.record(req, request)
+}
+
+// Cassette 获取磁带，录制时包含已完成的记录
+func (doer *CassetteDoer) Cassette() (cassette *Cassette) {
+	doer.mu.Lock()
+	defer doer.mu.Unlock()
+	return &Cassette{Interactions: append([]*CassetteInteraction(nil), doer.cassette.Interactions...)}
+}
+
+// replayResponse 回放响应，依次使用匹配的记录，全部使用过后重复使用最后一条匹配的记录
+func (doer *CassetteDoer) replayResponse(req *http.Request, request CassetteRequest) (resp *http.Response, err error) {
+	doer.mu.Lock()
+	var (
+		interaction *CassetteInteraction
+		last        = -1
+	)
+	for i, v := range doer.cassette.Interactions {
+		if !doer.match(v.Request, request) {
+			continue
+		}
+		last = i
+		if !doer.used[i] {
+			doer.used[i] = true
+			interaction = v
+			break
+		}
+	}
+	if interaction == nil && last != -1 {
+		interaction = doer.cassette.Interactions[last]
+	}
+	doer.mu.Unlock()
+	if interaction == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrCassetteNoMatch, request.Method, request.URL)
+	}
+	// 构建响应
+	r := interaction.Response
+	resp = &http.Response{
+		Status:     fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode: r.StatusCode,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     r.Header.Clone(),
+		Request:    req,
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	if len(r.Chunks) > 0 {
+		chunks := make([][]byte, 0, len(r.Chunks))
+		for _, chunk := range r.Chunks {
+			var data []byte
+			if data, err = decodeCassetteBody(chunk, r.Encoding); err != nil {
+				return nil, err
+			}
+			chunks = append(chunks, data)
+		}
+		resp.ContentLength = -1
+		resp.Body = &chunkReader{chunks: chunks}
+		return
+	}
+	var data []byte
+	if data, err = decodeCassetteBody(r.Body, r.Encoding); err != nil {
+		return nil, err
+	}
+	resp.ContentLength = int64(len(data))
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return
+}
+
+// record 发送真实请求并录制
+func (doer *CassetteDoer) record(req *http.Request, request CassetteRequest) (resp *http.Response, err error) {
+	startTime := time.Now()
+	if resp, err = doer.config.HTTPClient.Do(req); err != nil {
+		return
+	}
+	interaction := &CassetteInteraction{
+		Request: request,
+		Response: CassetteResponse{
+			StatusCode: resp.StatusCode,
+			Header:     doer.sanitizeHeader(resp.Header),
+		},
+	}
+	// 流式响应边读取边录制，读取结束或关闭时保存
+	if isStreamResponse(resp) || strings.Contains(resp.Header.Get("Content-Type"), "ndjson") {
+		resp.Body = &recordingBody{
+			body: resp.Body,
+			done: func(chunks [][]byte) {
+				interaction.Response.Duration = time.Since(startTime).String()
+				interaction.Response.Chunks, interaction.Response.Encoding = encodeCassetteChunks(doer.sanitizeChunks(chunks))
+				doer.save(interaction)
+			},
+		}
+		return
+	}
+	// 普通响应读取完整响应体后保存
+	var data []byte
+	data, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	interaction.Response.Duration = time.Since(startTime).String()
+	if utf8.Valid(data) {
+		interaction.Response.Body = doer.sanitizeBody(data)
+	} else {
+		interaction.Response.Body = base64.StdEncoding.EncodeToString(data)
+		interaction.Response.Encoding = "base64"
+	}
+	if err = doer.save(interaction); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// save 追加记录并写入磁带文件
+func (doer *CassetteDoer) save(interaction *CassetteInteraction) (err error) {
+	doer.mu.Lock()
+	defer doer.mu.Unlock()
+
+	doer.cassette.Interactions = append(doer.cassette.Interactions, interaction)
+	var data []byte
+	if isYAMLCassette(doer.config.Path) {
+		data, err = yaml.Marshal(doer.cassette)
+	} else {
+		data, err = json.MarshalIndent(doer.cassette, "", "  ")
+	}
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(doer.config.Path), 0755); err != nil {
+		return
+	}
+	return os.WriteFile(doer.config.Path, data, 0644)
+}
+
+// match 判断录制的请求与当前请求是否匹配
+func (doer *CassetteDoer) match(recorded, request CassetteRequest) (ok bool) {
+	if recorded.Method != request.Method || recorded.URL != request.URL || recorded.Body != request.Body {
+		return false
+	}
+	for _, key := range doer.config.MatchHeaders {
+		if strings.Join(recorded.Header.Values(key), ", ") != strings.Join(request.Header.Values(key), ", ") {
+			return false
+		}
+	}
+	return true
+}
+
+// newCassetteRequest 将请求转换为脱敏后的录制请求，录制和匹配使用相同的规则
+func (doer *CassetteDoer) newCassetteRequest(req *http.Request, body []byte) (request CassetteRequest) {
+	request = CassetteRequest{
+		Method: req.Method,
+		URL:    doer.sanitizeURL(req.URL),
+		Header: doer.sanitizeHeader(req.Header),
+	}
+	if utf8.Valid(body) {
+		request.Body = doer.sanitizeBody(body)
+	} else {
+		request.Body = "base64:" + base64.StdEncoding.EncodeToString(body)
+	}
+	return
+}
+
+// sanitizeURL 脱敏 URL 查询参数
+func (doer *CassetteDoer) sanitizeURL(u *url.URL) (rawURL string) {
+	query := u.Query()
+	if len(query) == 0 {
+		return u.String()
+	}
+	for key := range query {
+		if isSensitiveField(key, doer.config.SensitiveFields) {
+			query.Set(key, "***")
+		}
+	}
+	c := *u
+	c.RawQuery = query.Encode()
+	return c.String()
+}
+
+// sanitizeHeader 脱敏请求头/响应头
+func (doer *CassetteDoer) sanitizeHeader(header http.Header) (result http.Header) {
+	if len(header) == 0 {
+		return nil
+	}
+	result = header.Clone()
+	for key := range result {
+		if isSensitiveField(key, doer.config.SensitiveFields) {
+			result[key] = []string{"***"}
+		}
+	}
+	return
+}
+
+// sanitizeBody 脱敏 JSON 格式的请求体/响应体，JSON 会被重新序列化（对象的键按字母排序）
+func (doer *CassetteDoer) sanitizeBody(data []byte) (body string) {
+	if len(data) == 0 {
+		return
+	}
+	if json.Valid(data) {
+		var v any
+		if err := json.Unmarshal(data, &v); err == nil {
+			if b, err := json.Marshal(sanitizeValue(v, doer.config.SensitiveFields, 0)); err == nil {
+				return string(b)
+			}
+		}
+	}
+	return string(data)
+}
+
+// sanitizeChunks 脱敏流式响应的数据块，NDJSON 的每行和 SSE 的 data 字段按 JSON 脱敏
+//
+//	一行数据可能被拆分到多个数据块中，脱敏后的数据块按行对齐，每个数据块包含原数据块中结束的完整行
+func (doer *CassetteDoer) sanitizeChunks(chunks [][]byte) (result [][]byte) {
+	result = make([][]byte, 0, len(chunks))
+	var pending []byte // 尚未结束的行
+	for _, chunk := range chunks {
+		pending = append(pending, chunk...)
+		i := bytes.LastIndexByte(pending, '\n')
+		if i < 0 {
+			continue
+		}
+		result = append(result, doer.sanitizeLines(pending[:i+1]))
+		pending = bytes.Clone(pending[i+1:])
+	}
+	if len(pending) > 0 {
+		result = append(result, doer.sanitizeLines(pending))
+	}
+	return
+}
+
+// sanitizeLines 逐行脱敏流式响应数据，保留行结束符和 SSE 的字段前缀
+func (doer *CassetteDoer) sanitizeLines(data []byte) (sanitized []byte) {
+	sanitized = make([]byte, 0, len(data))
+	for len(data) > 0 {
+		var line []byte
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i+1], data[i+1:]
+		} else {
+			line, data = data, nil
+		}
+		content := bytes.TrimRight(line, "\r\n")
+		prefix, payload := []byte(nil), content
+		if after, ok := bytes.CutPrefix(content, []byte("data:")); ok {
+			payload = bytes.TrimPrefix(after, []byte(" "))
+			prefix = content[:len(content)-len(payload)]
+		}
+		if len(bytes.TrimSpace(payload)) == 0 || !json.Valid(payload) {
+			sanitized = append(sanitized, line...)
+			continue
+		}
+		sanitized = append(sanitized, prefix...)
+		sanitized = append(sanitized, doer.sanitizeBody(payload)...)
+		sanitized = append(sanitized, line[len(content):]...)
+	}
+	return
+}
+
+// loadCassette 加载磁带文件
+func loadCassette(path string) (cassette *Cassette, err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return
+	}
+	cassette = &Cassette{}
+	if isYAMLCassette(path) {
+		err = yaml.Unmarshal(data, cassette)
+	} else {
+		err = json.Unmarshal(data, cassette)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cassette: %s, error: %w", path, err)
+	}
+	return
+}
+
+// isYAMLCassette 是否为 YAML 格式的磁带文件
+func isYAMLCassette(path string) (ok bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// encodeCassetteChunks 编码流式响应的数据块，任一数据块不是 UTF-8 时全部使用 base64
+func encodeCassetteChunks(chunks [][]byte) (encoded []string, encoding string) {
+	for _, chunk := range chunks {
+		if !utf8.Valid(chunk) {
+			encoding = "base64"
+			break
+		}
+	}
+	encoded = make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		if encoding == "base64" {
+			encoded = append(encoded, base64.StdEncoding.EncodeToString(chunk))
+		} else {
+			encoded = append(encoded, string(chunk))
+		}
+	}
+	return
+}
+
+// decodeCassetteBody 解码响应体
+func decodeCassetteBody(body, encoding string) (data []byte, err error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
+
+// recordingBody 边读取边录制的响应体
+type recordingBody struct {
+	body   io.ReadCloser
+	chunks [][]byte
+	done   func(chunks [][]byte)
+	once   sync.Once
+}
+
+// Read 读取数据
+func (b *recordingBody) Read(p []byte) (n int, err error) {
+	n, err = b.body.Read(p)
+	if n > 0 {
+		b.chunks = append(b.chunks, bytes.Clone(p[:n]))
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return
+}
+
+// Close 关闭响应体
+func (b *recordingBody) Close() (err error) {
+	err = b.body.Close()
+	b.finish()
+	return
+}
+
+// finish 录制结束
+func (b *recordingBody) finish() {
+	b.once.Do(func() {
+		b.done(b.chunks)
+	})
+}
+
+// chunkReader 逐块返回数据的响应体
+type chunkReader struct {
+	chunks [][]byte
+}
+
+// Read 读取数据，每次最多返回一个数据块
+func (r *chunkReader) Read(p []byte) (n int, err error) {
+	for len(r.chunks) > 0 && len(r.chunks[0]) == 0 {
+		r.chunks = r.chunks[1:]
+	}
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n = copy(p, r.chunks[0])
+	r.chunks[0] = r.chunks[0][n:]
+	return
+}
+
+// Close 关闭响应体
+func (r *chunkReader) Close() (err error) {
+	return
+}
@@ -0,0 +1,214 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-22 15:31:44
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-22 15:31:44
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCassetteServer(calls *atomic.Int32) (server *httptest.Server) {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			for i := range 3 {
+				fmt.Fprintf(w, "data: {\"text\":\"%d\"}\n\n", i)
+				w.(http.Flusher).Flush()
+			}
+			w.Write([]byte("data: [DONE]\n\n"))
+		default:
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=abc")
+			fmt.Fprintf(w, `{"echo":%s,"token":"secret-token","tenant":"%s"}`, body, r.Header.Get("X-Tenant"))
+		}
+	}))
+}
+
+func newCassetteClient(baseURL string, doer gtkhttp.HTTPDoer) (client *gtkhttp.HTTPClient) {
+	return gtkhttp.NewHTTPClientWithConfig(gtkhttp.HTTPClientConfig{
+		BaseURL:                     baseURL,
+		HTTPClient:                  doer,
+		ResponseDecoder:             &gtkhttp.DefaultResponseDecoder{},
+		EmptyMessagesLimit:          300,
+		StreamReturnIntervalTimeout: 5 * time.Second,
+	})
+}
+
+func TestCassetteDoer(t *testing.T) {
+	for _, ext := range []string{".yaml", ".json"} {
+		t.Run(ext, func(t *testing.T) {
+			var (
+				assert = assert.New(t)
+				ctx    = context.Background()
+				calls  atomic.Int32
+				server = newCassetteServer(&calls)
+				path   = filepath.Join(t.TempDir(), "cassettes", "chat"+ext)
+				config = gtkhttp.CassetteConfig{
+					Path:            path,
+					MatchHeaders:    []string{"X-Tenant"},
+					SensitiveFields: []string{"authorization", "token", "cookie", "api_key"},
+				}
+			)
+			defer server.Close()
+
+			send := func(doer gtkhttp.HTTPDoer, tenant string) (body string, err error) {
+				client := newCassetteClient(server.URL, doer)
+				req, err := client.NewRequest(ctx, http.MethodPost, server.URL+"/chat?api_key=k1&model=m",
+					gtkhttp.WithBody(map[string]any{"prompt": "hi", "token": "request-token"}),
+					gtkhttp.WithKeyValue("Authorization", "Bearer sk-123"),
+					gtkhttp.WithKeyValue("X-Tenant", tenant))
+				if err != nil {
+					return
+				}
+				resp, err := doer.Do(req)
+				if err != nil {
+					return
+				}
+				defer resp.Body.Close()
+				data, err := io.ReadAll(resp.Body)
+				return string(data), err
+			}
+			stream := func(doer gtkhttp.HTTPDoer) (texts []string, err error) {
+				client := newCassetteClient(server.URL, doer)
+				req, err := client.NewRequest(ctx, http.MethodGet, server.URL+"/stream")
+				if err != nil {
+					return
+				}
+				reader, err := gtkhttp.SendRequestStream[chunk](client, req)
+				if err != nil {
+					return
+				}
+				err = reader.ForEach(func(c chunk, isFinished bool) (err error) {
+					if !isFinished {
+						texts = append(texts, c.Text)
+					}
+					return
+				})
+				return
+			}
+
+			// 录制
+			recorder, err := gtkhttp.NewCassetteDoer(config)
+			assert.NoError(err)
+			body, err := send(recorder, "a")
+			assert.NoError(err)
+			assert.Contains(body, "secret-token")
+			texts, err := stream(recorder)
+			assert.NoError(err)
+			assert.Equal([]string{"0", "1", "2"}, texts)
+			assert.Equal(int32(2), calls.Load())
+			// 敏感信息已脱敏
+			data, err := os.ReadFile(path)
+			assert.NoError(err)
+			for _, secret := range []string{"sk-123", "request-token", "secret-token", "session=abc", "k1"} {
+				assert.NotContains(string(data), secret)
+			}
+			interactions := recorder.Cassette().Interactions
+			if assert.Len(interactions, 2) {
+				assert.Equal([]string{"***"}, interactions[0].Request.Header["Authorization"])
+				assert.Contains(interactions[0].Request.URL, "api_key=%2A%2A%2A")
+				assert.NotEmpty(interactions[1].Response.Chunks)
+			}
+
+			// 回放，不再请求服务端
+			player, err := gtkhttp.NewCassetteDoer(config)
+			assert.NoError(err)
+			body, err = send(player, "a")
+			assert.NoError(err)
+			assert.JSONEq(`{"echo":{"prompt":"hi","token":"***"},"tenant":"a","token":"***"}`, body)
+			texts, err = stream(player)
+			assert.NoError(err)
+			assert.Equal([]string{"0", "1", "2"}, texts)
+			assert.Equal(int32(2), calls.Load())
+			// 请求头不匹配
+			_, err = send(player, "b")
+			assert.ErrorIs(err, gtkhttp.ErrCassetteNoMatch)
+		})
+	}
+}
+
+func TestCassetteDoerReplayMissing(t *testing.T) {
+	assert := assert.New(t)
+	_, err := gtkhttp.NewCassetteDoer(gtkhttp.CassetteConfig{
+		Path: filepath.Join(t.TempDir(), "missing.json"),
+		Mode: gtkhttp.CassetteModeReplay,
+	})
+	assert.ErrorIs(err, os.ErrNotExist)
+	_, err = gtkhttp.NewCassetteDoer(gtkhttp.CassetteConfig{})
+	assert.True(strings.Contains(err.Error(), "path"))
+}
+
+func TestCassetteDoerStreamSanitize(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/ndjson" {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				w.Write([]byte("{\"text\":\"a\",\"api_key\":\"ndjson-key\"}\n{\"text\":\"b\"}\n"))
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			// 一行数据被拆分到多个数据块中
+			w.Write([]byte(`data: {"text":"0","token":"stream-`))
+			w.(http.Flusher).Flush()
+			w.Write([]byte("token\"}\n\nevent: plain\ndata:  keep token \n\n"))
+			w.(http.Flusher).Flush()
+			w.Write([]byte("data: [DONE]\n\n"))
+		}))
+		path = filepath.Join(t.TempDir(), "stream.json")
+	)
+	defer server.Close()
+
+	recorder, err := gtkhttp.NewCassetteDoer(gtkhttp.CassetteConfig{
+		Path:            path,
+		SensitiveFields: []string{"token", "api_key"},
+	})
+	assert.NoError(err)
+	var bodies []string
+	for _, p := range []string{"/sse", "/ndjson"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+p, nil)
+		resp, err := recorder.Do(req)
+		if !assert.NoError(err) {
+			return
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(err)
+		bodies = append(bodies, string(data))
+	}
+	// 调用方读取到的是原始数据
+	assert.Contains(bodies[0], "stream-token")
+	assert.Contains(bodies[1], "ndjson-key")
+	// 磁带中的 JSON 数据已脱敏，非 JSON 数据保持原样
+	data, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.NotContains(string(data), "stream-token")
+	assert.NotContains(string(data), "ndjson-key")
+	interactions := recorder.Cassette().Interactions
+	if assert.Len(interactions, 2) {
+		assert.Equal("data: {\"text\":\"0\",\"token\":\"***\"}\n\nevent: plain\ndata:  keep token \n\ndata: [DONE]\n\n", strings.Join(interactions[0].Response.Chunks, ""))
+		assert.Equal("{\"api_key\":\"***\",\"text\":\"a\"}\n{\"text\":\"b\"}\n", strings.Join(interactions[1].Response.Chunks, ""))
+	}
+}
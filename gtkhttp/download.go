@@ -10,36 +10,22 @@
 package gtkhttp
 
 import (
-	"fmt"
-	"github.com/liusuxian/go-toolkit/internal/utils"
-	"io"
-	"net/http"
+	"context"
 	"net/url"
-	"os"
 	"path"
 	"path/filepath"
 	"strings"
 )
 
 // DownloadFile 从指定的 URL 下载文件到本地路径
+//
+//	需要超时控制、断点续传、分段并行下载或校验时请使用 Downloader
 func DownloadFile(url, dirPath string, fileName ...string) (filePath string, err error) {
-	// 发起 HTTP GET 请求
-	var resp *http.Response
-	if resp, err = http.Get(url); err != nil {
-		err = fmt.Errorf("failed to http get: %s, error: %w", url, err)
-		return
-	}
-	defer resp.Body.Close()
-	// 检查 HTTP 响应状态码
-	if resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("failed to http get: %s, status: %s", url, resp.Status)
-		return
-	}
-	// 创建目录
-	if err = utils.MakeDirAll(dirPath); err != nil {
-		err = fmt.Errorf("failed to create directory: %s, error: %w", dirPath, err)
-		return
-	}
+	return DownloadFileWithContext(context.Background(), url, dirPath, fileName...)
+}
+
+// DownloadFileWithContext 从指定的 URL 下载文件到本地路径
+func DownloadFileWithContext(ctx context.Context, url, dirPath string, fileName ...string) (filePath string, err error) {
 	// 构建文件完整路径
 	var fname string
 	if len(fileName) > 0 && fileName[0] != "" {
@@ -48,16 +34,8 @@ func DownloadFile(url, dirPath string, fileName ...string) (filePath string, err
 		fname = ExtractFileNameFromURL(url)
 	}
 	filePath = filepath.Join(dirPath, fname)
-	// 创建文件
-	var outFile *os.File
-	if outFile, err = os.Create(filePath); err != nil {
-		err = fmt.Errorf("failed to create file: %s, error: %w", filePath, err)
-		return
-	}
-	defer outFile.Close()
-	// 写入文件
-	if _, err = io.Copy(outFile, resp.Body); err != nil {
-		err = fmt.Errorf("failed to write file: %s, error: %w", filePath, err)
+	// 下载文件
+	if _, err = NewDownloader(DownloaderConfig{}).Download(ctx, url, filePath); err != nil {
 		return
 	}
 	return
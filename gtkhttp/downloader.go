@@ -0,0 +1,480 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-21 10:48:27
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-21 10:48:27
+ * @Description: 文件下载器，支持断点续传、分段并行下载和校验
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/internal/utils"
+	"golang.org/x/sync/errgroup"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	defaultMinSegmentSize int64  = 1 << 20      // 默认每段最小字节数 1MB
+	downloadTempSuffix    string = ".download"  // 下载临时文件后缀
+	validatorSuffix       string = ".validator" // 临时文件对应的校验器（ETag 或 Last-Modified）文件后缀
+)
+
+// ChecksumAlgorithm 校验算法
+type ChecksumAlgorithm string
+
+const (
+	ChecksumSHA256 ChecksumAlgorithm = "sha256" // SHA-256
+	ChecksumMD5    ChecksumAlgorithm = "md5"    // MD5
+)
+
+// DownloadProgressFunc 下载进度回调函数，total 未知时为 -1，分段并行下载时会被并发调用
+type DownloadProgressFunc func(downloaded, total int64)
+
+// DownloaderConfig 下载器配置
+type DownloaderConfig struct {
+	HTTPClient     HTTPDoer             // HTTP 请求执行器，默认无超时限制（由 ctx 控制）
+	Header         http.Header          // 额外的请求头
+	Concurrency    int                  // 分段并行下载的并发数，默认 1（不分段），服务端支持 Range 且文件大小已知时生效
+	MinSegmentSize int64                // 每段最小字节数，默认 1MB
+	MaxSize        int64                // 文件最大字节数，超出时返回 ErrDownloadTooLarge，默认不限制
+	Resume         bool                 // 是否开启断点续传，开启后下载失败时保留临时文件，下次下载时通过 Range 和 If-Range 请求继续下载（仅单连接下载），服务端文件已变化或未返回 ETag/Last-Modified 时从头下载
+	OnProgress     DownloadProgressFunc // 下载进度回调
+}
+
+// DownloadOption 下载选项
+type DownloadOption func(opts *downloadOptions)
+
+// downloadOptions 下载选项
+type downloadOptions struct {
+	algorithm ChecksumAlgorithm
+	checksum  string
+}
+
+// WithChecksum 设置文件校验值（十六进制），下载完成后校验失败时删除临时文件并返回 ErrChecksumMismatch
+func WithChecksum(algorithm ChecksumAlgorithm, checksum string) (opt DownloadOption) {
+	return func(opts *downloadOptions) {
+		opts.algorithm = algorithm
+		opts.checksum = strings.ToLower(checksum)
+	}
+}
+
+// DownloadResult 下载结果
+type DownloadResult struct {
+	FilePath string // 文件路径
+	Size     int64  // 文件大小
+	Resumed  bool   // 是否为断点续传
+	Segments int    // 分段数，单连接下载时为 1
+}
+
+// Downloader 文件下载器
+//
+//	下载时先写入同目录下的临时文件（文件路径 + .download），下载并校验完成后原子重命名为目标文件，下载失败不会留下不完整的目标文件
+type Downloader struct {
+	config DownloaderConfig
+}
+
+// NewDownloader 新建文件下载器
+func NewDownloader(config DownloaderConfig) (d *Downloader) {
+	if config.HTTPClient == nil {
+		config.HTTPClient = NewDefaultHTTPDoer(0)
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	if config.MinSegmentSize <= 0 {
+		config.MinSegmentSize = defaultMinSegmentSize
+	}
+	return &Downloader{
+		config: config,
+	}
+}
+
+// Download 下载文件到指定路径
+func (d *Downloader) Download(ctx context.Context, url, filePath string, opts ...DownloadOption) (result DownloadResult, err error) {
+	options := &downloadOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.algorithm != "" {
+		if _, err = newChecksumHash(options.algorithm); err != nil {
+			return
+		}
+	}
+	// 创建目录
+	if err = utils.MakeDirAll(filepath.Dir(filePath)); err != nil {
+		err = fmt.Errorf("failed to create directory: %s, error: %w", filepath.Dir(filePath), err)
+		return
+	}
+
+	tempPath := filePath + downloadTempSuffix
+	validatorPath := tempPath + validatorSuffix
+	result = DownloadResult{FilePath: filePath, Segments: 1}
+	// 分段并行下载
+	var total int64 = -1
+	if d.config.Concurrency > 1 {
+		var acceptRanges bool
+		if total, acceptRanges, err = d.probe(ctx, url); err != nil {
+			return
+		}
+		if segments := d.segmentCount(total); acceptRanges && segments > 1 {
+			result.Segments = segments
+			os.Remove(validatorPath)
+			if err = d.downloadSegments(ctx, url, tempPath, total, segments); err != nil {
+				os.Remove(tempPath)
+				return
+			}
+		}
+	}
+	// 单连接下载
+	if result.Segments == 1 {
+		if result.Resumed, err = d.downloadSingle(ctx, url, tempPath); err != nil {
+			if !d.config.Resume {
+				os.Remove(tempPath)
+				os.Remove(validatorPath)
+			}
+			return
+		}
+	}
+	// 校验文件
+	if options.algorithm != "" {
+		if err = verifyChecksum(tempPath, options.algorithm, options.checksum); err != nil {
+			os.Remove(tempPath)
+			os.Remove(validatorPath)
+			return
+		}
+	}
+	// 原子重命名
+	var info os.FileInfo
+	if info, err = os.Stat(tempPath); err != nil {
+		return
+	}
+	result.Size = info.Size()
+	if err = os.Rename(tempPath, filePath); err != nil {
+		err = fmt.Errorf("failed to rename file: %s, error: %w", filePath, err)
+		return
+	}
+	os.Remove(validatorPath)
+	return
+}
+
+// newRequest 新建下载请求
+func (d *Downloader) newRequest(ctx context.Context, method, url string) (req *http.Request, err error) {
+	if req, err = http.NewRequestWithContext(ctx, method, url, nil); err != nil {
+		return
+	}
+	for k, v := range d.config.Header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	return
+}
+
+// probe 探测文件大小以及服务端是否支持 Range 请求
+func (d *Downloader) probe(ctx context.Context, url string) (total int64, acceptRanges bool, err error) {
+	var req *http.Request
+	if req, err = d.newRequest(ctx, http.MethodHead, url); err != nil {
+		return
+	}
+	var resp *http.Response
+	if resp, err = d.config.HTTPClient.Do(req); err != nil {
+		err = fmt.Errorf("failed to http head: %s, error: %w", url, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// 不支持 HEAD 请求时回退为单连接下载
+		return -1, false, nil
+	}
+	total = resp.ContentLength
+	if err = d.checkSize(total); err != nil {
+		return
+	}
+	acceptRanges = strings.EqualFold(resp.Header.Get("Accept-Ranges"), "bytes") && total > 0
+	return
+}
+
+// segmentCount 计算分段数
+func (d *Downloader) segmentCount(total int64) (segments int) {
+	if total <= 0 {
+		return 1
+	}
+	segments = int(min(int64(d.config.Concurrency), total/d.config.MinSegmentSize))
+	return max(segments, 1)
+}
+
+// downloadSingle 单连接下载，开启断点续传时从临时文件末尾继续下载
+//
+//	续传请求携带 If-Range，服务端文件已变化时返回完整文件，避免将新数据拼接到旧数据之后
+func (d *Downloader) downloadSingle(ctx context.Context, url, tempPath string) (resumed bool, err error) {
+	var (
+		offset        int64
+		validator     string
+		validatorPath = tempPath + validatorSuffix
+	)
+	if d.config.Resume {
+		// 没有校验器时无法判断临时文件是否过期，从头下载
+		if data, e := os.ReadFile(validatorPath); e == nil && len(data) > 0 {
+			if info, e := os.Stat(tempPath); e == nil {
+				offset, validator = info.Size(), string(data)
+			}
+		}
+	}
+
+	var req *http.Request
+	if req, err = d.newRequest(ctx, http.MethodGet, url); err != nil {
+		return
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+	var resp *http.Response
+	if resp, err = d.config.HTTPClient.Do(req); err != nil {
+		err = fmt.Errorf("failed to http get: %s, error: %w", url, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	var total int64 = -1
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// 服务端不支持 Range 请求或文件已变化时从头下载，覆盖临时文件
+		offset = 0
+		total = resp.ContentLength
+		if d.config.Resume {
+			if err = writeValidator(validatorPath, resp.Header); err != nil {
+				return
+			}
+		}
+	case http.StatusPartialContent:
+		var start int64
+		if start, total, err = parseContentRange(resp.Header.Get("Content-Range")); err != nil {
+			return
+		}
+		if start != offset {
+			err = fmt.Errorf("failed to http get: %s, unexpected content range: %s", url, resp.Header.Get("Content-Range"))
+			return
+		}
+		resumed = true
+	case http.StatusRequestedRangeNotSatisfiable:
+		// 临时文件已经完整
+		if _, size, e := parseContentRange(resp.Header.Get("Content-Range")); e == nil && size == offset {
+			return true, d.checkSize(offset)
+		}
+		os.Remove(tempPath)
+		os.Remove(validatorPath)
+		err = fmt.Errorf("failed to http get: %s, status: %s", url, resp.Status)
+		return
+	default:
+		err = fmt.Errorf("failed to http get: %s, status: %s", url, resp.Status)
+		return
+	}
+	if err = d.checkSize(total); err != nil {
+		return
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flag = os.O_WRONLY | os.O_APPEND
+	}
+	var outFile *os.File
+	if outFile, err = os.OpenFile(tempPath, flag, 0644); err != nil {
+		err = fmt.Errorf("failed to create file: %s, error: %w", tempPath, err)
+		return
+	}
+	defer outFile.Close()
+
+	var downloaded atomic.Int64
+	downloaded.Store(offset)
+	if err = d.copy(outFile, resp.Body, &downloaded, total); err != nil {
+		err = fmt.Errorf("failed to write file: %s, error: %w", tempPath, err)
+		return
+	}
+	if total >= 0 && downloaded.Load() != total {
+		err = fmt.Errorf("failed to write file: %s, error: %w", tempPath, io.ErrUnexpectedEOF)
+	}
+	return
+}
+
+// writeValidator 保存响应的校验器，用于续传时的 If-Range 请求，优先使用强 ETag，弱 ETag 不能用于 If-Range
+func writeValidator(validatorPath string, header http.Header) (err error) {
+	validator := header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = header.Get("Last-Modified")
+	}
+	if validator == "" {
+		if err = os.Remove(validatorPath); os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	if err = os.WriteFile(validatorPath, []byte(validator), 0644); err != nil {
+		err = fmt.Errorf("failed to create file: %s, error: %w", validatorPath, err)
+	}
+	return
+}
+
+// downloadSegments 分段并行下载
+func (d *Downloader) downloadSegments(ctx context.Context, url, tempPath string, total int64, segments int) (err error) {
+	var outFile *os.File
+	if outFile, err = os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644); err != nil {
+		return fmt.Errorf("failed to create file: %s, error: %w", tempPath, err)
+	}
+	defer outFile.Close()
+	if err = outFile.Truncate(total); err != nil {
+		return fmt.Errorf("failed to truncate file: %s, error: %w", tempPath, err)
+	}
+
+	var (
+		downloaded  atomic.Int64
+		segmentSize = total / int64(segments)
+		g, gctx     = errgroup.WithContext(ctx)
+	)
+	for i := range segments {
+		start := int64(i) * segmentSize
+		end := start + segmentSize - 1
+		if i == segments-1 {
+			end = total - 1
+		}
+		g.Go(func() (err error) {
+			return d.downloadSegment(gctx, url, outFile, start, end, &downloaded, total)
+		})
+	}
+	return g.Wait()
+}
+
+// downloadSegment 下载单个分段
+func (d *Downloader) downloadSegment(ctx context.Context, url string, outFile *os.File, start, end int64, downloaded *atomic.Int64, total int64) (err error) {
+	var req *http.Request
+	if req, err = d.newRequest(ctx, http.MethodGet, url); err != nil {
+		return
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	var resp *http.Response
+	if resp, err = d.config.HTTPClient.Do(req); err != nil {
+		return fmt.Errorf("failed to http get: %s, error: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("failed to http get: %s, range: %d-%d, status: %s", url, start, end, resp.Status)
+	}
+
+	w := io.NewOffsetWriter(outFile, start)
+	var n int64
+	if n, err = io.Copy(&progressWriter{w: w, downloaded: downloaded, total: total, onProgress: d.config.OnProgress}, io.LimitReader(resp.Body, end-start+1)); err != nil {
+		return fmt.Errorf("failed to write file: %s, error: %w", outFile.Name(), err)
+	}
+	if n != end-start+1 {
+		return fmt.Errorf("failed to write file: %s, range: %d-%d, error: %w", outFile.Name(), start, end, io.ErrUnexpectedEOF)
+	}
+	return
+}
+
+// copy 复制数据并限制文件大小
+func (d *Downloader) copy(w io.Writer, r io.Reader, downloaded *atomic.Int64, total int64) (err error) {
+	if d.config.MaxSize > 0 {
+		r = io.LimitReader(r, d.config.MaxSize-downloaded.Load()+1)
+	}
+	if _, err = io.Copy(&progressWriter{w: w, downloaded: downloaded, total: total, onProgress: d.config.OnProgress}, r); err != nil {
+		return
+	}
+	return d.checkSize(downloaded.Load())
+}
+
+// checkSize 检查文件大小
+func (d *Downloader) checkSize(size int64) (err error) {
+	if d.config.MaxSize > 0 && size > d.config.MaxSize {
+		return fmt.Errorf("%w: %d > %d", ErrDownloadTooLarge, size, d.config.MaxSize)
+	}
+	return
+}
+
+// progressWriter 记录下载进度的写入器
+type progressWriter struct {
+	w          io.Writer
+	downloaded *atomic.Int64
+	total      int64
+	onProgress DownloadProgressFunc
+}
+
+// Write 写入数据
+func (pw *progressWriter) Write(p []byte) (n int, err error) {
+	n, err = pw.w.Write(p)
+	downloaded := pw.downloaded.Add(int64(n))
+	if pw.onProgress != nil && n > 0 {
+		pw.onProgress(downloaded, pw.total)
+	}
+	return
+}
+
+// parseContentRange 解析响应头 Content-Range，格式为 bytes start-end/total 或 bytes */total，total 未知时为 -1
+func parseContentRange(value string) (start, total int64, err error) {
+	rangeSpec, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		err = fmt.Errorf("invalid content range: %s", value)
+		return
+	}
+	rangePart, totalPart, ok := strings.Cut(rangeSpec, "/")
+	if !ok {
+		err = fmt.Errorf("invalid content range: %s", value)
+		return
+	}
+	total = -1
+	if totalPart != "*" {
+		if total, err = strconv.ParseInt(totalPart, 10, 64); err != nil {
+			return
+		}
+	}
+	if rangePart != "*" {
+		startPart, _, _ := strings.Cut(rangePart, "-")
+		if start, err = strconv.ParseInt(startPart, 10, 64); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// newChecksumHash 新建校验算法
+func newChecksumHash(algorithm ChecksumAlgorithm) (h hash.Hash, err error) {
+	switch algorithm {
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumMD5:
+		return md5.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+	}
+}
+
+// verifyChecksum 校验文件
+func verifyChecksum(filePath string, algorithm ChecksumAlgorithm, checksum string) (err error) {
+	var h hash.Hash
+	if h, err = newChecksumHash(algorithm); err != nil {
+		return
+	}
+	var f *os.File
+	if f, err = os.Open(filePath); err != nil {
+		return
+	}
+	defer f.Close()
+	if _, err = io.Copy(h, f); err != nil {
+		return
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != checksum {
+		return fmt.Errorf("%w: %s expected %s, got %s", ErrChecksumMismatch, algorithm, checksum, actual)
+	}
+	return
+}
@@ -0,0 +1,212 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-21 11:52:09
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-21 11:52:09
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingDoer 记录请求的 HTTP 请求执行器
+type recordingDoer struct {
+	mu     sync.Mutex
+	doer   gtkhttp.HTTPDoer
+	ranges []string
+}
+
+func (d *recordingDoer) SetTimeout(timeout time.Duration) {
+	d.doer.SetTimeout(timeout)
+}
+
+func (d *recordingDoer) Do(req *http.Request) (resp *http.Response, err error) {
+	d.mu.Lock()
+	d.ranges = append(d.ranges, req.Method+" "+req.Header.Get("Range"))
+	d.mu.Unlock()
+	return d.doer.Do(req)
+}
+
+func newFileServer(content []byte) (server *httptest.Server) {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+}
+
+func TestDownloader(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		ctx      = context.Background()
+		content  = bytes.Repeat([]byte("0123456789"), 1000)
+		server   = newFileServer(content)
+		doer     = &recordingDoer{doer: gtkhttp.NewDefaultHTTPDoer(0)}
+		filePath = filepath.Join(t.TempDir(), "a", "file.bin")
+		sum      = sha256.Sum256(content)
+		progress int64
+	)
+	defer server.Close()
+
+	result, err := gtkhttp.NewDownloader(gtkhttp.DownloaderConfig{
+		HTTPClient: doer,
+		OnProgress: func(downloaded, total int64) {
+			assert.Equal(int64(len(content)), total)
+			progress = downloaded
+		},
+	}).Download(ctx, server.URL, filePath, gtkhttp.WithChecksum(gtkhttp.ChecksumSHA256, hex.EncodeToString(sum[:])))
+	assert.NoError(err)
+	assert.Equal(gtkhttp.DownloadResult{FilePath: filePath, Size: int64(len(content)), Segments: 1}, result)
+	assert.Equal(int64(len(content)), progress)
+	assert.Equal([]string{"GET "}, doer.ranges)
+	data, _ := os.ReadFile(filePath)
+	assert.Equal(content, data)
+	assert.NoFileExists(filePath + ".download")
+}
+
+func TestDownloaderSegments(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		content  = bytes.Repeat([]byte("abcdefghij"), 1000)
+		server   = newFileServer(content)
+		doer     = &recordingDoer{doer: gtkhttp.NewDefaultHTTPDoer(0)}
+		filePath = filepath.Join(t.TempDir(), "file.bin")
+		sum      = md5.Sum(content)
+	)
+	defer server.Close()
+
+	result, err := gtkhttp.NewDownloader(gtkhttp.DownloaderConfig{
+		HTTPClient:     doer,
+		Concurrency:    4,
+		MinSegmentSize: 3000,
+	}).Download(context.Background(), server.URL, filePath, gtkhttp.WithChecksum(gtkhttp.ChecksumMD5, hex.EncodeToString(sum[:])))
+	assert.NoError(err)
+	// 分段数受最小分段大小限制
+	assert.Equal(3, result.Segments)
+	assert.ElementsMatch([]string{"HEAD ", "GET bytes=0-3332", "GET bytes=3333-6665", "GET bytes=6666-9999"}, doer.ranges)
+	data, _ := os.ReadFile(filePath)
+	assert.Equal(content, data)
+}
+
+// newResumableFileServer 支持 ETag 的文件服务，interrupt 为 true 时完整下载请求只返回前 400 字节后断开连接
+func newResumableFileServer(content *[]byte, etag *string, interrupt *atomic.Bool) (server *httptest.Server) {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", *etag)
+		if interrupt.Load() && r.Header.Get("Range") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(*content)))
+			w.Write((*content)[:400])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(*content))
+	}))
+}
+
+func TestDownloaderResume(t *testing.T) {
+	var (
+		assert     = assert.New(t)
+		content    = bytes.Repeat([]byte("0123456789"), 100)
+		etag       = `"v1"`
+		interrupt  atomic.Bool
+		server     = newResumableFileServer(&content, &etag, &interrupt)
+		doer       = &recordingDoer{doer: gtkhttp.NewDefaultHTTPDoer(0)}
+		filePath   = filepath.Join(t.TempDir(), "file.bin")
+		downloader = gtkhttp.NewDownloader(gtkhttp.DownloaderConfig{
+			HTTPClient: doer,
+			Resume:     true,
+		})
+	)
+	defer server.Close()
+
+	// 下载中断，保留临时文件
+	interrupt.Store(true)
+	_, err := downloader.Download(context.Background(), server.URL, filePath)
+	assert.Error(err)
+	data, _ := os.ReadFile(filePath + ".download")
+	assert.Equal(content[:400], data)
+	// 继续下载
+	interrupt.Store(false)
+	doer.ranges = nil
+	result, err := downloader.Download(context.Background(), server.URL, filePath)
+	assert.NoError(err)
+	assert.True(result.Resumed)
+	assert.Equal([]string{"GET bytes=400-"}, doer.ranges)
+	data, _ = os.ReadFile(filePath)
+	assert.Equal(content, data)
+	assert.NoFileExists(filePath + ".download")
+	assert.NoFileExists(filePath + ".download.validator")
+
+	// 中断后服务端文件已变化，从头下载而不是拼接到旧数据之后
+	interrupt.Store(true)
+	_, err = downloader.Download(context.Background(), server.URL, filePath)
+	assert.Error(err)
+	interrupt.Store(false)
+	content, etag = bytes.Repeat([]byte("abcdefghij"), 120), `"v2"`
+	doer.ranges = nil
+	result, err = downloader.Download(context.Background(), server.URL, filePath)
+	assert.NoError(err)
+	assert.False(result.Resumed)
+	assert.Equal([]string{"GET bytes=400-"}, doer.ranges)
+	data, _ = os.ReadFile(filePath)
+	assert.Equal(content, data)
+
+	// 没有校验器时无法判断临时文件是否过期，从头下载
+	assert.NoError(os.WriteFile(filePath+".download", []byte("stale"), 0644))
+	doer.ranges = nil
+	result, err = downloader.Download(context.Background(), server.URL, filePath)
+	assert.NoError(err)
+	assert.False(result.Resumed)
+	assert.Equal([]string{"GET "}, doer.ranges)
+	data, _ = os.ReadFile(filePath)
+	assert.Equal(content, data)
+}
+
+func TestDownloaderErrors(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		ctx      = context.Background()
+		content  = bytes.Repeat([]byte("x"), 2048)
+		server   = newFileServer(content)
+		filePath = filepath.Join(t.TempDir(), "file.bin")
+	)
+	defer server.Close()
+
+	// 文件大小超出限制
+	_, err := gtkhttp.NewDownloader(gtkhttp.DownloaderConfig{MaxSize: 1024}).Download(ctx, server.URL, filePath)
+	assert.True(gtkhttp.IsDownloadTooLargeError(err))
+	assert.NoFileExists(filePath)
+	assert.NoFileExists(filePath + ".download")
+	// 校验失败
+	_, err = gtkhttp.NewDownloader(gtkhttp.DownloaderConfig{}).Download(ctx, server.URL, filePath, gtkhttp.WithChecksum(gtkhttp.ChecksumSHA256, "00"))
+	assert.True(gtkhttp.IsChecksumMismatchError(err))
+	assert.NoFileExists(filePath)
+	assert.NoFileExists(filePath + ".download")
+	// 上下文取消
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = gtkhttp.NewDownloader(gtkhttp.DownloaderConfig{}).Download(cancelCtx, server.URL, filePath)
+	assert.ErrorIs(err, context.Canceled)
+	assert.NoFileExists(filePath)
+	// 兼容旧接口
+	filePath, err = gtkhttp.DownloadFile(server.URL+"/file.bin", t.TempDir())
+	assert.NoError(err)
+	assert.Equal("file.bin", filepath.Base(filePath))
+	assert.FileExists(filePath)
+}
@@ -16,6 +16,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 )
 
 var (
@@ -23,18 +24,25 @@ var (
 	ErrCompletionStreamNotSupported = errors.New("streaming is not supported with this method, please use CreateChatCompletionStream") // 流式传输不支持
 	ErrTooManyEmptyStreamMessages   = errors.New("stream has sent too many empty messages")                                            // 流式传输发送了太多空消息
 	ErrStreamReturnIntervalTimeout  = errors.New("stream return interval timeout")                                                     // 流式传输返回间隔超时
+	ErrCircuitOpen                  = errors.New("circuit breaker is open")                                                            // 熔断器已打开
+	ErrRateLimited                  = errors.New("rate limit exceeded")                                                                // 超出限流配额
+	ErrBulkheadFull                 = errors.New("bulkhead is full")                                                                   // 并发数已满
+	ErrDownloadTooLarge             = errors.New("download size exceeds limit")                                                        // 下载文件大小超出限制
+	ErrChecksumMismatch             = errors.New("checksum mismatch")                                                                  // 文件校验失败
 )
 
 // APIError API错误信息
 type APIError struct {
-	Code           any         `json:"code,omitempty"`
-	Message        string      `json:"message"`
-	RequestId      string      `json:"request_id,omitempty"`
-	Param          *string     `json:"param,omitempty"`
-	Type           string      `json:"type"`
-	HTTPStatus     string      `json:"-"`
-	HTTPStatusCode int         `json:"-"`
-	InnerError     *InnerError `json:"innererror,omitempty"`
+	Code           any           `json:"code,omitempty"`
+	Message        string        `json:"message"`
+	RequestId      string        `json:"request_id,omitempty"`
+	Param          *string       `json:"param,omitempty"`
+	Type           string        `json:"type"`
+	HTTPStatus     string        `json:"-"`
+	HTTPStatusCode int           `json:"-"`
+	InnerError     *InnerError   `json:"innererror,omitempty"`
+	retryAfter     time.Duration // 响应头 Retry-After 指定的等待时间，通过 Classify 获取
+	requestID      string        // 响应头或上下文中的请求ID，通过 Classify 获取
 }
 
 // InnerError 内部错误信息
@@ -91,10 +99,12 @@ type ContentFilterResults struct {
 
 // RequestError 请求错误
 type RequestError struct {
-	HTTPStatus     string // HTTP 状态描述
-	HTTPStatusCode int    // HTTP 状态码
-	Err            error  // 错误信息
-	Body           []byte // 响应体
+	HTTPStatus     string        // HTTP 状态描述
+	HTTPStatusCode int           // HTTP 状态码
+	Err            error         // 错误信息
+	Body           []byte        // 响应体
+	retryAfter     time.Duration // 响应头 Retry-After 指定的等待时间，通过 Classify 获取
+	requestID      string        // 响应头或上下文中的请求ID，通过 Classify 获取
 }
 
 // ErrorResponse 错误响应
@@ -198,6 +208,36 @@ func IsStreamReturnIntervalTimeoutError(err error) (is bool) {
 	return errors.Is(err, ErrStreamReturnIntervalTimeout)
 }
 
+// IsCircuitOpenError 判断是否是熔断器已打开错误
+func IsCircuitOpenError(err error) (is bool) {
+	return errors.Is(err, ErrCircuitOpen)
+}
+
+// IsRateLimitedError 判断是否是超出限流配额错误
+func IsRateLimitedError(err error) (is bool) {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsBulkheadFullError 判断是否是并发数已满错误
+func IsBulkheadFullError(err error) (is bool) {
+	return errors.Is(err, ErrBulkheadFull)
+}
+
+// IsDownloadTooLargeError 判断是否是下载文件大小超出限制错误
+func IsDownloadTooLargeError(err error) (is bool) {
+	return errors.Is(err, ErrDownloadTooLarge)
+}
+
+// IsChecksumMismatchError 判断是否是文件校验失败错误
+func IsChecksumMismatchError(err error) (is bool) {
+	return errors.Is(err, ErrChecksumMismatch)
+}
+
+// isLocalRejectionError 判断是否是本地拒绝的错误（熔断、限流、并发数已满），这类错误没有请求上游，不应重试或计入熔断失败
+func isLocalRejectionError(err error) (is bool) {
+	return IsCircuitOpenError(err) || IsRateLimitedError(err) || IsBulkheadFullError(err)
+}
+
 // IsCanceledError 判断是否是取消错误
 func IsCanceledError(err error) (is bool) {
 	return errors.Is(err, context.Canceled)
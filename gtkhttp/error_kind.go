@@ -0,0 +1,240 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 17:52:09
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 17:52:09
+ * @Description: 错误分类
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// ErrorKind 错误类型
+type ErrorKind string
+
+const (
+	ErrorKindUnknown         ErrorKind = "unknown"          // 未知错误
+	ErrorKindTimeout         ErrorKind = "timeout"          // 超时，包括请求超时和 408、504 等状态码
+	ErrorKindRateLimited     ErrorKind = "rate_limited"     // 被限流，包括本地限流和 429 状态码
+	ErrorKindAuth            ErrorKind = "auth"             // 认证或鉴权失败，401、403、407 状态码
+	ErrorKindNotFound        ErrorKind = "not_found"        // 资源不存在，404 状态码
+	ErrorKindClient          ErrorKind = "client"           // 其他客户端错误，如 400、422 状态码
+	ErrorKindServer          ErrorKind = "server"           // 服务端错误，5xx 状态码
+	ErrorKindNetwork         ErrorKind = "network"          // 网络错误
+	ErrorKindCanceled        ErrorKind = "canceled"         // 请求被取消
+	ErrorKindContentFiltered ErrorKind = "content_filtered" // 内容被过滤
+	ErrorKindDecode          ErrorKind = "decode"           // 响应解析失败
+	ErrorKindRejected        ErrorKind = "rejected"         // 被本地拒绝（熔断器已打开、并发数已满）
+)
+
+// TypedError 分类后的错误，HTTPClient 返回的响应解析错误会包装为 TypedError
+//
+//	错误响应仍然返回 *APIError 或 *RequestError，通过 Classify、AsTypedError 获取包括 Retry-After、请求ID 在内的分类结果
+type TypedError struct {
+	Kind       ErrorKind     // 错误类型
+	StatusCode int           // HTTP 状态码，非 HTTP 错误时为 0
+	RetryAfter time.Duration // 响应头 Retry-After 指定的等待时间，未指定时为 0
+	RequestID  string        // 请求ID，优先使用服务端返回的请求ID
+	Err        error         // 原始错误
+}
+
+// Error 实现 error 接口的方法
+func (e *TypedError) Error() (s string) {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return string(e.Kind)
+}
+
+// Unwrap 解包错误
+func (e *TypedError) Unwrap() (err error) {
+	return e.Err
+}
+
+// Temporary 是否为临时错误，临时错误重试后可能成功
+func (e *TypedError) Temporary() (ok bool) {
+	switch e.Kind {
+	case ErrorKindTimeout, ErrorKindRateLimited, ErrorKindNetwork:
+		return true
+	case ErrorKindServer:
+		return slices.Contains(retryableHTTPStatusCodes, e.StatusCode)
+	default:
+		return false
+	}
+}
+
+// AsTypedError 获取错误的分类结果，错误链中包含 *TypedError 时直接返回，否则根据错误内容分类
+func AsTypedError(err error) (te *TypedError, ok bool) {
+	if err == nil {
+		return nil, false
+	}
+	return Classify(err), true
+}
+
+// ErrorKindOf 获取错误类型，err 为 nil 时返回空字符串
+func ErrorKindOf(err error) (kind ErrorKind) {
+	if err == nil {
+		return
+	}
+	return Classify(err).Kind
+}
+
+// IsErrorKind 判断错误是否属于指定的错误类型之一
+func IsErrorKind(err error, kinds ...ErrorKind) (is bool) {
+	return err != nil && slices.Contains(kinds, ErrorKindOf(err))
+}
+
+// Classify 对错误进行分类，err 为 nil 时返回 nil
+func Classify(err error) (te *TypedError) {
+	if err == nil {
+		return nil
+	}
+	if errors.As(err, &te) {
+		return
+	}
+	te = &TypedError{Kind: ErrorKindUnknown, Err: err}
+	var clientErr *MWClientError
+	if errors.As(err, &clientErr) {
+		te.RequestID = clientErr.RequestID
+	}
+	var (
+		apiError     *APIError
+		requestError *RequestError
+		netErr       net.Error
+	)
+	switch {
+	case errors.As(err, &apiError):
+		te.StatusCode, te.RetryAfter = apiError.HTTPStatusCode, apiError.retryAfter
+		if apiError.RequestId != "" {
+			te.RequestID = apiError.RequestId
+		} else if apiError.requestID != "" {
+			te.RequestID = apiError.requestID
+		}
+		te.Kind = errorKindOfAPIError(apiError)
+	case errors.As(err, &requestError):
+		te.StatusCode, te.RetryAfter = requestError.HTTPStatusCode, requestError.retryAfter
+		if requestError.requestID != "" {
+			te.RequestID = requestError.requestID
+		}
+		te.Kind = errorKindOfStatus(requestError.HTTPStatusCode)
+	case errors.Is(err, context.Canceled):
+		te.Kind = ErrorKindCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrStreamReturnIntervalTimeout):
+		te.Kind = ErrorKindTimeout
+	case errors.Is(err, ErrRateLimited):
+		te.Kind = ErrorKindRateLimited
+	case errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrBulkheadFull):
+		te.Kind = ErrorKindRejected
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			te.Kind = ErrorKindTimeout
+		} else {
+			te.Kind = ErrorKindNetwork
+		}
+	case isDecodeError(err):
+		te.Kind = ErrorKindDecode
+	}
+	return
+}
+
+// responseMeta 获取错误响应的 Retry-After 和请求ID，请求ID 优先使用响应头 X-Request-Id，其次使用上下文中的请求ID
+func responseMeta(resp *http.Response) (retryAfter time.Duration, requestID string) {
+	retryAfter, _ = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if requestID = resp.Header.Get("X-Request-Id"); requestID == "" && resp.Request != nil {
+		if requestInfo := GetRequestInfo(resp.Request.Context()); requestInfo.RequestID != "unknown" {
+			requestID = requestInfo.RequestID
+		}
+	}
+	return
+}
+
+// newResponseError 根据响应创建分类后的错误
+func newResponseError(resp *http.Response, err error) (te *TypedError) {
+	te = Classify(err)
+	retryAfter, requestID := responseMeta(resp)
+	if retryAfter > 0 {
+		te.RetryAfter = retryAfter
+	}
+	if te.RequestID == "" {
+		te.RequestID = requestID
+	}
+	return
+}
+
+// newDecodeError 根据响应解析错误创建分类后的错误，无法识别的解析错误归类为 ErrorKindDecode
+func newDecodeError(resp *http.Response, err error) (te *TypedError) {
+	te = newResponseError(resp, err)
+	te.StatusCode = resp.StatusCode
+	if te.Kind == ErrorKindUnknown {
+		te.Kind = ErrorKindDecode
+	}
+	return
+}
+
+// errorKindOfAPIError 获取 API 错误的错误类型，内容过滤优先于状态码
+func errorKindOfAPIError(apiError *APIError) (kind ErrorKind) {
+	if apiError.Code == "content_filter" || apiError.Type == "content_filter" {
+		return ErrorKindContentFiltered
+	}
+	if inner := apiError.InnerError; inner != nil {
+		if inner.Code == "ResponsibleAIPolicyViolation" || inner.ContentFilterResults.filtered() {
+			return ErrorKindContentFiltered
+		}
+	}
+	return errorKindOfStatus(apiError.HTTPStatusCode)
+}
+
+// errorKindOfStatus 获取 HTTP 状态码对应的错误类型
+func errorKindOfStatus(statusCode int) (kind ErrorKind) {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusProxyAuthRequired:
+		return ErrorKindAuth
+	case http.StatusNotFound:
+		return ErrorKindNotFound
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout, 524, 598, 599:
+		return ErrorKindTimeout
+	case http.StatusTooManyRequests:
+		return ErrorKindRateLimited
+	}
+	switch {
+	case statusCode >= http.StatusInternalServerError:
+		return ErrorKindServer
+	case statusCode >= http.StatusBadRequest:
+		return ErrorKindClient
+	default:
+		return ErrorKindUnknown
+	}
+}
+
+// filtered 是否有内容被过滤
+func (r *ContentFilterResults) filtered() (ok bool) {
+	if r == nil {
+		return false
+	}
+	return (r.Hate != nil && r.Hate.Filtered) ||
+		(r.SelfHarm != nil && r.SelfHarm.Filtered) ||
+		(r.Sexual != nil && r.Sexual.Filtered) ||
+		(r.Violence != nil && r.Violence.Filtered) ||
+		(r.JailBreak != nil && r.JailBreak.Filtered) ||
+		(r.Profanity != nil && r.Profanity.Filtered)
+}
+
+// isDecodeError 判断是否是响应解析错误
+func isDecodeError(err error) (is bool) {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,144 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 17:52:09
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 17:52:09
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/limited":
+			w.Header().Set("Retry-After", "2")
+			w.Header().Set("X-Request-Id", "upstream-id")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"message":"slow down","type":"rate_limit"}}`))
+		case "/filtered":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"filtered","type":"invalid_request_error","request_id":"body-id","innererror":{"content_filter_result":{"hate":{"filtered":true}}}}}`))
+		case "/unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("unauthorized"))
+		case "/decode":
+			w.Write([]byte(`{"message":`))
+		}
+	}))
+	defer server.Close()
+
+	client := gtkhttp.NewHTTPClient(server.URL)
+	send := func(path string) (err error) {
+		req, err := client.NewRequest(ctx, http.MethodGet, client.FullURL(path))
+		if err != nil {
+			return
+		}
+		return client.SendRequest(req, &interceptorResponse{})
+	}
+	// 限流错误携带状态码、Retry-After 和服务端请求ID
+	err := send("/limited")
+	te, ok := gtkhttp.AsTypedError(err)
+	if assert.True(ok) {
+		assert.Equal(gtkhttp.ErrorKindRateLimited, te.Kind)
+		assert.Equal(http.StatusTooManyRequests, te.StatusCode)
+		assert.Equal(2*time.Second, te.RetryAfter)
+		assert.Equal("upstream-id", te.RequestID)
+		assert.True(te.Temporary())
+	}
+	// 错误响应仍然返回具体的错误类型
+	apiErr, ok := err.(*gtkhttp.APIError)
+	if assert.True(ok) {
+		assert.Equal("slow down", apiErr.Message)
+	}
+	// 内容过滤优先于状态码
+	err = send("/filtered")
+	assert.True(gtkhttp.IsErrorKind(err, gtkhttp.ErrorKindContentFiltered))
+	assert.Equal("body-id", gtkhttp.Classify(err).RequestID)
+	// 无法解析的错误响应
+	err = send("/unauthorized")
+	_, ok = err.(*gtkhttp.RequestError)
+	assert.True(ok)
+	assert.Equal(gtkhttp.ErrorKindAuth, gtkhttp.ErrorKindOf(err))
+	// 响应解析失败
+	err = send("/decode")
+	assert.Equal(gtkhttp.ErrorKindDecode, gtkhttp.ErrorKindOf(err))
+	assert.False(gtkhttp.Classify(err).Temporary())
+
+	// 非 HTTP 错误
+	for _, tt := range []struct {
+		err  error
+		kind gtkhttp.ErrorKind
+	}{
+		{context.Canceled, gtkhttp.ErrorKindCanceled},
+		{fmt.Errorf("wrap: %w", context.DeadlineExceeded), gtkhttp.ErrorKindTimeout},
+		{gtkhttp.ErrRateLimited, gtkhttp.ErrorKindRateLimited},
+		{gtkhttp.ErrCircuitOpen, gtkhttp.ErrorKindRejected},
+		{&gtkhttp.APIError{HTTPStatusCode: http.StatusNotFound}, gtkhttp.ErrorKindNotFound},
+		{&gtkhttp.APIError{HTTPStatusCode: http.StatusBadGateway}, gtkhttp.ErrorKindServer},
+		{&gtkhttp.RequestError{HTTPStatusCode: http.StatusGatewayTimeout}, gtkhttp.ErrorKindTimeout},
+		{&gtkhttp.RequestError{HTTPStatusCode: http.StatusUnprocessableEntity}, gtkhttp.ErrorKindClient},
+		{errors.New("boom"), gtkhttp.ErrorKindUnknown},
+	} {
+		assert.Equal(tt.kind, gtkhttp.ErrorKindOf(tt.err), tt.err.Error())
+	}
+	assert.Nil(gtkhttp.Classify(nil))
+	assert.Empty(gtkhttp.ErrorKindOf(nil))
+	// 网络错误
+	server.Close()
+	err = send("/limited")
+	assert.Equal(gtkhttp.ErrorKindNetwork, gtkhttp.ErrorKindOf(err))
+	// MWClientError 携带请求ID
+	mwClient := gtkhttp.NewMWClient(gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}))
+	_, err = mwClient.HandlerRequest(ctx, "user", "Chat", nil, func(ctx context.Context, _ any) (resp any, err error) {
+		return nil, context.Canceled
+	})
+	te = gtkhttp.Classify(err)
+	assert.Equal(gtkhttp.ErrorKindCanceled, te.Kind)
+	assert.Equal("test-request-id", te.RequestID)
+}
+
+func TestMetricsErrorLabels(t *testing.T) {
+	var (
+		assert    = assert.New(t)
+		ctx       = context.Background()
+		collector = gtkhttp.NewDefaultMetricsCollector()
+		client    = gtkhttp.NewMWClient(
+			gtkhttp.WithMetrics(gtkhttp.MetricsMiddlewareConfig{Collector: collector}),
+			gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+		)
+	)
+	// 错误指标的标签不随错误分类变化
+	for _, err := range []error{
+		&gtkhttp.APIError{HTTPStatusCode: http.StatusTooManyRequests},
+		&gtkhttp.RequestError{HTTPStatusCode: http.StatusBadGateway},
+		&net.OpError{Op: "dial", Err: errors.New("connection refused")},
+		errors.New("boom"),
+	} {
+		client.HandlerRequest(ctx, "user", "Chat", nil, func(ctx context.Context, req any) (resp any, e error) {
+			return nil, err
+		})
+	}
+	errorCounts := collector.GetMetrics()["error_counts"].(map[string]int64)
+	for _, label := range []string{"api_error", "request_error", "net_err", "unknown"} {
+		assert.Equal(int64(1), errorCounts["Chat:"+label], label)
+	}
+}
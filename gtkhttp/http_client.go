@@ -13,6 +13,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -112,6 +113,7 @@ type HTTPClient struct {
 	config            HTTPClientConfig                 // 客户端配置
 	requestBuilder    RequestBuilder                   // 请求构建器
 	createFormBuilder func(body io.Writer) FormBuilder // 表单构建器
+	interceptors      []Interceptor                    // HTTP 拦截器，按优先级排序
 }
 
 // HTTPClientOption 客户端选项
@@ -186,6 +188,7 @@ func NewHTTPClientWithConfig(config HTTPClientConfig, opts ...RequestBuilderOpti
 type RequestOptions struct {
 	body   any
 	header http.Header
+	query  any
 }
 
 // RequestOption 请求选项配置器
@@ -198,6 +201,13 @@ func WithBody(body any) (reqOpt RequestOption) {
 	}
 }
 
+// WithQuery 设置 HTTP 请求的查询参数，支持的类型见 EncodeQuery，会与 URL 中已有的查询参数合并
+func WithQuery(query any) (reqOpt RequestOption) {
+	return func(reqOpts *RequestOptions) {
+		reqOpts.query = query
+	}
+}
+
 // WithContentType 设置 HTTP 请求头的 Content-Type 字段
 func WithContentType(contentType string) (reqOpt RequestOption) {
 	return func(reqOpts *RequestOptions) {
@@ -238,6 +248,11 @@ func (c *HTTPClient) NewRequest(ctx context.Context, method, url string, setters
 		setter(reqOpts)
 	}
 
+	if reqOpts.query != nil {
+		if url, err = mergeQuery(url, reqOpts.query); err != nil {
+			return
+		}
+	}
 	if req, err = c.requestBuilder.Build(ctx, method, url, reqOpts.body, reqOpts.header); err != nil {
 		return
 	}
@@ -257,7 +272,7 @@ func (c *HTTPClient) SendRequest(req *http.Request, v Response) (err error) {
 	}
 
 	var resp *http.Response
-	if resp, err = c.config.HTTPClient.Do(req); err != nil {
+	if resp, err = c.do(req); err != nil {
 		return
 	}
 	defer resp.Body.Close()
@@ -271,13 +286,16 @@ func (c *HTTPClient) SendRequest(req *http.Request, v Response) (err error) {
 		return c.handleErrorResp(resp)
 	}
 
-	return c.config.ResponseDecoder.Decode(resp.Body, v)
+	if err = c.config.ResponseDecoder.Decode(resp.Body, v); err != nil {
+		return newDecodeError(resp, err)
+	}
+	return
 }
 
 // SendRequestRaw 发送请求
 func (c *HTTPClient) SendRequestRaw(req *http.Request) (response RawResponse, err error) {
 	var resp *http.Response
-	if resp, err = c.config.HTTPClient.Do(req); err != nil {
+	if resp, err = c.do(req); err != nil {
 		return
 	}
 
@@ -293,11 +311,19 @@ func (c *HTTPClient) SendRequestRaw(req *http.Request) (response RawResponse, er
 }
 
 // SendRequestStream 发送流式请求
-func SendRequestStream[T Streamable](client *HTTPClient, req *http.Request) (stream *StreamReader[T], err error) {
+func SendRequestStream[T Streamable](client *HTTPClient, req *http.Request, opts ...StreamOption) (stream *StreamReader[T], err error) {
+	options := &streamOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	accept := "text/event-stream"
+	if options.framer != nil {
+		accept = options.framer.Accept()
+	}
 	// 设置默认请求头
 	for _, v := range [][]string{
 		{"Content-Type", "application/json"},
-		{"Accept", "text/event-stream"},
+		{"Accept", accept},
 		{"Cache-Control", "no-cache"},
 		{"Connection", "keep-alive"},
 	} {
@@ -306,8 +332,10 @@ func SendRequestStream[T Streamable](client *HTTPClient, req *http.Request) (str
 		}
 	}
 
+	req = req.WithContext(withStreamRequest(req.Context()))
+
 	var resp *http.Response
-	if resp, err = client.config.HTTPClient.Do(req); err != nil {
+	if resp, err = client.do(req); err != nil {
 		stream = &StreamReader[T]{}
 		return
 	}
@@ -328,7 +356,46 @@ func SendRequestStream[T Streamable](client *HTTPClient, req *http.Request) (str
 		responseDecoder:             client.config.ResponseDecoder,
 		startTime:                   time.Now(),
 		HttpHeader:                  HttpHeader(resp.Header),
+		framer:                      options.framer,
+		ctx:                         req.Context(),
+	}
+	// 断线重连（仅 SSE 格式）
+	if options.reconnect != nil && options.framer == nil {
+		stream.reconnectConfig = *options.reconnect
+		stream.reconnectFn = func(lastEventID string) (resp *http.Response, err error) {
+			return client.reconnectStream(req, lastEventID)
+		}
+	}
+	return
+}
+
+// reconnectStream 重新发送流式请求
+func (c *HTTPClient) reconnectStream(req *http.Request, lastEventID string) (resp *http.Response, err error) {
+	newReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if newReq.Body, err = req.GetBody(); err != nil {
+			return nil, errors.Join(errStreamNoReconnect, err)
+		}
+	} else if req.Body != nil && req.Body != http.NoBody {
+		return nil, fmt.Errorf("%w: request body cannot be replayed", errStreamNoReconnect)
+	}
+	if lastEventID != "" {
+		newReq.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	if resp, err = c.do(newReq); err != nil {
+		return
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		resp.Body.Close()
+		return nil, errStreamNoReconnect
+	}
+	if isFailureStatusCode(resp) {
+		err = c.handleErrorResp(resp)
+		resp.Body.Close()
+		return nil, err
 	}
+	c.setRequestID(newReq, resp)
 	return
 }
 
@@ -350,8 +417,9 @@ func (c *HTTPClient) setRequestID(request *http.Request, response *http.Response
 	}
 }
 
-// handleErrorResp 处理错误响应
+// handleErrorResp 处理错误响应，返回 *APIError 或 *RequestError，Retry-After 和请求ID 通过 Classify 获取
 func (c *HTTPClient) handleErrorResp(resp *http.Response) (err error) {
+	retryAfter, requestID := responseMeta(resp)
 	// 读取响应体
 	var body []byte
 	if body, err = io.ReadAll(resp.Body); err != nil {
@@ -362,6 +430,7 @@ func (c *HTTPClient) handleErrorResp(resp *http.Response) (err error) {
 	if err = json.Unmarshal(body, &errRes); err == nil && errRes.Error != nil {
 		errRes.Error.HTTPStatus = resp.Status
 		errRes.Error.HTTPStatusCode = resp.StatusCode
+		errRes.Error.retryAfter, errRes.Error.requestID = retryAfter, requestID
 		return errRes.Error
 	}
 	// 尝试解析为 APIError
@@ -369,6 +438,7 @@ func (c *HTTPClient) handleErrorResp(resp *http.Response) (err error) {
 	if err = json.Unmarshal(body, &apiErr); err == nil && apiErr != nil {
 		apiErr.HTTPStatus = resp.Status
 		apiErr.HTTPStatusCode = resp.StatusCode
+		apiErr.retryAfter, apiErr.requestID = retryAfter, requestID
 		return apiErr
 	}
 	// 如果都解析失败，返回包含解析错误的 RequestError
@@ -377,5 +447,7 @@ func (c *HTTPClient) handleErrorResp(resp *http.Response) (err error) {
 		HTTPStatusCode: resp.StatusCode,
 		Err:            fmt.Errorf("failed to parse error response"),
 		Body:           body,
+		retryAfter:     retryAfter,
+		requestID:      requestID,
 	}
 }
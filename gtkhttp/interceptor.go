@@ -0,0 +1,119 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-20 09:46:12
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-20 09:46:12
+ * @Description: HTTP 拦截器接口定义，作用于 HTTPClient 发出的每一个 *http.Request
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"net/http"
+	"sort"
+)
+
+// HTTPHandler HTTP 请求处理函数类型
+type HTTPHandler func(req *http.Request) (resp *http.Response, err error)
+
+// Interceptor HTTP 拦截器接口
+type Interceptor interface {
+	Intercept(req *http.Request, next HTTPHandler) (resp *http.Response, err error) // 拦截请求，next是下一个处理器
+	Name() (name string)                                                            // 返回拦截器名称，用于标识和排序
+	Priority() (priority int)                                                       // 返回拦截器优先级，数值越小优先级越高
+}
+
+// InterceptorFunc 拦截器函数
+type InterceptorFunc func(req *http.Request, next HTTPHandler) (resp *http.Response, err error)
+
+// RequestSigner 请求签名函数，用于设置认证信息或对请求签名
+type RequestSigner func(req *http.Request) (err error)
+
+// funcInterceptor 函数拦截器
+type funcInterceptor struct {
+	name     string
+	priority int
+	fn       InterceptorFunc
+}
+
+// NewInterceptor 通过拦截器函数创建拦截器
+func NewInterceptor(name string, priority int, fn InterceptorFunc) (i Interceptor) {
+	return &funcInterceptor{
+		name:     name,
+		priority: priority,
+		fn:       fn,
+	}
+}
+
+// Intercept 拦截请求
+func (i *funcInterceptor) Intercept(req *http.Request, next HTTPHandler) (resp *http.Response, err error) {
+	return i.fn(req, next)
+}
+
+// Name 返回拦截器名称
+func (i *funcInterceptor) Name() (name string) {
+	return i.name
+}
+
+// Priority 返回拦截器优先级
+func (i *funcInterceptor) Priority() (priority int) {
+	return i.priority
+}
+
+// NewHeaderInterceptor 创建请求头拦截器，为每个请求设置指定的请求头（覆盖同名请求头）
+func NewHeaderInterceptor(header http.Header) (i Interceptor) {
+	return NewInterceptor("header", 30, func(req *http.Request, next HTTPHandler) (resp *http.Response, err error) {
+		for k, v := range header {
+			req.Header.Del(k)
+			for _, vv := range v {
+				req.Header.Add(k, vv)
+			}
+		}
+		return next(req)
+	})
+}
+
+// NewAuthInterceptor 创建认证拦截器，在每次发送请求（包括重试）前调用 signer 设置认证信息或对请求签名
+func NewAuthInterceptor(signer RequestSigner) (i Interceptor) {
+	return NewInterceptor("auth", 40, func(req *http.Request, next HTTPHandler) (resp *http.Response, err error) {
+		if err = signer(req); err != nil {
+			return
+		}
+		return next(req)
+	})
+}
+
+// BearerToken 设置 Bearer Token 认证信息的请求签名函数
+func BearerToken(token string) (signer RequestSigner) {
+	return func(req *http.Request) (err error) {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return
+	}
+}
+
+// WithInterceptors 添加 HTTP 拦截器（非并发安全）
+func WithInterceptors(interceptors ...Interceptor) (opt HTTPClientOption) {
+	return func(c *HTTPClient) {
+		c.interceptors = append(c.interceptors, interceptors...)
+		// 按优先级排序拦截器
+		sort.SliceStable(c.interceptors, func(i, j int) bool {
+			return c.interceptors[i].Priority() < c.interceptors[j].Priority()
+		})
+	}
+}
+
+// do 经过拦截器链发送请求
+func (c *HTTPClient) do(req *http.Request) (resp *http.Response, err error) {
+	handler := HTTPHandler(c.config.HTTPClient.Do)
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		var (
+			interceptor = c.interceptors[i]
+			nextHandler = handler
+		)
+		handler = func(req *http.Request) (resp *http.Response, err error) {
+			return interceptor.Intercept(req, nextHandler)
+		}
+	}
+	return handler(req)
+}
@@ -0,0 +1,206 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-20 10:21:35
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-20 10:21:35
+ * @Description: 日志拦截器
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/liusuxian/go-toolkit/gtklog"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxLogBodySize = 4096 // 默认记录的最大请求体/响应体字节数
+)
+
+const (
+	streamRequestKey ContextKey = "toolkit_stream_request" // 流式请求标记在上下文中的键
+)
+
+// streamContentTypes 流式响应的 Content-Type
+var streamContentTypes = []string{
+	"text/event-stream",
+	"application/x-ndjson",
+	"application/stream+json",
+	"application/octet-stream",
+}
+
+// LoggingInterceptorConfig 日志拦截器配置
+type LoggingInterceptorConfig struct {
+	Logger          gtklog.ILogger // 日志器
+	LogRequestBody  bool           // 是否记录请求体
+	LogResponseBody bool           // 是否记录响应体（流式响应和 SendRequestStream 发出的请求不记录）
+	SkipSuccessLog  bool           // 是否跳过成功请求的日志
+	SensitiveFields []string       // 敏感字段，会被脱敏，同时作用于请求头、响应头和 JSON 格式的请求体/响应体
+	MaxBodySize     int            // 记录的最大请求体/响应体字节数，超出部分会被截断，默认 4096
+}
+
+// LoggingInterceptor 日志拦截器
+type LoggingInterceptor struct {
+	config LoggingInterceptorConfig
+}
+
+// httpLogEntry HTTP 日志
+type httpLogEntry struct {
+	RequestID      string         `json:"request_id,omitempty"`
+	Method         string         `json:"method"`
+	URL            string         `json:"url"`
+	RequestHeader  map[string]any `json:"request_header,omitempty"`
+	RequestBody    any            `json:"request_body,omitempty"`
+	StatusCode     int            `json:"status_code,omitempty"`
+	ResponseHeader map[string]any `json:"response_header,omitempty"`
+	ResponseBody   any            `json:"response_body,omitempty"`
+	DurationMs     int64          `json:"duration_ms"`
+	Error          string         `json:"error,omitempty"`
+}
+
+// NewLoggingInterceptor 创建日志拦截器
+func NewLoggingInterceptor(config LoggingInterceptorConfig) (li *LoggingInterceptor) {
+	if config.Logger == nil {
+		config.Logger = gtklog.NewDefaultLogger(gtklog.InfoLevel)
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = defaultMaxLogBodySize
+	}
+	return &LoggingInterceptor{
+		config: config,
+	}
+}
+
+// Intercept 拦截请求
+func (i *LoggingInterceptor) Intercept(req *http.Request, next HTTPHandler) (resp *http.Response, err error) {
+	var (
+		ctx       = req.Context()
+		startTime = time.Now()
+		entry     = &httpLogEntry{
+			Method:        req.Method,
+			URL:           req.URL.String(),
+			RequestHeader: i.sanitizeHeader(req.Header),
+		}
+	)
+	if requestInfo := GetRequestInfo(ctx); requestInfo.RequestID != "unknown" {
+		entry.RequestID = requestInfo.RequestID
+	}
+	// 记录请求体
+	if i.config.LogRequestBody && req.GetBody != nil {
+		if body, e := req.GetBody(); e == nil {
+			data, _ := io.ReadAll(io.LimitReader(body, int64(i.config.MaxBodySize)+1))
+			body.Close()
+			entry.RequestBody = i.sanitizeBody(data)
+		}
+	}
+	// 执行下一个处理器
+	resp, err = next(req)
+	entry.DurationMs = time.Since(startTime).Milliseconds()
+	if err != nil {
+		entry.Error = err.Error()
+		i.config.Logger.Errorf(ctx, "http request failed: %s", toMustString(entry))
+		return
+	}
+	entry.StatusCode = resp.StatusCode
+	entry.ResponseHeader = i.sanitizeHeader(resp.Header)
+	// 记录响应体，读取的部分会被放回响应体，不影响后续读取
+	if (i.config.LogResponseBody || isFailureStatusCode(resp)) && !isStreamingBody(resp) && resp.Body != nil {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, int64(i.config.MaxBodySize)+1))
+		resp.Body = &multiReadCloser{
+			Reader: io.MultiReader(bytes.NewReader(data), resp.Body),
+			Closer: resp.Body,
+		}
+		entry.ResponseBody = i.sanitizeBody(data)
+	}
+	if isFailureStatusCode(resp) {
+		i.config.Logger.Errorf(ctx, "http request failed: %s", toMustString(entry))
+		return
+	}
+	if !i.config.SkipSuccessLog {
+		i.config.Logger.Infof(ctx, "http request completed: %s", toMustString(entry))
+	}
+	return
+}
+
+// Name 返回拦截器名称
+func (i *LoggingInterceptor) Name() (name string) {
+	return "logging"
+}
+
+// Priority 返回拦截器优先级
+func (i *LoggingInterceptor) Priority() (priority int) {
+	return 100 // 日志拦截器优先级较低，记录每一次实际发出的请求（包括重试）
+}
+
+// sanitizeHeader 脱敏请求头/响应头
+func (i *LoggingInterceptor) sanitizeHeader(header http.Header) (result map[string]any) {
+	if len(header) == 0 {
+		return nil
+	}
+	result = make(map[string]any, len(header))
+	for k, v := range header {
+		if isSensitiveField(k, i.config.SensitiveFields) {
+			result[k] = "***"
+			continue
+		}
+		result[k] = strings.Join(v, ", ")
+	}
+	return
+}
+
+// sanitizeBody 脱敏请求体/响应体
+func (i *LoggingInterceptor) sanitizeBody(data []byte) (body any) {
+	if len(data) == 0 {
+		return nil
+	}
+	if len(data) > i.config.MaxBodySize {
+		return string(data[:i.config.MaxBodySize]) + "...(truncated)"
+	}
+	if json.Valid(data) {
+		var v any
+		if err := json.Unmarshal(data, &v); err == nil {
+			return sanitizeValue(v, i.config.SensitiveFields, 0)
+		}
+	}
+	return string(data)
+}
+
+// isStreamResponse 是否为流式响应
+func isStreamResponse(resp *http.Response) (ok bool) {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// isStreamingBody 响应体是否为流式数据，读取时可能长时间阻塞，不能提前读取用于记录日志
+func isStreamingBody(resp *http.Response) (ok bool) {
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	for _, v := range streamContentTypes {
+		if strings.HasPrefix(contentType, v) {
+			return true
+		}
+	}
+	return resp.Request != nil && isStreamRequest(resp.Request.Context())
+}
+
+// withStreamRequest 标记为流式请求，如通过分帧器读取 JSON 数组等 Content-Type 无法区分的流式响应
+func withStreamRequest(ctx context.Context) (newCtx context.Context) {
+	return context.WithValue(ctx, streamRequestKey, true)
+}
+
+// isStreamRequest 是否为流式请求
+func isStreamRequest(ctx context.Context) (ok bool) {
+	ok, _ = ctx.Value(streamRequestKey).(bool)
+	return
+}
+
+// multiReadCloser 组合的 io.ReadCloser
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,89 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-20 11:38:20
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-20 11:38:20
+ * @Description: 监控拦截器
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MetricsInterceptorKeyFunc 监控拦截器的指标键函数
+type MetricsInterceptorKeyFunc func(req *http.Request) (key string)
+
+// MetricsInterceptorConfig 监控拦截器配置
+type MetricsInterceptorConfig struct {
+	Collector MetricsCollector          // 指标收集器，可以与 MetricsMiddleware 共用
+	KeyFunc   MetricsInterceptorKeyFunc // 指标键函数，默认为 "HTTP 方法 + 空格 + URL 路径"
+}
+
+// MetricsInterceptor 监控拦截器
+type MetricsInterceptor struct {
+	config MetricsInterceptorConfig
+}
+
+// NewMetricsInterceptor 创建监控拦截器
+func NewMetricsInterceptor(config MetricsInterceptorConfig) (mi *MetricsInterceptor) {
+	if config.Collector == nil {
+		config.Collector = NewDefaultMetricsCollector()
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = func(req *http.Request) (key string) {
+			return req.Method + " " + req.URL.Path
+		}
+	}
+	return &MetricsInterceptor{
+		config: config,
+	}
+}
+
+// Intercept 拦截请求
+func (i *MetricsInterceptor) Intercept(req *http.Request, next HTTPHandler) (resp *http.Response, err error) {
+	var (
+		key       = i.config.KeyFunc(req)
+		startTime = time.Now()
+	)
+	// 记录请求开始
+	i.config.Collector.RecordRequestStart(key)
+	// 执行下一个处理器
+	resp, err = next(req)
+	success := err == nil && !isFailureStatusCode(resp)
+	// 记录请求完成
+	i.config.Collector.RecordRequestComplete(key, time.Since(startTime).Milliseconds(), success)
+	// 记录错误
+	switch {
+	case err != nil:
+		errorType := "unknown"
+		if IsNetError(err) {
+			errorType = "net_err"
+		} else if IsCanceledError(err) {
+			errorType = "canceled"
+		}
+		i.config.Collector.RecordError(key, errorType)
+	case !success:
+		i.config.Collector.RecordError(key, "http_"+strconv.Itoa(resp.StatusCode))
+	}
+	return
+}
+
+// Name 返回拦截器名称
+func (i *MetricsInterceptor) Name() (name string) {
+	return "metrics"
+}
+
+// Priority 返回拦截器优先级
+func (i *MetricsInterceptor) Priority() (priority int) {
+	return 10 // 监控拦截器优先级较高，统计包含重试在内的完整请求
+}
+
+// GetMetrics 获取指标数据
+func (i *MetricsInterceptor) GetMetrics() (metrics map[string]any) {
+	return i.config.Collector.GetMetrics()
+}
@@ -0,0 +1,159 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-20 11:03:58
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-20 11:03:58
+ * @Description: 重试拦截器
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryInterceptorCondition 重试拦截器的重试条件函数，resp 和 err 至多一个不为空
+type RetryInterceptorCondition func(attempt int, resp *http.Response, err error) (ok bool)
+
+// RetryInterceptorConfig 重试拦截器配置
+type RetryInterceptorConfig struct {
+	MaxAttempts      int                       // 最大重试次数（不包括首次请求），默认 3
+	Strategy         RetryStrategy             // 重试策略，可选值: fixed 固定间隔，linear 线性递增，exponential 指数退避，jitter 带抖动的指数退避，默认 exponential
+	BaseDelay        time.Duration             // 基础延迟时间，默认 1s
+	MaxDelay         time.Duration             // 最大延迟时间，默认 10s
+	Multiplier       float64                   // 重试间隔倍数（用于指数退避），默认 2.0
+	JitterPercent    float64                   // 抖动百分比（用于抖动策略，范围0-1，如0.1表示±10%），默认 0.1
+	RetryStatusCodes []int                     // 需要重试的 HTTP 状态码，默认 429、500、502、503、504
+	MaxRetryAfter    time.Duration             // 响应头 Retry-After 指定的最大等待时间，超过时不再重试，默认 60s
+	Condition        RetryInterceptorCondition // 重试条件，默认网络错误或状态码在 RetryStatusCodes 中时重试
+}
+
+// RetryInterceptor 重试拦截器
+//
+//	仅能重试请求体可重放（req.GetBody 不为空）或没有请求体的请求，通过 NewRequest 创建的请求均满足该条件
+type RetryInterceptor struct {
+	config  RetryInterceptorConfig
+	backoff *RetryMiddleware // 复用重试中间件的延迟计算
+}
+
+// NewRetryInterceptor 创建重试拦截器
+func NewRetryInterceptor(config RetryInterceptorConfig) (ri *RetryInterceptor) {
+	// 设置最大重试次数，默认 3
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	// 设置需要重试的 HTTP 状态码
+	if len(config.RetryStatusCodes) == 0 {
+		config.RetryStatusCodes = []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+	// 设置 Retry-After 指定的最大等待时间，默认 60s
+	if config.MaxRetryAfter <= 0 {
+		config.MaxRetryAfter = 60 * time.Second
+	}
+	ri = &RetryInterceptor{
+		backoff: NewRetryMiddleware(RetryMiddlewareConfig{
+			MaxAttempts:   config.MaxAttempts,
+			Strategy:      config.Strategy,
+			BaseDelay:     config.BaseDelay,
+			MaxDelay:      config.MaxDelay,
+			Multiplier:    config.Multiplier,
+			JitterPercent: config.JitterPercent,
+		}),
+	}
+	// 设置重试条件
+	if config.Condition == nil {
+		config.Condition = func(attempt int, resp *http.Response, err error) (ok bool) {
+			if err != nil {
+				return IsNetError(err)
+			}
+			return slices.Contains(config.RetryStatusCodes, resp.StatusCode)
+		}
+	}
+	ri.config = config
+	return
+}
+
+// Intercept 拦截请求
+func (i *RetryInterceptor) Intercept(req *http.Request, next HTTPHandler) (resp *http.Response, err error) {
+	ctx := req.Context()
+	// 请求体不可重放时不重试
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return next(req)
+	}
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				if attemptReq.Body, err = req.GetBody(); err != nil {
+					return
+				}
+			}
+		}
+		resp, err = next(attemptReq)
+		if attempt >= i.config.MaxAttempts || !i.config.Condition(attempt, resp, err) {
+			return
+		}
+		// 计算延迟时间，优先使用响应头 Retry-After
+		delay := i.backoff.calculateDelay(attempt + 1)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if retryAfter > i.config.MaxRetryAfter {
+					return
+				}
+				delay = retryAfter
+			}
+			// 丢弃本次响应
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		// 等待延迟时间
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+			// 继续下次重试
+		}
+	}
+}
+
+// Name 返回拦截器名称
+func (i *RetryInterceptor) Name() (name string) {
+	return "retry"
+}
+
+// Priority 返回拦截器优先级
+func (i *RetryInterceptor) Priority() (priority int) {
+	return 20 // 重试拦截器在请求头和认证拦截器之前执行，每次重试都会重新设置请求头和签名
+}
+
+// parseRetryAfter 解析响应头 Retry-After，支持秒数和 HTTP 日期两种格式
+func parseRetryAfter(value string, now time.Time) (delay time.Duration, ok bool) {
+	if value = strings.TrimSpace(value); value == "" {
+		return
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return
+}
@@ -0,0 +1,273 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-20 14:12:06
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-20 14:12:06
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/liusuxian/go-toolkit/gtklog"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// captureLogger 记录 Info/Warn/Error 日志内容的日志器
+type captureLogger struct {
+	gtklog.ILogger
+	mu   sync.Mutex
+	logs []string
+}
+
+func newCaptureLogger() (l *captureLogger) {
+	return &captureLogger{ILogger: gtklog.NewDefaultLogger(gtklog.InfoLevel)}
+}
+
+func (l *captureLogger) record(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logs = append(l.logs, fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Infof(ctx context.Context, format string, args ...any) {
+	l.record(format, args...)
+}
+
+func (l *captureLogger) Warnf(ctx context.Context, format string, args ...any) {
+	l.record(format, args...)
+}
+
+func (l *captureLogger) Errorf(ctx context.Context, format string, args ...any) {
+	l.record(format, args...)
+}
+
+func (l *captureLogger) Logs() (logs []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.logs...)
+}
+
+// interceptorResponse 拦截器测试响应
+type interceptorResponse struct {
+	gtkhttp.HttpHeader `json:"-"`
+	Message            string `json:"message"`
+}
+
+func newInterceptorClient(baseURL string, interceptors ...gtkhttp.Interceptor) (client *gtkhttp.HTTPClient) {
+	client = gtkhttp.NewHTTPClient(baseURL)
+	gtkhttp.WithInterceptors(interceptors...)(client)
+	return
+}
+
+func TestHeaderAndAuthInterceptor(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("X-App"), r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	client := newInterceptorClient(server.URL,
+		gtkhttp.NewAuthInterceptor(gtkhttp.BearerToken("token")),
+		gtkhttp.NewHeaderInterceptor(http.Header{"X-App": {"toolkit"}}),
+	)
+	req, err := client.NewRequest(ctx, http.MethodGet, client.FullURL("/"))
+	assert.NoError(err)
+	resp, err := client.SendRequestRaw(req)
+	assert.NoError(err)
+	defer resp.Close()
+	body, err := io.ReadAll(resp)
+	assert.NoError(err)
+	assert.Equal("toolkit|Bearer token", string(body))
+}
+
+func TestRetryInterceptor(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		calls  atomic.Int32
+		bodies = make(chan string, 3)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies <- string(data)
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"message":"ok"}`))
+	}))
+	defer server.Close()
+
+	client := newInterceptorClient(server.URL, gtkhttp.NewRetryInterceptor(gtkhttp.RetryInterceptorConfig{
+		MaxAttempts: 3,
+		BaseDelay:   time.Hour, // Retry-After 优先于退避时间
+	}))
+	req, err := client.NewRequest(ctx, http.MethodPost, client.FullURL("/"), gtkhttp.WithBody(map[string]any{"q": "hi"}))
+	assert.NoError(err)
+	resp := &interceptorResponse{}
+	assert.NoError(client.SendRequest(req, resp))
+	assert.Equal("ok", resp.Message)
+	assert.Equal(int32(3), calls.Load())
+	// 每次重试都会重放请求体
+	close(bodies)
+	for body := range bodies {
+		assert.JSONEq(`{"q":"hi"}`, body)
+	}
+}
+
+func TestRetryInterceptorGiveUp(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		calls  atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// Retry-After 超过最大等待时间时不再重试
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := newInterceptorClient(server.URL, gtkhttp.NewRetryInterceptor(gtkhttp.RetryInterceptorConfig{}))
+	req, err := client.NewRequest(ctx, http.MethodGet, client.FullURL("/"))
+	assert.NoError(err)
+	resp := &interceptorResponse{}
+	err = client.SendRequest(req, resp)
+	assert.Error(err)
+	assert.Equal(int32(1), calls.Load())
+	// 不需要重试的状态码
+	client = newInterceptorClient(server.URL, gtkhttp.NewRetryInterceptor(gtkhttp.RetryInterceptorConfig{
+		RetryStatusCodes: []int{http.StatusBadGateway},
+	}))
+	assert.Error(client.SendRequest(req, resp))
+	assert.Equal(int32(2), calls.Load())
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		logger = newCaptureLogger()
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":"ok","access_token":"secret-token"}`))
+	}))
+	defer server.Close()
+
+	collector := gtkhttp.NewDefaultMetricsCollector()
+	client := newInterceptorClient(server.URL,
+		gtkhttp.NewLoggingInterceptor(gtkhttp.LoggingInterceptorConfig{
+			Logger:          logger,
+			LogRequestBody:  true,
+			LogResponseBody: true,
+			SensitiveFields: []string{"authorization", "password", "token"},
+		}),
+		gtkhttp.NewAuthInterceptor(gtkhttp.BearerToken("bearer-secret")),
+		gtkhttp.NewMetricsInterceptor(gtkhttp.MetricsInterceptorConfig{Collector: collector}),
+	)
+	req, err := client.NewRequest(ctx, http.MethodPost, client.FullURL("/login"), gtkhttp.WithBody(map[string]any{
+		"user":     "alice",
+		"password": "p@ss",
+	}))
+	assert.NoError(err)
+	resp := &interceptorResponse{}
+	// 记录响应体后不影响响应解码
+	assert.NoError(client.SendRequest(req, resp))
+	assert.Equal("ok", resp.Message)
+
+	logs := logger.Logs()
+	if assert.Len(logs, 1) {
+		assert.Contains(logs[0], "alice")
+		assert.Contains(logs[0], `"status_code":200`)
+		assert.NotContains(logs[0], "p@ss")
+		assert.NotContains(logs[0], "secret-token")
+		assert.NotContains(logs[0], "bearer-secret")
+	}
+	assert.Equal(int64(1), collector.GetMetrics()["total_requests"].(map[string]int64)["POST /login"])
+}
+
+func TestLoggingInterceptorStream(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		framer      gtkhttp.StreamFramer
+		first, rest string
+	}{
+		{name: "ndjson", contentType: "application/x-ndjson", framer: gtkhttp.NewNDJSONFramer(0), first: "{\"text\":\"a\"}\n", rest: "{\"text\":\"b\"}\n"},
+		{name: "json array", contentType: "application/json", framer: gtkhttp.NewJSONArrayFramer(), first: "[{\"text\":\"a\"},", rest: "{\"text\":\"b\"}]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				assert  = assert.New(t)
+				logger  = newCaptureLogger()
+				release = make(chan struct{})
+			)
+			// 第一帧发送后，等客户端读取到第一帧才发送剩余数据
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(tt.first))
+				w.(http.Flusher).Flush()
+				select {
+				case <-release:
+				case <-r.Context().Done():
+					return
+				}
+				w.Write([]byte(tt.rest))
+			}))
+			defer server.Close()
+			defer close(release)
+
+			client := newInterceptorClient(server.URL, gtkhttp.NewLoggingInterceptor(gtkhttp.LoggingInterceptorConfig{
+				Logger:          logger,
+				LogResponseBody: true,
+			}))
+			req, err := client.NewRequest(context.Background(), http.MethodPost, server.URL)
+			assert.NoError(err)
+			received := make(chan string, 1)
+			go func() {
+				stream, err := gtkhttp.SendRequestStream[chunk](client, req, gtkhttp.WithStreamFramer(tt.framer))
+				if err != nil {
+					received <- err.Error()
+					return
+				}
+				defer stream.Close()
+				c, _, err := stream.Recv()
+				if err != nil {
+					received <- err.Error()
+					return
+				}
+				received <- c.Text
+			}()
+			// 记录日志时不读取流式响应体，第一帧不会被阻塞
+			select {
+			case text := <-received:
+				assert.Equal("a", text)
+			case <-time.After(3 * time.Second):
+				assert.Fail("stream blocked by logging interceptor")
+			}
+			logs := logger.Logs()
+			if assert.Len(logs, 1) {
+				assert.NotContains(logs[0], "response_body")
+			}
+		})
+	}
+}
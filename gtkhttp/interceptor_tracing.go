@@ -0,0 +1,86 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 10:42:05
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 10:42:05
+ * @Description: 链路追踪拦截器
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"github.com/liusuxian/go-toolkit/gtktrace"
+	"net/http"
+	"strconv"
+)
+
+// TracingInterceptorConfig 链路追踪拦截器配置
+type TracingInterceptorConfig struct {
+	Tracer gtktrace.Tracer // 追踪器，默认使用 gtktrace.GetTracer()
+}
+
+// TracingInterceptor 链路追踪拦截器，为每一次实际发出的请求（包括重试）创建客户端跨度，并在请求头中注入 traceparent
+type TracingInterceptor struct {
+	config TracingInterceptorConfig
+}
+
+// NewTracingInterceptor 创建链路追踪拦截器
+func NewTracingInterceptor(config TracingInterceptorConfig) (ti *TracingInterceptor) {
+	return &TracingInterceptor{
+		config: config,
+	}
+}
+
+// Intercept 拦截请求
+func (i *TracingInterceptor) Intercept(req *http.Request, next HTTPHandler) (resp *http.Response, err error) {
+	tracer := i.config.Tracer
+	if tracer == nil {
+		tracer = gtktrace.GetTracer()
+	}
+	ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method,
+		gtktrace.WithSpanKind(gtktrace.SpanKindClient),
+		gtktrace.WithAttributes(
+			gtktrace.Attr("http.request.method", req.Method),
+			gtktrace.Attr("url.full", redactURL(req)),
+			gtktrace.Attr("server.address", req.URL.Hostname()),
+		))
+	if requestInfo := GetRequestInfo(ctx); requestInfo.RequestID != "unknown" {
+		span.SetAttributes(gtktrace.Attr("request_id", requestInfo.RequestID))
+	}
+	// 注入追踪信息，请求头的修改只作用于本次请求
+	req = req.WithContext(ctx)
+	req.Header = req.Header.Clone()
+	gtktrace.Inject(ctx, gtktrace.HeaderCarrier(req.Header))
+	// 执行下一个处理器
+	resp, err = next(req)
+	if err != nil {
+		gtktrace.EndSpan(span, err)
+		return
+	}
+	span.SetAttributes(gtktrace.Attr("http.response.status_code", resp.StatusCode))
+	if isFailureStatusCode(resp) {
+		span.SetStatus(gtktrace.StatusError, strconv.Itoa(resp.StatusCode))
+	}
+	span.End()
+	return
+}
+
+// Name 返回拦截器名称
+func (i *TracingInterceptor) Name() (name string) {
+	return "tracing"
+}
+
+// Priority 返回拦截器优先级
+func (i *TracingInterceptor) Priority() (priority int) {
+	return 50 // 在重试、认证拦截器之后执行，每一次实际发出的请求都有独立的跨度
+}
+
+// redactURL 获取不包含用户信息和查询参数的 URL，避免敏感信息进入追踪系统
+func redactURL(req *http.Request) (rawURL string) {
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
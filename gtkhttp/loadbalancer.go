@@ -10,10 +10,11 @@
 package gtkhttp
 
 import (
+	"context"
 	"errors"
-	"math"
 	"slices"
 	"sync"
+	"time"
 )
 
 var (
@@ -26,21 +27,74 @@ var (
 
 // APIKey API密钥
 type APIKey struct {
-	Key       string // 密钥
-	Times     uint32 // 请求次数
-	Available bool   // 是否可用
-	Weight    uint32 // 权重
+	Key          string        // 密钥
+	Times        uint32        // 请求次数
+	Available    bool          // 是否可用
+	Weight       uint32        // 权重
+	InFlight     uint32        // 正在处理的请求数，GetAPIKey 时加 1，Report 时减 1
+	Failures     uint32        // 连续失败次数
+	Latency      time.Duration // 请求耗时的指数加权移动平均值（EWMA）
+	EjectedUntil time.Time     // 被自动摘除的截止时间，零值表示未被摘除
+	RPM          uint32        // 每分钟请求数配额，0 表示不限制
+	TPM          uint32        // 每分钟令牌数配额，0 表示不限制，需要通过 Consume 上报用量
+	probation    bool          // 冷却结束后重新加入，在首次成功前处于观察期，观察期内失败会被立即摘除
+}
+
+// ejected 是否处于自动摘除状态
+func (k *APIKey) ejected(now time.Time) (ok bool) {
+	return now.Before(k.EjectedUntil)
+}
+
+// clone 深拷贝
+func (k *APIKey) clone() (apiKey *APIKey) {
+	c := *k
+	return &c
+}
+
+// HealthConfig 健康检查配置，根据 Report 反馈的结果自动摘除和恢复APIKey
+type HealthConfig struct {
+	MaxFailures uint32        // 连续失败多少次后自动摘除，默认 5
+	CoolDown    time.Duration // 摘除后的冷却时间，冷却结束后重新加入，默认 30 秒
+	EWMAAlpha   float64       // 耗时 EWMA 的平滑系数，取值 (0, 1]，越大越偏向最近的耗时，默认 0.3
 }
 
 // LoadBalancer 负载均衡器
 type LoadBalancer struct {
-	apiKeyList []*APIKey    // API密钥列表
-	mu         sync.RWMutex // 读写锁
+	apiKeyList   []*APIKey       // API密钥列表
+	strategy     BalanceStrategy // 负载均衡策略
+	healthConfig HealthConfig    // 健康检查配置
+	quotaStore   QuotaStore      // 配额用量存储
+	exhausted    quotaExhausted  // 当前窗口内配额已用完的APIKey
+	mu           sync.RWMutex    // 读写锁
+}
+
+// LoadBalancerOption 负载均衡器选项
+type LoadBalancerOption func(lb *LoadBalancer)
+
+// WithBalanceStrategy 设置负载均衡策略，默认使用 NewLeastRequestsStrategy
+func WithBalanceStrategy(strategy BalanceStrategy) (opt LoadBalancerOption) {
+	return func(lb *LoadBalancer) {
+		lb.strategy = strategy
+	}
+}
+
+// WithHealthConfig 设置健康检查配置
+func WithHealthConfig(config HealthConfig) (opt LoadBalancerOption) {
+	return func(lb *LoadBalancer) {
+		lb.healthConfig = config
+	}
+}
+
+// WithQuotaStore 设置配额用量存储，默认使用进程内存储；多个实例需要共享APIKey配额时使用 NewRedisQuotaStore
+func WithQuotaStore(store QuotaStore) (opt LoadBalancerOption) {
+	return func(lb *LoadBalancer) {
+		lb.quotaStore = store
+	}
 }
 
 // NewLoadBalancer 创建负载均衡器
-func NewLoadBalancer(keyList []string) (lb *LoadBalancer) {
-	lb = &LoadBalancer{}
+func NewLoadBalancer(keyList []string, opts ...LoadBalancerOption) (lb *LoadBalancer) {
+	lb = &LoadBalancer{exhausted: make(quotaExhausted)}
 	// 初始化API密钥列表
 	for _, key := range keyList {
 		lb.apiKeyList = append(lb.apiKeyList, &APIKey{
@@ -49,39 +103,148 @@ func NewLoadBalancer(keyList []string) (lb *LoadBalancer) {
 			Weight:    1, // 默认权重为1
 		})
 	}
+	// 设置选项
+	for _, opt := range opts {
+		opt(lb)
+	}
+	// 设置默认值
+	if lb.strategy == nil {
+		lb.strategy = NewLeastRequestsStrategy()
+	}
+	if lb.healthConfig.MaxFailures == 0 {
+		lb.healthConfig.MaxFailures = 5
+	}
+	if lb.healthConfig.CoolDown <= 0 {
+		lb.healthConfig.CoolDown = 30 * time.Second
+	}
+	if lb.quotaStore == nil {
+		lb.quotaStore = NewMemoryQuotaStore()
+	}
+	if lb.healthConfig.EWMAAlpha <= 0 || lb.healthConfig.EWMAAlpha > 1 {
+		lb.healthConfig.EWMAAlpha = 0.3
+	}
 	return
 }
 
-// GetAPIKey 获取一个APIKey，使用最少连接算法
+// GetAPIKey 获取一个APIKey，默认使用最少请求算法
+//
+//	使用 redis 等共享配额存储时，建议使用 GetAPIKeyFor 传入调用方的上下文
 func (lb *LoadBalancer) GetAPIKey() (apiKey *APIKey, err error) {
+	return lb.GetAPIKeyFor(context.Background(), "")
+}
+
+// GetAPIKeyFor 根据调用方的 hashKey（如用户ID、会话ID）获取一个APIKey，只有一致性哈希策略会使用 hashKey
+//
+//	跳过不可用、被自动摘除和当前分钟配额已用完的APIKey，ctx 用于访问配额存储；使用 Report 时，每次获取都应对应一次 Report
+func (lb *LoadBalancer) GetAPIKeyFor(ctx context.Context, hashKey string) (apiKey *APIKey, err error) {
+	window := quotaWindow(time.Now())
+	for {
+		var rpm, tpm uint32
+		if apiKey, rpm, tpm, err = lb.selectAPIKey(hashKey, window); err != nil || (rpm == 0 && tpm == 0) {
+			return
+		}
+		// 检查配额并占用一次请求配额，多个实例共享存储时可能同时选中同一个APIKey，配额已用完时重新选择
+		var ok bool
+		if ok, err = lb.quotaStore.Reserve(ctx, apiKey.Key, window, rpm, tpm); err != nil {
+			lb.release(apiKey)
+			return nil, err
+		}
+		if ok {
+			return
+		}
+		lb.release(apiKey)
+		lb.setExhausted(apiKey.Key, window)
+	}
+}
+
+// selectAPIKey 按策略选择一个APIKey，返回选中时的每分钟请求数和令牌数配额
+func (lb *LoadBalancer) selectAPIKey(hashKey string, window time.Time) (apiKey *APIKey, rpm, tpm uint32, err error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
 	if len(lb.apiKeyList) == 0 {
-		return nil, errEmptyAPIKeyList
+		return nil, 0, 0, errEmptyAPIKeyList
 	}
-	// 选择使用次数最少的APIKey
-	lb.mu.RLock()
+	// 筛选可用的APIKey
 	var (
-		selectedAPIKey *APIKey
-		minScore       = math.MaxFloat64
+		now        = time.Now()
+		candidates = make([]*APIKey, 0, len(lb.apiKeyList))
 	)
 	for _, v := range lb.apiKeyList {
-		if v.Available {
-			score := float64(v.Times) / float64(v.Weight)
-			if score < minScore {
-				selectedAPIKey = v
-				minScore = score
-			}
+		if v.Available && !v.ejected(now) && !lb.exhausted.contains(v.Key, window) {
+			candidates = append(candidates, v)
 		}
 	}
-	lb.mu.RUnlock()
 	// 如果未找到可用的APIKey，则返回错误
-	if selectedAPIKey == nil {
-		return nil, errNoAPIKeyAvailable
+	if len(candidates) == 0 {
+		return nil, 0, 0, errNoAPIKeyAvailable
+	}
+	// 按策略选择APIKey
+	if apiKey = lb.strategy.Select(candidates, hashKey); apiKey == nil {
+		return nil, 0, 0, errNoAPIKeyAvailable
 	}
-	// 增加使用次数（需要写锁）
+	apiKey.Times++
+	apiKey.InFlight++
+	return apiKey, apiKey.RPM, apiKey.TPM, nil
+}
+
+// release 撤销一次选择
+func (lb *LoadBalancer) release(apiKey *APIKey) {
 	lb.mu.Lock()
-	selectedAPIKey.Times++
-	lb.mu.Unlock()
-	return selectedAPIKey, nil
+	defer lb.mu.Unlock()
+
+	if apiKey.Times > 0 {
+		apiKey.Times--
+	}
+	if apiKey.InFlight > 0 {
+		apiKey.InFlight--
+	}
+}
+
+// Report 反馈一次请求的结果，用于更新耗时 EWMA 和正在处理的请求数，并根据连续失败次数自动摘除APIKey
+//
+//	连续失败达到 MaxFailures 次后摘除，冷却 CoolDown 后重新加入；重新加入后首次请求失败会被立即再次摘除
+func (lb *LoadBalancer) Report(key string, latency time.Duration, err error) (e error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	// 获取APIKey的索引
+	index := slices.IndexFunc(lb.apiKeyList, func(apiKey *APIKey) bool {
+		return apiKey.Key == key
+	})
+	// 如果APIKey不存在，则返回错误
+	if index == -1 {
+		return errAPIKeyNotFound
+	}
+	apiKey := lb.apiKeyList[index]
+	if apiKey.InFlight > 0 {
+		apiKey.InFlight--
+	}
+	// 更新耗时 EWMA
+	if apiKey.Latency == 0 {
+		apiKey.Latency = latency
+	} else {
+		alpha := lb.healthConfig.EWMAAlpha
+		apiKey.Latency = time.Duration(alpha*float64(latency) + (1-alpha)*float64(apiKey.Latency))
+	}
+	// 更新健康状态
+	if err == nil {
+		apiKey.Failures = 0
+		apiKey.probation = false
+		return
+	}
+	now := time.Now()
+	if apiKey.ejected(now) {
+		// 摘除前发出的请求，不重复处理
+		return
+	}
+	apiKey.Failures++
+	if apiKey.probation || apiKey.Failures >= lb.healthConfig.MaxFailures {
+		apiKey.EjectedUntil = now.Add(lb.healthConfig.CoolDown)
+		apiKey.Failures = 0
+		apiKey.probation = true
+	}
+	return
 }
 
 // SetAvailability 设置指定APIKey的可用性
@@ -184,12 +347,7 @@ func (lb *LoadBalancer) GetAPIKeyList() (apiKeyList []*APIKey) {
 	apiKeyList = make([]*APIKey, len(lb.apiKeyList))
 	for i, apiKey := range lb.apiKeyList {
 		// 深拷贝：创建新的APIKey对象
-		apiKeyList[i] = &APIKey{
-			Key:       apiKey.Key,
-			Times:     apiKey.Times,
-			Available: apiKey.Available,
-			Weight:    apiKey.Weight,
-		}
+		apiKeyList[i] = apiKey.clone()
 	}
 	return
 }
@@ -201,20 +359,29 @@ func (lb *LoadBalancer) GetStats() (stats map[string]any) {
 
 	stats = make(map[string]any)
 	var (
+		now             = time.Now()
 		totalAPIKey     = len(lb.apiKeyList)
 		availableAPIKey = 0
+		ejectedAPIKey   = 0
 		totalRequests   = uint32(0)
+		inFlight        = uint32(0)
 	)
 
 	for _, apiKey := range lb.apiKeyList {
 		if apiKey.Available {
 			availableAPIKey++
 		}
+		if apiKey.ejected(now) {
+			ejectedAPIKey++
+		}
 		totalRequests += apiKey.Times
+		inFlight += apiKey.InFlight
 	}
 
 	stats["total_api_key"] = totalAPIKey
 	stats["available_api_key"] = availableAPIKey
+	stats["ejected_api_key"] = ejectedAPIKey
 	stats["total_requests"] = totalRequests
+	stats["in_flight"] = inFlight
 	return stats
 }
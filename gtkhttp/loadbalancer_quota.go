@@ -0,0 +1,232 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-22 10:16:53
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-22 10:16:53
+ * @Description: 负载均衡器的APIKey配额（RPM/TPM）
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/gtkconv"
+	"github.com/liusuxian/go-toolkit/gtkredis"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultQuotaPrefix = "gtkhttp:quota:" // 配额用量 redis key 的默认前缀
+)
+
+// quotaReserveScript 检查配额并占用一次请求配额的 lua 脚本，KEYS[1] 为窗口用量的 key，ARGV 为 rpm、tpm、过期时间（毫秒时间戳），占用成功返回 1
+const quotaReserveScript = `
+local rpm, tpm = tonumber(ARGV[1]), tonumber(ARGV[2])
+local r = tonumber(redis.call('HGET', KEYS[1], 'r') or '0')
+local t = tonumber(redis.call('HGET', KEYS[1], 't') or '0')
+if (rpm > 0 and r >= rpm) or (tpm > 0 and t >= tpm) then
+	return 0
+end
+redis.call('HINCRBY', KEYS[1], 'r', 1)
+redis.call('PEXPIREAT', KEYS[1], ARGV[3])
+return 1
+`
+
+// QuotaUsage 配额用量
+type QuotaUsage struct {
+	Requests int64 // 请求数
+	Tokens   int64 // 令牌数
+}
+
+// QuotaStore 配额用量存储，按自然分钟窗口统计每个APIKey的用量
+type QuotaStore interface {
+	Reserve(ctx context.Context, key string, window time.Time, rpm, tpm uint32) (ok bool, err error)             // 原子地检查APIKey在窗口内的用量，请求数和令牌数均未达到配额（0 表示不限制）时占用一次请求配额并返回 true
+	Add(ctx context.Context, key string, window time.Time, requests, tokens int64) (usage QuotaUsage, err error) // 原子地增加APIKey在窗口内的用量，返回增加后的用量
+}
+
+// quotaWindow 获取时间所在的配额窗口
+func quotaWindow(t time.Time) (window time.Time) {
+	return t.Truncate(time.Minute)
+}
+
+// quotaExhausted 配额已用完的APIKey -> 所在窗口，窗口内的用量只增不减，同一窗口内无需再次访问配额存储
+type quotaExhausted map[string]time.Time
+
+// contains 判断APIKey在窗口内的配额是否已用完
+func (e quotaExhausted) contains(key string, window time.Time) (ok bool) {
+	w, ok := e[key]
+	return ok && w.Equal(window)
+}
+
+// exceeded 判断用量是否达到配额
+func (u QuotaUsage) exceeded(rpm, tpm uint32) (ok bool) {
+	return (rpm > 0 && u.Requests >= int64(rpm)) || (tpm > 0 && u.Tokens >= int64(tpm))
+}
+
+// SetQuota 设置APIKey的每分钟请求数和令牌数配额，0 表示不限制
+func (lb *LoadBalancer) SetQuota(key string, rpm, tpm uint32) (err error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	// 获取APIKey的索引
+	index := slices.IndexFunc(lb.apiKeyList, func(apiKey *APIKey) bool {
+		return apiKey.Key == key
+	})
+	// 如果APIKey不存在，则返回错误
+	if index == -1 {
+		return errAPIKeyNotFound
+	}
+	// 设置APIKey的配额，配额变化后需要重新检查用量
+	lb.apiKeyList[index].RPM = rpm
+	lb.apiKeyList[index].TPM = tpm
+	delete(lb.exhausted, key)
+	return
+}
+
+// setExhausted 记录APIKey在窗口内的配额已用完
+func (lb *LoadBalancer) setExhausted(key string, window time.Time) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	lb.exhausted[key] = window
+}
+
+// Consume 上报APIKey在一次请求中消耗的令牌数，应在每次响应后调用，未设置配额的APIKey不会记录，ctx 用于访问配额存储
+func (lb *LoadBalancer) Consume(ctx context.Context, key string, tokens int) (err error) {
+	lb.mu.RLock()
+	index := slices.IndexFunc(lb.apiKeyList, func(apiKey *APIKey) bool {
+		return apiKey.Key == key
+	})
+	hasQuota := index != -1 && (lb.apiKeyList[index].RPM > 0 || lb.apiKeyList[index].TPM > 0)
+	lb.mu.RUnlock()
+	// 如果APIKey不存在，则返回错误
+	if index == -1 {
+		return errAPIKeyNotFound
+	}
+	if !hasQuota || tokens <= 0 {
+		return
+	}
+	_, err = lb.quotaStore.Add(ctx, key, quotaWindow(time.Now()), 0, int64(tokens))
+	return
+}
+
+// MemoryQuotaStore 进程内配额用量存储
+type MemoryQuotaStore struct {
+	usage map[string]*windowUsage // APIKey -> 当前窗口的用量
+	mu    sync.Mutex              // 互斥锁
+}
+
+// windowUsage 窗口用量
+type windowUsage struct {
+	window time.Time
+	usage  QuotaUsage
+}
+
+// NewMemoryQuotaStore 创建进程内配额用量存储
+func NewMemoryQuotaStore() (store *MemoryQuotaStore) {
+	return &MemoryQuotaStore{
+		usage: make(map[string]*windowUsage),
+	}
+}
+
+// Reserve 原子地检查APIKey在窗口内的用量，未达到配额时占用一次请求配额
+func (store *MemoryQuotaStore) Reserve(ctx context.Context, key string, window time.Time, rpm, tpm uint32) (ok bool, err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	wu := store.windowUsage(key, window)
+	if wu.usage.exceeded(rpm, tpm) {
+		return false, nil
+	}
+	wu.usage.Requests++
+	return true, nil
+}
+
+// Add 原子地增加APIKey在窗口内的用量
+func (store *MemoryQuotaStore) Add(ctx context.Context, key string, window time.Time, requests, tokens int64) (usage QuotaUsage, err error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	wu := store.windowUsage(key, window)
+	wu.usage.Requests += requests
+	wu.usage.Tokens += tokens
+	return wu.usage, nil
+}
+
+// windowUsage 获取APIKey在窗口内的用量，进入新的窗口时重新计数，调用方需持有锁
+func (store *MemoryQuotaStore) windowUsage(key string, window time.Time) (wu *windowUsage) {
+	wu, ok := store.usage[key]
+	if !ok || !wu.window.Equal(window) {
+		wu = &windowUsage{window: window}
+		store.usage[key] = wu
+	}
+	return
+}
+
+// RedisQuotaStore 基于 redis 的配额用量存储，多个实例共享同一份APIKey配额
+//
+//	每个窗口的用量保存在一个 hash 中，key 为 前缀+APIKey的SHA256摘要+窗口时间戳，避免APIKey明文出现在 redis 中，窗口结束后自动过期
+type RedisQuotaStore struct {
+	client *gtkredis.RedisClient
+	prefix string
+}
+
+// NewRedisQuotaStore 创建基于 redis 的配额用量存储，prefix 为 redis key 的前缀，默认 gtkhttp:quota:
+func NewRedisQuotaStore(client *gtkredis.RedisClient, prefix ...string) (store *RedisQuotaStore) {
+	store = &RedisQuotaStore{client: client, prefix: defaultQuotaPrefix}
+	if len(prefix) > 0 && prefix[0] != "" {
+		store.prefix = prefix[0]
+	}
+	return
+}
+
+// Reserve 原子地检查APIKey在窗口内的用量，未达到配额时占用一次请求配额，检查和占用在一个 lua 脚本中完成
+func (store *RedisQuotaStore) Reserve(ctx context.Context, key string, window time.Time, rpm, tpm uint32) (ok bool, err error) {
+	var value any
+	if value, err = store.client.Eval(ctx, quotaReserveScript, []string{store.redisKey(key, window)}, rpm, tpm, quotaExpireAt(window)); err != nil {
+		return
+	}
+	return gtkconv.ToInt64(value) == 1, nil
+}
+
+// Add 原子地增加APIKey在窗口内的用量
+func (store *RedisQuotaStore) Add(ctx context.Context, key string, window time.Time, requests, tokens int64) (usage QuotaUsage, err error) {
+	redisKey := store.redisKey(key, window)
+	var results []*gtkredis.PipelineResult
+	if results, err = store.client.Pipeline(ctx,
+		[]any{"HINCRBY", redisKey, "r", requests},
+		[]any{"HINCRBY", redisKey, "t", tokens},
+		[]any{"PEXPIREAT", redisKey, quotaExpireAt(window)},
+	); err != nil {
+		return
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			return usage, result.Err
+		}
+	}
+	if len(results) < 2 {
+		return usage, fmt.Errorf("unexpected pipeline results: %d", len(results))
+	}
+	usage.Requests = gtkconv.ToInt64(results[0].Val)
+	usage.Tokens = gtkconv.ToInt64(results[1].Val)
+	return
+}
+
+// quotaExpireAt 获取窗口用量的过期时间（毫秒时间戳），窗口结束后再保留一个窗口，容忍实例间的时钟偏差
+func quotaExpireAt(window time.Time) (expireAt int64) {
+	return window.Add(2 * time.Minute).UnixMilli()
+}
+
+// redisKey 获取APIKey在窗口内的 redis key
+func (store *RedisQuotaStore) redisKey(key string, window time.Time) (redisKey string) {
+	sum := sha256.Sum256([]byte(key))
+	return store.prefix + hex.EncodeToString(sum[:8]) + ":" + strconv.FormatInt(window.Unix(), 10)
+}
@@ -0,0 +1,196 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-22 11:02:18
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-22 11:02:18
+ * @Description: 负载均衡器配额测试
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/liusuxian/go-toolkit/gtkredis"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestQuota tests per-key RPM/TPM quotas
+func TestQuota(t *testing.T) {
+	t.Run("skip keys with exhausted RPM", func(t *testing.T) {
+		lb := NewLoadBalancer([]string{"key1", "key2"})
+		lb.SetQuota("key1", 2, 0)
+		lb.SetQuota("key2", 1, 0)
+
+		counts := make(map[string]int)
+		for range 3 {
+			apiKey, err := lb.GetAPIKey()
+			if err != nil {
+				t.Fatalf("failed to get API key: %v", err)
+			}
+			counts[apiKey.Key]++
+		}
+		if counts["key1"] != 2 || counts["key2"] != 1 {
+			t.Errorf("unexpected selection counts: %v", counts)
+		}
+		if _, err := lb.GetAPIKey(); err != errNoAPIKeyAvailable {
+			t.Errorf("expected error %v, got %v", errNoAPIKeyAvailable, err)
+		}
+	})
+
+	t.Run("skip keys with exhausted TPM", func(t *testing.T) {
+		lb := NewLoadBalancer([]string{"key1", "key2"})
+		lb.SetQuota("key1", 0, 100)
+
+		if err := lb.Consume(context.Background(), "key1", 100); err != nil {
+			t.Fatalf("failed to consume: %v", err)
+		}
+		for range 3 {
+			apiKey, _ := lb.GetAPIKey()
+			if apiKey.Key != "key2" {
+				t.Errorf("expected key2, got %s", apiKey.Key)
+			}
+		}
+		if err := lb.Consume(context.Background(), "key3", 1); err != errAPIKeyNotFound {
+			t.Errorf("expected error %v, got %v", errAPIKeyNotFound, err)
+		}
+	})
+
+	t.Run("share quota through redis", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			r   = miniredis.RunT(t)
+		)
+		client, err := gtkredis.NewClient(ctx, &gtkredis.ClientConfig{Addr: r.Addr()})
+		if err != nil {
+			t.Fatalf("failed to create redis client: %v", err)
+		}
+		defer client.Close()
+
+		// Two pods share the same key quota
+		pods := make([]*LoadBalancer, 2)
+		for i := range pods {
+			pods[i] = NewLoadBalancer([]string{"key1"}, WithQuotaStore(NewRedisQuotaStore(client)))
+			pods[i].SetQuota("key1", 3, 50)
+		}
+		granted := 0
+		for i := range 6 {
+			if _, err := pods[i%2].GetAPIKey(); err == nil {
+				granted++
+			}
+		}
+		if granted != 3 {
+			t.Errorf("expected 3 requests granted across pods, got %d", granted)
+		}
+
+		pods[1].SetQuota("key1", 0, 50)
+		pods[0].Consume(ctx, "key1", 30)
+		if _, err := pods[1].GetAPIKey(); err != nil {
+			t.Errorf("expected key1 to be available, got %v", err)
+		}
+		pods[1].Consume(ctx, "key1", 20)
+		if _, err := pods[1].GetAPIKey(); err != errNoAPIKeyAvailable {
+			t.Errorf("expected error %v, got %v", errNoAPIKeyAvailable, err)
+		}
+	})
+
+	t.Run("reserve quota atomically across pods", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+			r   = miniredis.RunT(t)
+		)
+		client, err := gtkredis.NewClient(ctx, &gtkredis.ClientConfig{Addr: r.Addr()})
+		if err != nil {
+			t.Fatalf("failed to create redis client: %v", err)
+		}
+		defer client.Close()
+
+		pods := make([]*LoadBalancer, 4)
+		for i := range pods {
+			pods[i] = NewLoadBalancer([]string{"key1"}, WithQuotaStore(NewRedisQuotaStore(client)))
+			pods[i].SetQuota("key1", 10, 0)
+		}
+		var (
+			granted atomic.Int32
+			wg      sync.WaitGroup
+		)
+		for i := range 40 {
+			wg.Go(func() {
+				if _, err := pods[i%len(pods)].GetAPIKeyFor(ctx, ""); err == nil {
+					granted.Add(1)
+				}
+			})
+		}
+		wg.Wait()
+		if granted.Load() != 10 {
+			t.Errorf("expected 10 requests granted across pods, got %d", granted.Load())
+		}
+	})
+
+	t.Run("use the caller's context", func(t *testing.T) {
+		r := miniredis.RunT(t)
+		client, err := gtkredis.NewClient(context.Background(), &gtkredis.ClientConfig{Addr: r.Addr()})
+		if err != nil {
+			t.Fatalf("failed to create redis client: %v", err)
+		}
+		defer client.Close()
+
+		lb := NewLoadBalancer([]string{"key1"}, WithQuotaStore(NewRedisQuotaStore(client)))
+		lb.SetQuota("key1", 10, 100)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := lb.GetAPIKeyFor(ctx, ""); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected error %v, got %v", context.Canceled, err)
+		}
+		if err := lb.Consume(ctx, "key1", 10); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected error %v, got %v", context.Canceled, err)
+		}
+		// 取消的请求不占用配额
+		if stats := lb.GetAPIKeyList()[0]; stats.Times != 0 || stats.InFlight != 0 {
+			t.Errorf("expected no selection recorded, got times=%d inFlight=%d", stats.Times, stats.InFlight)
+		}
+	})
+
+	t.Run("skip exhausted keys without querying the store", func(t *testing.T) {
+		store := &countingQuotaStore{QuotaStore: NewMemoryQuotaStore()}
+		lb := NewLoadBalancer([]string{"key1", "key2"}, WithQuotaStore(store))
+		lb.SetQuota("key1", 1, 0)
+		lb.SetQuota("key2", 1, 0)
+		for range 2 {
+			if _, err := lb.GetAPIKey(); err != nil {
+				t.Fatalf("failed to get API key: %v", err)
+			}
+		}
+		for range 3 {
+			if _, err := lb.GetAPIKey(); err != errNoAPIKeyAvailable {
+				t.Errorf("expected error %v, got %v", errNoAPIKeyAvailable, err)
+			}
+		}
+		// 2 次占用成功，2 次发现配额已用完，之后不再访问存储
+		if n := store.reserves.Load(); n != 4 {
+			t.Errorf("expected 4 reserve calls, got %d", n)
+		}
+		// 调整配额后重新检查
+		lb.SetQuota("key1", 2, 0)
+		if apiKey, err := lb.GetAPIKey(); err != nil || apiKey.Key != "key1" {
+			t.Errorf("expected key1, got %v, %v", apiKey, err)
+		}
+	})
+}
+
+// countingQuotaStore 统计 Reserve 调用次数的配额用量存储
+type countingQuotaStore struct {
+	QuotaStore
+	reserves atomic.Int32
+}
+
+// Reserve 原子地检查APIKey在窗口内的用量，未达到配额时占用一次请求配额
+func (store *countingQuotaStore) Reserve(ctx context.Context, key string, window time.Time, rpm, tpm uint32) (ok bool, err error) {
+	store.reserves.Add(1)
+	return store.QuotaStore.Reserve(ctx, key, window, rpm, tpm)
+}
@@ -0,0 +1,128 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-21 20:11:27
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-21 20:11:27
+ * @Description: 负载均衡策略
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+)
+
+// BalanceStrategy 负载均衡策略
+//
+//	Select 在负载均衡器的写锁内调用，candidates 为当前可用的APIKey（非空），策略可以安全地维护自身状态
+type BalanceStrategy interface {
+	Select(candidates []*APIKey, hashKey string) (apiKey *APIKey) // 从候选APIKey中选择一个
+}
+
+// BalanceStrategyFunc 函数形式的负载均衡策略
+type BalanceStrategyFunc func(candidates []*APIKey, hashKey string) (apiKey *APIKey)
+
+// Select 从候选APIKey中选择一个
+func (f BalanceStrategyFunc) Select(candidates []*APIKey, hashKey string) (apiKey *APIKey) {
+	return f(candidates, hashKey)
+}
+
+// NewLeastRequestsStrategy 加权最少请求策略，选择 请求次数/权重 最小的APIKey
+func NewLeastRequestsStrategy() (strategy BalanceStrategy) {
+	return BalanceStrategyFunc(func(candidates []*APIKey, hashKey string) (apiKey *APIKey) {
+		return minScoreAPIKey(candidates, func(k *APIKey) (score float64) {
+			return float64(k.Times) / float64(k.Weight)
+		})
+	})
+}
+
+// NewLeastInFlightStrategy 加权最少正在处理请求策略，选择 正在处理的请求数/权重 最小的APIKey，需要配合 Report 使用
+func NewLeastInFlightStrategy() (strategy BalanceStrategy) {
+	return BalanceStrategyFunc(func(candidates []*APIKey, hashKey string) (apiKey *APIKey) {
+		return minScoreAPIKey(candidates, func(k *APIKey) (score float64) {
+			return float64(k.InFlight) / float64(k.Weight)
+		})
+	})
+}
+
+// NewEWMAStrategy 最低延迟策略，选择 耗时EWMA*(正在处理的请求数+1)/权重 最小的APIKey，需要配合 Report 使用
+//
+//	还没有耗时数据的APIKey会被优先选择，以便尽快获得耗时数据
+func NewEWMAStrategy() (strategy BalanceStrategy) {
+	return BalanceStrategyFunc(func(candidates []*APIKey, hashKey string) (apiKey *APIKey) {
+		return minScoreAPIKey(candidates, func(k *APIKey) (score float64) {
+			return float64(k.Latency) * float64(k.InFlight+1) / float64(k.Weight)
+		})
+	})
+}
+
+// roundRobinStrategy 轮询策略
+type roundRobinStrategy struct {
+	next uint64
+}
+
+// NewRoundRobinStrategy 轮询策略，忽略权重，依次选择每个可用的APIKey
+func NewRoundRobinStrategy() (strategy BalanceStrategy) {
+	return &roundRobinStrategy{}
+}
+
+// Select 从候选APIKey中选择一个
+func (s *roundRobinStrategy) Select(candidates []*APIKey, hashKey string) (apiKey *APIKey) {
+	apiKey = candidates[s.next%uint64(len(candidates))]
+	s.next++
+	return
+}
+
+// NewWeightedRandomStrategy 加权随机策略，按权重比例随机选择APIKey
+func NewWeightedRandomStrategy() (strategy BalanceStrategy) {
+	return BalanceStrategyFunc(func(candidates []*APIKey, hashKey string) (apiKey *APIKey) {
+		var total uint64
+		for _, k := range candidates {
+			total += uint64(k.Weight)
+		}
+		n := rand.Uint64N(total)
+		for _, k := range candidates {
+			if n < uint64(k.Weight) {
+				return k
+			}
+			n -= uint64(k.Weight)
+		}
+		return candidates[len(candidates)-1]
+	})
+}
+
+// NewConsistentHashStrategy 一致性哈希策略，相同 hashKey 总是选择同一个APIKey，可用于会话粘滞或提高上游缓存命中率
+//
+//	使用加权最高随机权重（Rendezvous）哈希，APIKey增减或被摘除时只有映射到该APIKey的 hashKey 会重新分配
+func NewConsistentHashStrategy() (strategy BalanceStrategy) {
+	return BalanceStrategyFunc(func(candidates []*APIKey, hashKey string) (apiKey *APIKey) {
+		return minScoreAPIKey(candidates, func(k *APIKey) (score float64) {
+			h := fnv.New64a()
+			h.Write([]byte(k.Key))
+			h.Write([]byte{0})
+			h.Write([]byte(hashKey))
+			// 混淆哈希值使高位分布均匀，再映射到 (0, 1) 区间，分数越小越优先
+			x := h.Sum64()
+			x ^= x >> 33
+			x *= 0xff51afd7ed558ccd
+			x ^= x >> 33
+			u := (float64(x>>11) + 0.5) / (1 << 53)
+			return -math.Log(u) / float64(k.Weight)
+		})
+	})
+}
+
+// minScoreAPIKey 选择分数最小的APIKey，分数相同时选择靠前的
+func minScoreAPIKey(candidates []*APIKey, score func(k *APIKey) (score float64)) (apiKey *APIKey) {
+	minScore := math.Inf(1)
+	for _, k := range candidates {
+		if s := score(k); apiKey == nil || s < minScore {
+			apiKey = k
+			minScore = s
+		}
+	}
+	return
+}
@@ -0,0 +1,173 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-21 20:52:40
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-21 20:52:40
+ * @Description: 负载均衡策略测试
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestBalanceStrategies tests pluggable strategies
+func TestBalanceStrategies(t *testing.T) {
+	t.Run("round robin", func(t *testing.T) {
+		lb := NewLoadBalancer([]string{"key1", "key2", "key3"}, WithBalanceStrategy(NewRoundRobinStrategy()))
+		lb.SetWeight("key1", 10)
+
+		var got []string
+		for range 6 {
+			apiKey, err := lb.GetAPIKey()
+			if err != nil {
+				t.Fatalf("failed to get API key: %v", err)
+			}
+			got = append(got, apiKey.Key)
+		}
+		if fmt.Sprint(got) != "[key1 key2 key3 key1 key2 key3]" {
+			t.Errorf("unexpected round robin order: %v", got)
+		}
+	})
+
+	t.Run("weighted random", func(t *testing.T) {
+		lb := NewLoadBalancer([]string{"key1", "key2"}, WithBalanceStrategy(NewWeightedRandomStrategy()))
+		lb.SetWeight("key2", 9)
+
+		counts := make(map[string]int)
+		for range 1000 {
+			apiKey, _ := lb.GetAPIKey()
+			counts[apiKey.Key]++
+		}
+		if counts["key2"] < 800 || counts["key1"] == 0 {
+			t.Errorf("selection should follow weights, got %v", counts)
+		}
+	})
+
+	t.Run("least in-flight", func(t *testing.T) {
+		lb := NewLoadBalancer([]string{"key1", "key2"}, WithBalanceStrategy(NewLeastInFlightStrategy()))
+
+		first, _ := lb.GetAPIKey()
+		second, _ := lb.GetAPIKey()
+		if first.Key == second.Key {
+			t.Errorf("expected different keys, got %s twice", first.Key)
+		}
+		// Finishing the first request makes it the least loaded
+		lb.Report(first.Key, time.Millisecond, nil)
+		third, _ := lb.GetAPIKey()
+		if third.Key != first.Key {
+			t.Errorf("expected %s, got %s", first.Key, third.Key)
+		}
+	})
+
+	t.Run("consistent hash", func(t *testing.T) {
+		keys := []string{"key1", "key2", "key3", "key4"}
+		lb := NewLoadBalancer(keys, WithBalanceStrategy(NewConsistentHashStrategy()))
+
+		assigned := make(map[string]string)
+		used := make(map[string]bool)
+		for i := range 100 {
+			user := fmt.Sprintf("user-%d", i)
+			apiKey, _ := lb.GetAPIKeyFor(context.Background(), user)
+			again, _ := lb.GetAPIKeyFor(context.Background(), user)
+			if apiKey.Key != again.Key {
+				t.Fatalf("same hash key should map to the same API key")
+			}
+			assigned[user] = apiKey.Key
+			used[apiKey.Key] = true
+		}
+		if len(used) != len(keys) {
+			t.Errorf("expected all keys to be used, got %v", used)
+		}
+		// Only users of the removed key are remapped
+		lb.SetAvailability("key1", false)
+		for user, key := range assigned {
+			apiKey, _ := lb.GetAPIKeyFor(context.Background(), user)
+			if key != "key1" && apiKey.Key != key {
+				t.Errorf("user %s should stay on %s, got %s", user, key, apiKey.Key)
+			}
+		}
+	})
+
+	t.Run("EWMA latency", func(t *testing.T) {
+		lb := NewLoadBalancer([]string{"key1", "key2"}, WithBalanceStrategy(NewEWMAStrategy()))
+		lb.Report("key1", 100*time.Millisecond, nil)
+		lb.Report("key2", 10*time.Millisecond, nil)
+
+		for range 3 {
+			apiKey, _ := lb.GetAPIKey()
+			if apiKey.Key != "key2" {
+				t.Errorf("expected faster key2, got %s", apiKey.Key)
+			}
+			lb.Report(apiKey.Key, 10*time.Millisecond, nil)
+		}
+		// EWMA moves towards recent latency
+		lb.Report("key2", 1000*time.Millisecond, nil)
+		list := lb.GetAPIKeyList()
+		if list[1].Latency != 307*time.Millisecond {
+			t.Errorf("expected EWMA latency 307ms, got %v", list[1].Latency)
+		}
+		apiKey, _ := lb.GetAPIKey()
+		if apiKey.Key != "key1" {
+			t.Errorf("expected key1 after key2 slowed down, got %s", apiKey.Key)
+		}
+	})
+}
+
+// TestReport tests ejection and re-admission
+func TestReport(t *testing.T) {
+	t.Run("eject after consecutive failures", func(t *testing.T) {
+		lb := NewLoadBalancer([]string{"key1", "key2"}, WithHealthConfig(HealthConfig{
+			MaxFailures: 2,
+			CoolDown:    50 * time.Millisecond,
+		}))
+		errFailed := errors.New("failed")
+
+		lb.Report("key1", time.Millisecond, errFailed)
+		lb.Report("key1", time.Millisecond, nil)
+		lb.Report("key1", time.Millisecond, errFailed)
+		if stats := lb.GetStats(); stats["ejected_api_key"] != 0 {
+			t.Errorf("success should reset failures, got %v ejected", stats["ejected_api_key"])
+		}
+		lb.Report("key1", time.Millisecond, errFailed)
+		if stats := lb.GetStats(); stats["ejected_api_key"] != 1 {
+			t.Errorf("expected key1 to be ejected, got %v ejected", stats["ejected_api_key"])
+		}
+		for range 3 {
+			apiKey, _ := lb.GetAPIKey()
+			if apiKey.Key != "key2" {
+				t.Errorf("ejected key should not be selected, got %s", apiKey.Key)
+			}
+		}
+		// Re-admitted after cool-down, a failure on probation ejects it again immediately
+		time.Sleep(60 * time.Millisecond)
+		if stats := lb.GetStats(); stats["ejected_api_key"] != 0 {
+			t.Errorf("expected key1 to be re-admitted, got %v ejected", stats["ejected_api_key"])
+		}
+		lb.Report("key1", time.Millisecond, errFailed)
+		if stats := lb.GetStats(); stats["ejected_api_key"] != 1 {
+			t.Errorf("expected key1 to be ejected on probation, got %v ejected", stats["ejected_api_key"])
+		}
+	})
+
+	t.Run("all keys ejected", func(t *testing.T) {
+		lb := NewLoadBalancer([]string{"key1"}, WithHealthConfig(HealthConfig{MaxFailures: 1}))
+		lb.Report("key1", time.Millisecond, errors.New("failed"))
+		if _, err := lb.GetAPIKey(); err != errNoAPIKeyAvailable {
+			t.Errorf("expected error %v, got %v", errNoAPIKeyAvailable, err)
+		}
+	})
+
+	t.Run("report non-existent API key", func(t *testing.T) {
+		lb := NewLoadBalancer([]string{"key1"})
+		if err := lb.Report("key2", time.Millisecond, nil); err != errAPIKeyNotFound {
+			t.Errorf("expected error %v, got %v", errAPIKeyNotFound, err)
+		}
+	})
+}
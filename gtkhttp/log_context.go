@@ -0,0 +1,38 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-24 10:08:26
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-24 10:08:26
+ * @Description: 日志上下文字段提取
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"github.com/liusuxian/go-toolkit/gtklog"
+	"github.com/liusuxian/go-toolkit/gtktrace"
+)
+
+func init() {
+	gtklog.RegisterContextExtractor(LogContextFields)
+}
+
+// LogContextFields 从上下文中提取日志字段，包括请求ID（request_id）、用户（user）和追踪ID（trace_id、span_id）
+//
+//	导入 gtkhttp 包时自动注册到 gtklog，gtklog 的日志器会在每条日志中附加这些字段
+func LogContextFields(ctx context.Context) (fields []gtklog.Field) {
+	if info, ok := ctx.Value(RequestInfoKey).(*RequestInfo); ok && info != nil {
+		if info.RequestID != "" && info.RequestID != "unknown" {
+			fields = append(fields, gtklog.F("request_id", info.RequestID))
+		}
+		if info.User != "" {
+			fields = append(fields, gtklog.F("user", info.User))
+		}
+	}
+	if sc := gtktrace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields, gtklog.F("trace_id", sc.TraceID.String()), gtklog.F("span_id", sc.SpanID.String()))
+	}
+	return
+}
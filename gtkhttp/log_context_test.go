@@ -0,0 +1,43 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-24 10:08:26
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-24 10:08:26
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/liusuxian/go-toolkit/gtklog"
+	"github.com/liusuxian/go-toolkit/gtktrace"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLogContextFields(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		buf    bytes.Buffer
+	)
+	assert.Empty(gtkhttp.LogContextFields(ctx))
+	assert.Empty(gtkhttp.LogContextFields(gtkhttp.SetRequestInfo(ctx, &gtkhttp.RequestInfo{RequestID: "unknown"})))
+
+	ctx = gtkhttp.SetRequestInfo(ctx, &gtkhttp.RequestInfo{RequestID: "req-1", User: "u1"})
+	ctx, span := gtktrace.NewRecordingTracer(gtktrace.NewInMemoryExporter()).Start(ctx, "op")
+	defer span.End()
+	fields := gtkhttp.LogContextFields(ctx)
+	assert.Len(fields, 4)
+	assert.Equal(gtklog.F("request_id", "req-1"), fields[0])
+	assert.Equal(gtklog.F("user", "u1"), fields[1])
+	assert.Equal(gtklog.F("trace_id", span.SpanContext().TraceID.String()), fields[2])
+
+	log := gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(&buf))
+	log.Info(ctx, "handled")
+	assert.Contains(buf.String(), "handled request_id=req-1 user=u1 trace_id=")
+}
@@ -0,0 +1,281 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 15:16:40
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 15:16:40
+ * @Description: Prometheus 指标收集器
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHistogramBuckets 默认的请求耗时直方图桶（秒）
+var DefaultHistogramBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// PrometheusMetricsConfig Prometheus 指标收集器配置
+type PrometheusMetricsConfig struct {
+	Namespace   string            // 指标名称前缀，默认 gtkhttp
+	Buckets     []float64         // 请求耗时直方图桶（秒），默认 DefaultHistogramBuckets
+	ConstLabels map[string]string // 所有指标附加的固定标签，如 {"service": "chat"}
+}
+
+// PrometheusMetricsCollector Prometheus 指标收集器，实现 MetricsCollector、CircuitStateRecorder 和 PrometheusCollector
+//
+//	输出的指标（以默认前缀为例）：
+//	gtkhttp_requests_total{method,result}             请求总数，result 为 success 或 failure
+//	gtkhttp_requests_in_flight{method}                当前活跃请求数
+//	gtkhttp_request_duration_seconds{method}          请求耗时直方图
+//	gtkhttp_errors_total{method,error_type}           错误总数
+//	gtkhttp_retries_total{method}                     重试总次数
+//	gtkhttp_circuit_state{key,state}                  熔断器状态，当前状态为 1，其他状态为 0
+type PrometheusMetricsCollector struct {
+	config PrometheusMetricsConfig
+	mu     sync.RWMutex
+	// 请求计数器
+	successRequests map[string]uint64 // 成功请求数
+	failedRequests  map[string]uint64 // 失败请求数
+	// 活跃请求数
+	activeRequests map[string]int64 // 当前活跃请求数
+	// 响应时间直方图
+	durations map[string]*histogram // 响应时间直方图（秒）
+	// 错误统计
+	errorCounts map[[2]string]uint64 // 错误计数，键为 [方法, 错误类型]
+	// 重试统计
+	retryCounts map[string]uint64 // 重试计数
+	// 熔断器状态
+	circuitStates map[string]CircuitState // 熔断器当前状态
+	// 时间范围内的统计
+	startTime time.Time // 统计开始时间
+}
+
+// histogram 直方图
+type histogram struct {
+	counts []uint64 // 每个桶的计数（非累计）
+	sum    float64  // 观测值总和
+	count  uint64   // 观测次数
+}
+
+// NewPrometheusMetricsCollector 创建 Prometheus 指标收集器
+func NewPrometheusMetricsCollector(config PrometheusMetricsConfig) (c *PrometheusMetricsCollector) {
+	if config.Namespace == "" {
+		config.Namespace = "gtkhttp"
+	}
+	if len(config.Buckets) == 0 {
+		config.Buckets = DefaultHistogramBuckets
+	}
+	config.Buckets = slices.Clone(config.Buckets)
+	slices.Sort(config.Buckets)
+	config.Buckets = slices.Compact(config.Buckets)
+
+	c = &PrometheusMetricsCollector{config: config}
+	c.Reset()
+	return
+}
+
+// RecordRequestStart 记录请求开始
+func (c *PrometheusMetricsCollector) RecordRequestStart(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.activeRequests[method]++
+}
+
+// RecordRequestComplete 记录请求完成
+func (c *PrometheusMetricsCollector) RecordRequestComplete(method string, durationMs int64, success bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 记录成功/失败
+	if success {
+		c.successRequests[method]++
+	} else {
+		c.failedRequests[method]++
+	}
+	// 记录响应时间
+	h, ok := c.durations[method]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(c.config.Buckets))}
+		c.durations[method] = h
+	}
+	seconds := float64(durationMs) / 1000
+	if i := sort.SearchFloat64s(c.config.Buckets, seconds); i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.sum += seconds
+	h.count++
+	// 减少活跃请求数
+	if c.activeRequests[method] > 0 {
+		c.activeRequests[method]--
+	}
+}
+
+// RecordError 记录错误
+func (c *PrometheusMetricsCollector) RecordError(method, errorType string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.errorCounts[[2]string{method, errorType}]++
+}
+
+// RecordRetry 记录重试
+func (c *PrometheusMetricsCollector) RecordRetry(method string, retryCount int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.retryCounts[method] += uint64(retryCount)
+}
+
+// RecordCircuitStateChange 记录熔断器状态变化
+func (c *PrometheusMetricsCollector) RecordCircuitStateChange(key string, from, to CircuitState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.circuitStates[key] = to
+}
+
+// GetMetrics 获取指标数据，格式与 DefaultMetricsCollector 保持一致
+func (c *PrometheusMetricsCollector) GetMetrics() (metrics map[string]any) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var (
+		totalRequests    = make(map[string]int64)
+		successRequests  = make(map[string]int64)
+		failedRequests   = make(map[string]int64)
+		errorCounts      = make(map[string]int64)
+		retryCounts      = make(map[string]int64)
+		activeRequests   = make(map[string]int64)
+		successRates     = make(map[string]float64)
+		avgResponseTimes = make(map[string]float64)
+	)
+	for method, h := range c.durations {
+		success, failed := int64(c.successRequests[method]), int64(c.failedRequests[method])
+		successRequests[method] = success
+		failedRequests[method] = failed
+		totalRequests[method] = success + failed
+		if h.count > 0 {
+			successRates[method] = float64(success) / float64(h.count) * 100
+			avgResponseTimes[method] = h.sum * 1000 / float64(h.count)
+		}
+	}
+	// 未完成的请求也计入总请求数
+	for method, active := range c.activeRequests {
+		totalRequests[method] += active
+		activeRequests[method] = active
+	}
+	for key, count := range c.errorCounts {
+		errorCounts[key[0]+":"+key[1]] = int64(count)
+	}
+	for method, count := range c.retryCounts {
+		retryCounts[method] = int64(count)
+	}
+	metrics = map[string]any{
+		"total_requests":     totalRequests,
+		"success_requests":   successRequests,
+		"failed_requests":    failedRequests,
+		"error_counts":       errorCounts,
+		"retry_counts":       retryCounts,
+		"active_requests":    activeRequests,
+		"circuit_states":     maps.Clone(c.circuitStates),
+		"start_time":         c.startTime,
+		"success_rates":      successRates,
+		"avg_response_times": avgResponseTimes,
+		"uptime_seconds":     time.Since(c.startTime).Seconds(),
+	}
+	return
+}
+
+// Reset 重置指标数据
+func (c *PrometheusMetricsCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.successRequests = make(map[string]uint64)
+	c.failedRequests = make(map[string]uint64)
+	c.activeRequests = make(map[string]int64)
+	c.durations = make(map[string]*histogram)
+	c.errorCounts = make(map[[2]string]uint64)
+	c.retryCounts = make(map[string]uint64)
+	c.circuitStates = make(map[string]CircuitState)
+	c.startTime = time.Now()
+}
+
+// Collect 采集指标，实现 PrometheusCollector
+func (c *PrometheusMetricsCollector) Collect() (families []*MetricFamily) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var (
+		ns       = c.config.Namespace
+		requests = &MetricFamily{Name: ns + "_requests_total", Help: "Total number of requests by method and result.", Type: MetricTypeCounter}
+		inFlight = &MetricFamily{Name: ns + "_requests_in_flight", Help: "Number of requests currently in flight.", Type: MetricTypeGauge}
+		duration = &MetricFamily{Name: ns + "_request_duration_seconds", Help: "Request duration in seconds.", Type: MetricTypeHistogram}
+		errs     = &MetricFamily{Name: ns + "_errors_total", Help: "Total number of errors by method and error type.", Type: MetricTypeCounter}
+		retries  = &MetricFamily{Name: ns + "_retries_total", Help: "Total number of retries.", Type: MetricTypeCounter}
+		circuits = &MetricFamily{Name: ns + "_circuit_state", Help: "Circuit breaker state, 1 for the current state.", Type: MetricTypeGauge}
+	)
+	for _, method := range slices.Sorted(maps.Keys(c.durations)) {
+		h := c.durations[method]
+		requests.Samples = append(requests.Samples,
+			&MetricSample{Labels: c.labels("method", method, "result", "success"), Value: float64(c.successRequests[method])},
+			&MetricSample{Labels: c.labels("method", method, "result", "failure"), Value: float64(c.failedRequests[method])},
+		)
+		// 转换为累计计数
+		cumulative := make([]uint64, len(h.counts))
+		var total uint64
+		for i, count := range h.counts {
+			total += count
+			cumulative[i] = total
+		}
+		duration.Samples = append(duration.Samples, &MetricSample{
+			Labels: c.labels("method", method),
+			Histogram: &HistogramSample{
+				Buckets: c.config.Buckets,
+				Counts:  cumulative,
+				Sum:     h.sum,
+				Count:   h.count,
+			},
+		})
+	}
+	for _, method := range slices.Sorted(maps.Keys(c.activeRequests)) {
+		inFlight.Samples = append(inFlight.Samples, &MetricSample{Labels: c.labels("method", method), Value: float64(c.activeRequests[method])})
+	}
+	for _, key := range slices.SortedFunc(maps.Keys(c.errorCounts), func(a, b [2]string) int {
+		return cmp.Or(strings.Compare(a[0], b[0]), strings.Compare(a[1], b[1]))
+	}) {
+		errs.Samples = append(errs.Samples, &MetricSample{Labels: c.labels("method", key[0], "error_type", key[1]), Value: float64(c.errorCounts[key])})
+	}
+	for _, method := range slices.Sorted(maps.Keys(c.retryCounts)) {
+		retries.Samples = append(retries.Samples, &MetricSample{Labels: c.labels("method", method), Value: float64(c.retryCounts[method])})
+	}
+	for _, key := range slices.Sorted(maps.Keys(c.circuitStates)) {
+		for _, state := range []CircuitState{CircuitStateClosed, CircuitStateOpen, CircuitStateHalfOpen} {
+			var value float64
+			if c.circuitStates[key] == state {
+				value = 1
+			}
+			circuits.Samples = append(circuits.Samples, &MetricSample{Labels: c.labels("key", key, "state", string(state)), Value: value})
+		}
+	}
+	return []*MetricFamily{requests, inFlight, duration, errs, retries, circuits}
+}
+
+// labels 生成标签，包含固定标签
+func (c *PrometheusMetricsCollector) labels(kvs ...string) (labels map[string]string) {
+	labels = make(map[string]string, len(c.config.ConstLabels)+len(kvs)/2)
+	maps.Copy(labels, c.config.ConstLabels)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		labels[kvs[i]] = kvs[i+1]
+	}
+	return
+}
@@ -0,0 +1,98 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 15:16:40
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 15:16:40
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"context"
+	"errors"
+	"github.com/liusuxian/go-toolkit/gtkcache"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	var (
+		assert    = assert.New(t)
+		ctx       = context.Background()
+		collector = gtkhttp.NewPrometheusMetricsCollector(gtkhttp.PrometheusMetricsConfig{
+			Buckets:     []float64{0.1, 0.01, 1},
+			ConstLabels: map[string]string{"service": "chat"},
+		})
+		registry = gtkhttp.NewMetricsRegistry()
+		cache    = gtkcache.NewMemoryCache()
+	)
+	defer cache.Close(ctx)
+	// 记录请求
+	collector.RecordRequestStart("Chat")
+	collector.RecordRequestComplete("Chat", 5, true)
+	collector.RecordRequestStart("Chat")
+	collector.RecordRequestComplete("Chat", 500, false)
+	collector.RecordError("Chat", `say "hi"`)
+	collector.RecordRetry("Chat", 2)
+	collector.RecordRequestStart("Chat")
+	collector.RecordCircuitStateChange("Chat", gtkhttp.CircuitStateClosed, gtkhttp.CircuitStateOpen)
+	// 通过中间件记录
+	client := gtkhttp.NewMWClient(
+		gtkhttp.WithMetrics(gtkhttp.MetricsMiddlewareConfig{Collector: collector}),
+		gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+	)
+	_, err := client.HandlerRequest(ctx, "user", "Embedding", nil, func(ctx context.Context, _ any) (resp any, err error) {
+		return nil, errors.New("boom")
+	})
+	assert.Error(err)
+	// 缓存指标注册到同一个注册表
+	cache.Set(ctx, "k", "v")
+	registry.Register(collector)
+	registry.RegisterGaugeFunc("gtkcache_size", "Number of keys in the cache.", map[string]string{"cache": "memory"}, func() (value float64) {
+		size, _ := cache.Size(ctx)
+		return float64(size)
+	})
+
+	w := httptest.NewRecorder()
+	registry.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal("text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	for _, line := range []string{
+		`# HELP gtkcache_size Number of keys in the cache.`,
+		`# TYPE gtkcache_size gauge`,
+		`gtkcache_size{cache="memory"} 1`,
+		`# TYPE gtkhttp_requests_total counter`,
+		`gtkhttp_requests_total{method="Chat",result="success",service="chat"} 1`,
+		`gtkhttp_requests_total{method="Chat",result="failure",service="chat"} 1`,
+		`gtkhttp_requests_total{method="Embedding",result="failure",service="chat"} 1`,
+		`gtkhttp_requests_in_flight{method="Chat",service="chat"} 1`,
+		`# TYPE gtkhttp_request_duration_seconds histogram`,
+		`gtkhttp_request_duration_seconds_bucket{method="Chat",service="chat",le="0.01"} 1`,
+		`gtkhttp_request_duration_seconds_bucket{method="Chat",service="chat",le="0.1"} 1`,
+		`gtkhttp_request_duration_seconds_bucket{method="Chat",service="chat",le="1"} 2`,
+		`gtkhttp_request_duration_seconds_bucket{method="Chat",service="chat",le="+Inf"} 2`,
+		`gtkhttp_request_duration_seconds_sum{method="Chat",service="chat"} 0.505`,
+		`gtkhttp_request_duration_seconds_count{method="Chat",service="chat"} 2`,
+		`gtkhttp_errors_total{error_type="say \"hi\"",method="Chat",service="chat"} 1`,
+		`gtkhttp_errors_total{error_type="unknown",method="Embedding",service="chat"} 1`,
+		`gtkhttp_retries_total{method="Chat",service="chat"} 2`,
+		`gtkhttp_circuit_state{key="Chat",service="chat",state="open"} 1`,
+		`gtkhttp_circuit_state{key="Chat",service="chat",state="closed"} 0`,
+	} {
+		assert.Contains(body, line+"\n")
+	}
+	// 指标族按名称排序
+	assert.Less(strings.Index(body, "gtkcache_size"), strings.Index(body, "gtkhttp_circuit_state"))
+
+	metrics := collector.GetMetrics()
+	assert.Equal(int64(3), metrics["total_requests"].(map[string]int64)["Chat"])
+	assert.Equal(float64(50), metrics["success_rates"].(map[string]float64)["Chat"])
+	collector.Reset()
+	assert.Empty(collector.GetMetrics()["total_requests"])
+}
@@ -0,0 +1,250 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 15:16:40
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 15:16:40
+ * @Description: 指标注册表，以 Prometheus 文本格式输出指标
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MetricType 指标类型
+type MetricType string
+
+const (
+	MetricTypeCounter   MetricType = "counter"   // 计数器
+	MetricTypeGauge     MetricType = "gauge"     // 仪表盘
+	MetricTypeHistogram MetricType = "histogram" // 直方图
+)
+
+// MetricFamily 指标族，同名指标的所有样本
+type MetricFamily struct {
+	Name    string          // 指标名称
+	Help    string          // 指标说明
+	Type    MetricType      // 指标类型
+	Samples []*MetricSample // 样本列表
+}
+
+// MetricSample 指标样本
+type MetricSample struct {
+	Labels    map[string]string // 标签
+	Value     float64           // 值，直方图时忽略
+	Histogram *HistogramSample  // 直方图数据，仅 MetricTypeHistogram 有效
+}
+
+// HistogramSample 直方图样本
+type HistogramSample struct {
+	Buckets []float64 // 桶的上限，升序排列，不包含 +Inf
+	Counts  []uint64  // 每个桶的累计计数，与 Buckets 一一对应
+	Sum     float64   // 观测值总和
+	Count   uint64    // 观测次数
+}
+
+// PrometheusCollector 指标采集器接口，注册到 MetricsRegistry 后在每次输出时采集
+type PrometheusCollector interface {
+	Collect() (families []*MetricFamily) // 采集指标
+}
+
+// CollectorFunc 指标采集函数
+type CollectorFunc func() (families []*MetricFamily)
+
+// Collect 采集指标
+func (f CollectorFunc) Collect() (families []*MetricFamily) {
+	return f()
+}
+
+// MetricsRegistry 指标注册表，汇总多个采集器的指标，并以 Prometheus 文本格式输出
+//
+//	HTTP 客户端指标、缓存、消息队列等组件的指标都可以注册到同一个注册表，通过一个 /metrics 接口暴露
+type MetricsRegistry struct {
+	mu         sync.RWMutex
+	collectors []PrometheusCollector
+}
+
+// NewMetricsRegistry 创建指标注册表
+func NewMetricsRegistry() (r *MetricsRegistry) {
+	return &MetricsRegistry{}
+}
+
+// Register 注册指标采集器
+func (r *MetricsRegistry) Register(collectors ...PrometheusCollector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, collectors...)
+}
+
+// RegisterGaugeFunc 注册仪表盘指标，每次输出时调用 fn 获取当前值，如缓存条目数、队列长度
+func (r *MetricsRegistry) RegisterGaugeFunc(name, help string, labels map[string]string, fn func() (value float64)) {
+	r.registerFunc(name, help, MetricTypeGauge, labels, fn)
+}
+
+// RegisterCounterFunc 注册计数器指标，每次输出时调用 fn 获取当前值，fn 返回的值应单调递增，如缓存命中次数、已消费消息数
+func (r *MetricsRegistry) RegisterCounterFunc(name, help string, labels map[string]string, fn func() (value float64)) {
+	r.registerFunc(name, help, MetricTypeCounter, labels, fn)
+}
+
+// registerFunc 注册单值指标
+func (r *MetricsRegistry) registerFunc(name, help string, metricType MetricType, labels map[string]string, fn func() (value float64)) {
+	r.Register(CollectorFunc(func() (families []*MetricFamily) {
+		return []*MetricFamily{{
+			Name:    name,
+			Help:    help,
+			Type:    metricType,
+			Samples: []*MetricSample{{Labels: labels, Value: fn()}},
+		}}
+	}))
+}
+
+// Gather 采集所有指标，同名指标族合并样本，按名称排序
+func (r *MetricsRegistry) Gather() (families []*MetricFamily) {
+	r.mu.RLock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.RUnlock()
+
+	familyMap := make(map[string]*MetricFamily)
+	for _, collector := range collectors {
+		for _, family := range collector.Collect() {
+			if family == nil || family.Name == "" {
+				continue
+			}
+			name := sanitizeMetricName(family.Name)
+			if exists, ok := familyMap[name]; ok {
+				exists.Samples = append(exists.Samples, family.Samples...)
+				continue
+			}
+			merged := &MetricFamily{
+				Name:    name,
+				Help:    family.Help,
+				Type:    family.Type,
+				Samples: slices.Clone(family.Samples),
+			}
+			familyMap[name] = merged
+			families = append(families, merged)
+		}
+	}
+	slices.SortFunc(families, func(a, b *MetricFamily) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return
+}
+
+// WriteTo 以 Prometheus 文本格式输出所有指标
+func (r *MetricsRegistry) WriteTo(w io.Writer) (n int64, err error) {
+	var buf bytes.Buffer
+	for _, family := range r.Gather() {
+		writeMetricFamily(&buf, family)
+	}
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP 实现 http.Handler，以 Prometheus 文本格式输出所有指标，如 http.Handle("/metrics", registry)
+func (r *MetricsRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// writeMetricFamily 输出指标族
+func writeMetricFamily(w *bytes.Buffer, family *MetricFamily) {
+	metricType := family.Type
+	if metricType == "" {
+		metricType = MetricTypeGauge
+	}
+	if family.Help != "" {
+		w.WriteString("# HELP " + family.Name + " " + escapeMetricHelp(family.Help) + "\n")
+	}
+	w.WriteString("# TYPE " + family.Name + " " + string(metricType) + "\n")
+	for _, sample := range family.Samples {
+		if sample == nil {
+			continue
+		}
+		if metricType != MetricTypeHistogram || sample.Histogram == nil {
+			writeMetricLine(w, family.Name, sample.Labels, "", "", sample.Value)
+			continue
+		}
+		h := sample.Histogram
+		for i, bucket := range h.Buckets {
+			var count uint64
+			if i < len(h.Counts) {
+				count = h.Counts[i]
+			}
+			writeMetricLine(w, family.Name+"_bucket", sample.Labels, "le", formatMetricValue(bucket), float64(count))
+		}
+		writeMetricLine(w, family.Name+"_bucket", sample.Labels, "le", "+Inf", float64(h.Count))
+		writeMetricLine(w, family.Name+"_sum", sample.Labels, "", "", h.Sum)
+		writeMetricLine(w, family.Name+"_count", sample.Labels, "", "", float64(h.Count))
+	}
+}
+
+// writeMetricLine 输出一行样本，extraKey 不为空时追加一个标签（如直方图的 le）
+func writeMetricLine(w *bytes.Buffer, name string, labels map[string]string, extraKey, extraValue string, value float64) {
+	w.WriteString(name)
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	if len(keys) > 0 || extraKey != "" {
+		w.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(sanitizeMetricName(key) + `="` + escapeLabelValue(labels[key]) + `"`)
+		}
+		if extraKey != "" {
+			if len(keys) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraKey + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteString(" " + formatMetricValue(value) + "\n")
+}
+
+// formatMetricValue 格式化样本值
+func formatMetricValue(value float64) (s string) {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// sanitizeMetricName 将指标名称和标签名称中不合法的字符替换为下划线
+func sanitizeMetricName(name string) (s string) {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// escapeMetricHelp 转义指标说明
+func escapeMetricHelp(help string) (s string) {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// escapeLabelValue 转义标签值
+func escapeLabelValue(value string) (s string) {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
@@ -0,0 +1,162 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 17:02:45
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 17:02:45
+ * @Description: 舱壁中间件（并发数限制）
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BulkheadMiddlewareConfig 舱壁中间件配置
+type BulkheadMiddlewareConfig struct {
+	KeyFunc       MiddlewareKeyFunc // 并发配额键函数，默认 KeyByMethod
+	MaxConcurrent int               // 最大并发请求数，默认 10
+	MaxQueue      int               // 最大排队请求数，默认 0 表示不限制
+	QueueTimeout  time.Duration     // 排队超时时间，默认 0 表示不排队，并发已满时直接拒绝
+	Collector     MetricsCollector  // 指标收集器，记录被拒绝的请求
+}
+
+// BulkheadMiddleware 舱壁中间件
+type BulkheadMiddleware struct {
+	config    BulkheadMiddlewareConfig
+	bulkheads map[string]*bulkhead // 并发配额键 -> 舱壁
+	mu        sync.Mutex           // 互斥锁
+}
+
+// bulkhead 舱壁
+type bulkhead struct {
+	slots   chan struct{} // 并发槽位
+	waiting int           // 排队请求数
+	mu      sync.Mutex    // 互斥锁
+}
+
+// NewBulkheadMiddleware 创建舱壁中间件
+func NewBulkheadMiddleware(config BulkheadMiddlewareConfig) (bm *BulkheadMiddleware) {
+	// 设置并发配额键函数，默认 KeyByMethod
+	if config.KeyFunc == nil {
+		config.KeyFunc = KeyByMethod
+	}
+	// 设置最大并发请求数，默认 10
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = 10
+	}
+	// 设置最大排队请求数，默认 0 表示不限制
+	if config.MaxQueue < 0 {
+		config.MaxQueue = 0
+	}
+	// 设置排队超时时间，默认 0 表示不排队
+	if config.QueueTimeout < 0 {
+		config.QueueTimeout = 0
+	}
+	return &BulkheadMiddleware{
+		config:    config,
+		bulkheads: make(map[string]*bulkhead),
+	}
+}
+
+// Process 处理请求
+func (m *BulkheadMiddleware) Process(ctx context.Context, request any, next MWHandler) (response any, err error) {
+	// 从上下文中获取请求信息
+	requestInfo := GetRequestInfo(ctx)
+	var (
+		key = m.config.KeyFunc(ctx, requestInfo)
+		bh  = m.getBulkhead(key)
+	)
+	if err = m.acquire(ctx, key, bh); err != nil {
+		if m.config.Collector != nil && IsBulkheadFullError(err) {
+			m.config.Collector.RecordError(requestInfo.Method, "bulkhead_full")
+		}
+		return
+	}
+	defer bh.release()
+	// 执行下一个处理器
+	return next(ctx, request)
+}
+
+// Name 返回中间件名称
+func (m *BulkheadMiddleware) Name() (name string) {
+	return "bulkhead"
+}
+
+// Priority 返回中间件优先级
+func (m *BulkheadMiddleware) Priority() (priority int) {
+	return 50 // 舱壁中间件在重试、熔断和限流中间件之后执行，重试的退避等待期间不占用并发槽位
+}
+
+// InFlight 获取指定键当前正在执行的请求数
+func (m *BulkheadMiddleware) InFlight(key string) (n int) {
+	m.mu.Lock()
+	bh, ok := m.bulkheads[key]
+	m.mu.Unlock()
+	if !ok {
+		return 0
+	}
+	return len(bh.slots)
+}
+
+// acquire 获取并发槽位，并发已满时按配置排队或拒绝
+func (m *BulkheadMiddleware) acquire(ctx context.Context, key string, bh *bulkhead) (err error) {
+	// 尝试直接获取槽位
+	select {
+	case bh.slots <- struct{}{}:
+		return
+	default:
+	}
+	// 不排队，直接拒绝
+	if m.config.QueueTimeout == 0 {
+		return fmt.Errorf("%w: %s", ErrBulkheadFull, key)
+	}
+	// 检查排队请求数
+	bh.mu.Lock()
+	if m.config.MaxQueue > 0 && bh.waiting >= m.config.MaxQueue {
+		bh.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrBulkheadFull, key)
+	}
+	bh.waiting++
+	bh.mu.Unlock()
+	defer func() {
+		bh.mu.Lock()
+		bh.waiting--
+		bh.mu.Unlock()
+	}()
+	// 排队等待槽位
+	timer := time.NewTimer(m.config.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case bh.slots <- struct{}{}:
+		return
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return fmt.Errorf("%w: %s, queue timeout", ErrBulkheadFull, key)
+	}
+}
+
+// getBulkhead 获取指定键的舱壁，不存在时创建
+func (m *BulkheadMiddleware) getBulkhead(key string) (bh *bulkhead) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ok bool
+	if bh, ok = m.bulkheads[key]; !ok {
+		bh = &bulkhead{
+			slots: make(chan struct{}, m.config.MaxConcurrent),
+		}
+		m.bulkheads[key] = bh
+	}
+	return
+}
+
+// release 释放并发槽位
+func (bh *bulkhead) release() {
+	<-bh.slots
+}
@@ -0,0 +1,110 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 17:36:08
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 17:36:08
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"context"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBulkheadMiddleware(t *testing.T) {
+	var (
+		assert    = assert.New(t)
+		ctx       = context.Background()
+		collector = gtkhttp.NewDefaultMetricsCollector()
+		bh        = gtkhttp.NewBulkheadMiddleware(gtkhttp.BulkheadMiddlewareConfig{
+			MaxConcurrent: 2,
+			Collector:     collector,
+		})
+		client = gtkhttp.NewMWClient(
+			gtkhttp.WithMiddleware(bh),
+			gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+		)
+		started = make(chan struct{}, 2)
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	blockingHandler := func(ctx context.Context, req any) (resp any, err error) {
+		started <- struct{}{}
+		<-release
+		return "ok", nil
+	}
+	for range 2 {
+		wg.Go(func() {
+			_, err := client.HandlerRequest(ctx, "user", "Chat", nil, blockingHandler)
+			assert.NoError(err)
+		})
+	}
+	<-started
+	<-started
+	assert.Equal(2, bh.InFlight("Chat"))
+	// 并发已满且不排队时直接拒绝
+	_, err := client.HandlerRequest(ctx, "user", "Chat", nil, okHandler)
+	assert.True(gtkhttp.IsBulkheadFullError(err))
+	// 不同键不受影响
+	_, err = client.HandlerRequest(ctx, "user", "Embedding", nil, okHandler)
+	assert.NoError(err)
+	close(release)
+	wg.Wait()
+	assert.Equal(0, bh.InFlight("Chat"))
+	assert.Equal(int64(1), collector.GetMetrics()["error_counts"].(map[string]int64)["Chat:bulkhead_full"])
+}
+
+func TestBulkheadMiddlewareQueue(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		client = gtkhttp.NewMWClient(
+			gtkhttp.WithBulkhead(gtkhttp.BulkheadMiddlewareConfig{
+				MaxConcurrent: 1,
+				MaxQueue:      1,
+				QueueTimeout:  time.Second,
+			}),
+			gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+		)
+		running atomic.Int32
+		maxSeen atomic.Int32
+		wg      sync.WaitGroup
+		errs    = make(chan error, 3)
+	)
+	handler := func(ctx context.Context, req any) (resp any, err error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		if n > maxSeen.Load() {
+			maxSeen.Store(n)
+		}
+		time.Sleep(50 * time.Millisecond)
+		return "ok", nil
+	}
+	// 1 个执行、1 个排队、1 个因队列已满被拒绝
+	for range 3 {
+		wg.Go(func() {
+			_, err := client.HandlerRequest(ctx, "user", "Chat", nil, handler)
+			errs <- err
+		})
+		time.Sleep(10 * time.Millisecond)
+	}
+	wg.Wait()
+	close(errs)
+	var rejected int
+	for err := range errs {
+		if err != nil {
+			assert.True(gtkhttp.IsBulkheadFullError(err))
+			rejected++
+		}
+	}
+	assert.Equal(1, rejected)
+	assert.Equal(int32(1), maxSeen.Load())
+}
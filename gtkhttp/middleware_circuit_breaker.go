@@ -0,0 +1,349 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 15:10:27
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 15:10:27
+ * @Description: 熔断中间件
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitState 熔断器状态
+type CircuitState string
+
+const (
+	CircuitStateClosed   CircuitState = "closed"    // 关闭，请求正常通过
+	CircuitStateOpen     CircuitState = "open"      // 打开，请求被直接拒绝
+	CircuitStateHalfOpen CircuitState = "half_open" // 半开，允许少量探测请求通过
+)
+
+// CircuitBreakerFailureCondition 熔断失败判断函数，返回 true 表示该错误计入失败
+type CircuitBreakerFailureCondition func(err error) (ok bool)
+
+// CircuitStateChangeCallback 熔断器状态变化回调函数（同步执行，建议仅用于轻量级操作）
+type CircuitStateChangeCallback func(key string, from, to CircuitState)
+
+// CircuitStateRecorder 熔断器状态记录器接口，MetricsCollector 实现该接口时会记录熔断器状态变化
+type CircuitStateRecorder interface {
+	RecordCircuitStateChange(key string, from, to CircuitState) // 记录熔断器状态变化
+}
+
+// CircuitBreakerMiddlewareConfig 熔断中间件配置
+type CircuitBreakerMiddlewareConfig struct {
+	KeyFunc             MiddlewareKeyFunc              // 熔断器键函数，默认 KeyByMethod
+	FailureThreshold    int                            // 连续失败次数阈值，达到后打开熔断器，默认 5
+	ErrorRateThreshold  float64                        // 错误率阈值（范围0-1），统计窗口内错误率达到后打开熔断器，默认 0.5
+	MinRequests         int                            // 统计窗口内的最小请求数，达到后才按错误率判断，默认 20
+	Window              time.Duration                  // 关闭状态下的统计窗口，窗口结束后清空计数，默认 60s
+	CoolDown            time.Duration                  // 打开状态的冷却时间，冷却结束后进入半开状态，默认 30s
+	HalfOpenMaxRequests int                            // 半开状态允许通过的探测请求数，全部成功后关闭熔断器，默认 1
+	Condition           CircuitBreakerFailureCondition // 熔断失败判断函数，默认 DefaultCircuitBreakerFailureCondition
+	OnStateChange       CircuitStateChangeCallback     // 状态变化回调函数
+	Collector           MetricsCollector               // 指标收集器，记录被拒绝的请求和状态变化
+}
+
+// CircuitBreakerMiddleware 熔断中间件
+type CircuitBreakerMiddleware struct {
+	config   CircuitBreakerMiddlewareConfig
+	breakers map[string]*circuitBreaker // 熔断器键 -> 熔断器
+	mu       sync.Mutex                 // 互斥锁
+}
+
+// circuitCounts 熔断器计数
+type circuitCounts struct {
+	requests             int // 请求数
+	failures             int // 失败数
+	consecutiveFailures  int // 连续失败数
+	consecutiveSuccesses int // 连续成功数
+}
+
+// circuitBreaker 熔断器
+type circuitBreaker struct {
+	key        string                          // 熔断器键
+	config     *CircuitBreakerMiddlewareConfig // 熔断中间件配置
+	state      CircuitState                    // 当前状态
+	generation uint64                          // 状态代数，每次状态变化或窗口重置时递增，用于忽略过期的请求结果
+	counts     circuitCounts                   // 当前代的计数
+	expiry     time.Time                       // 当前代的过期时间（关闭状态为窗口结束时间，打开状态为冷却结束时间）
+	inFlight   int                             // 半开状态下正在执行的探测请求数
+	mu         sync.Mutex                      // 互斥锁
+}
+
+// NewCircuitBreakerMiddleware 创建熔断中间件
+func NewCircuitBreakerMiddleware(config CircuitBreakerMiddlewareConfig) (cb *CircuitBreakerMiddleware) {
+	// 设置熔断器键函数，默认 KeyByMethod
+	if config.KeyFunc == nil {
+		config.KeyFunc = KeyByMethod
+	}
+	// 设置连续失败次数阈值，默认 5
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	// 设置错误率阈值（范围0-1），默认 0.5
+	if config.ErrorRateThreshold <= 0 || config.ErrorRateThreshold > 1 {
+		config.ErrorRateThreshold = 0.5
+	}
+	// 设置统计窗口内的最小请求数，默认 20
+	if config.MinRequests <= 0 {
+		config.MinRequests = 20
+	}
+	// 设置统计窗口，默认 60s
+	if config.Window <= 0 {
+		config.Window = 60 * time.Second
+	}
+	// 设置冷却时间，默认 30s
+	if config.CoolDown <= 0 {
+		config.CoolDown = 30 * time.Second
+	}
+	// 设置半开状态允许通过的探测请求数，默认 1
+	if config.HalfOpenMaxRequests <= 0 {
+		config.HalfOpenMaxRequests = 1
+	}
+	// 设置熔断失败判断函数
+	if config.Condition == nil {
+		config.Condition = DefaultCircuitBreakerFailureCondition
+	}
+	return &CircuitBreakerMiddleware{
+		config:   config,
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+// Process 处理请求
+func (m *CircuitBreakerMiddleware) Process(ctx context.Context, request any, next MWHandler) (response any, err error) {
+	// 从上下文中获取请求信息
+	requestInfo := GetRequestInfo(ctx)
+	breaker := m.getBreaker(m.config.KeyFunc(ctx, requestInfo))
+	// 判断是否允许请求通过
+	generation, allowErr := breaker.allow()
+	if allowErr != nil {
+		if m.config.Collector != nil {
+			m.config.Collector.RecordError(requestInfo.Method, "circuit_open")
+		}
+		return nil, allowErr
+	}
+	// 执行下一个处理器
+	response, err = next(ctx, request)
+	// 记录请求结果
+	breaker.done(generation, err)
+	return
+}
+
+// Name 返回中间件名称
+func (m *CircuitBreakerMiddleware) Name() (name string) {
+	return "circuit_breaker"
+}
+
+// Priority 返回中间件优先级
+func (m *CircuitBreakerMiddleware) Priority() (priority int) {
+	return 30 // 熔断中间件在重试中间件之后执行，每次重试都会经过熔断判断
+}
+
+// State 获取指定键的熔断器状态，不存在时返回关闭状态
+func (m *CircuitBreakerMiddleware) State(key string) (state CircuitState) {
+	m.mu.Lock()
+	breaker, ok := m.breakers[key]
+	m.mu.Unlock()
+	if !ok {
+		return CircuitStateClosed
+	}
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	return breaker.currentState(time.Now())
+}
+
+// Reset 重置指定键的熔断器为关闭状态
+func (m *CircuitBreakerMiddleware) Reset(key string) {
+	m.mu.Lock()
+	breaker, ok := m.breakers[key]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	if breaker.state == CircuitStateClosed {
+		breaker.newGeneration(time.Now())
+		return
+	}
+	breaker.setState(CircuitStateClosed, time.Now())
+}
+
+// getBreaker 获取指定键的熔断器，不存在时创建
+func (m *CircuitBreakerMiddleware) getBreaker(key string) (breaker *circuitBreaker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ok bool
+	if breaker, ok = m.breakers[key]; !ok {
+		breaker = &circuitBreaker{
+			key:    key,
+			config: &m.config,
+			state:  CircuitStateClosed,
+			expiry: time.Now().Add(m.config.Window),
+		}
+		m.breakers[key] = breaker
+	}
+	return
+}
+
+// allow 判断是否允许请求通过，返回当前代数
+func (b *circuitBreaker) allow() (generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState(time.Now()) {
+	case CircuitStateOpen:
+		return b.generation, fmt.Errorf("%w: %s", ErrCircuitOpen, b.key)
+	case CircuitStateHalfOpen:
+		if b.inFlight >= b.config.HalfOpenMaxRequests {
+			return b.generation, fmt.Errorf("%w: %s", ErrCircuitOpen, b.key)
+		}
+		b.inFlight++
+	}
+	b.counts.requests++
+	return b.generation, nil
+}
+
+// done 记录请求结果
+func (b *circuitBreaker) done(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	state := b.currentState(now)
+	// 忽略过期的请求结果
+	if generation != b.generation {
+		return
+	}
+	if state == CircuitStateHalfOpen && b.inFlight > 0 {
+		b.inFlight--
+	}
+	switch {
+	case err == nil:
+		b.onSuccess(state, now)
+	case isIgnoredCircuitError(err):
+		// 请求没有到达上游，既不计入成功也不计入失败
+		if b.counts.requests > 0 {
+			b.counts.requests--
+		}
+	case b.config.Condition(err):
+		b.onFailure(state, now)
+	default:
+		b.onSuccess(state, now)
+	}
+}
+
+// isIgnoredCircuitError 判断是否是熔断器忽略的错误，取消的请求和被本地拒绝的请求（如限流、并发数已满）无法反映上游的状态
+func isIgnoredCircuitError(err error) (ok bool) {
+	return isLocalRejectionError(err) || Classify(err).Kind == ErrorKindCanceled
+}
+
+// onSuccess 处理成功的请求
+func (b *circuitBreaker) onSuccess(state CircuitState, now time.Time) {
+	b.counts.consecutiveFailures = 0
+	b.counts.consecutiveSuccesses++
+	// 半开状态下探测请求全部成功，关闭熔断器
+	if state == CircuitStateHalfOpen && b.counts.consecutiveSuccesses >= b.config.HalfOpenMaxRequests {
+		b.setState(CircuitStateClosed, now)
+	}
+}
+
+// onFailure 处理失败的请求
+func (b *circuitBreaker) onFailure(state CircuitState, now time.Time) {
+	b.counts.failures++
+	b.counts.consecutiveFailures++
+	b.counts.consecutiveSuccesses = 0
+	switch state {
+	case CircuitStateClosed:
+		if b.shouldTrip() {
+			b.setState(CircuitStateOpen, now)
+		}
+	case CircuitStateHalfOpen:
+		// 半开状态下探测请求失败，重新打开熔断器
+		b.setState(CircuitStateOpen, now)
+	}
+}
+
+// shouldTrip 判断是否需要打开熔断器
+func (b *circuitBreaker) shouldTrip() (ok bool) {
+	if b.counts.consecutiveFailures >= b.config.FailureThreshold {
+		return true
+	}
+	if b.counts.requests >= b.config.MinRequests {
+		return float64(b.counts.failures)/float64(b.counts.requests) >= b.config.ErrorRateThreshold
+	}
+	return false
+}
+
+// currentState 获取当前状态，处理统计窗口的重置和冷却结束
+func (b *circuitBreaker) currentState(now time.Time) (state CircuitState) {
+	switch b.state {
+	case CircuitStateClosed:
+		if !b.expiry.IsZero() && now.After(b.expiry) {
+			b.newGeneration(now)
+		}
+	case CircuitStateOpen:
+		if now.After(b.expiry) {
+			b.setState(CircuitStateHalfOpen, now)
+		}
+	}
+	return b.state
+}
+
+// setState 设置状态
+func (b *circuitBreaker) setState(state CircuitState, now time.Time) {
+	if b.state == state {
+		return
+	}
+	from := b.state
+	b.state = state
+	b.newGeneration(now)
+	if b.config.OnStateChange != nil {
+		b.config.OnStateChange(b.key, from, state)
+	}
+	if recorder, ok := b.config.Collector.(CircuitStateRecorder); ok {
+		recorder.RecordCircuitStateChange(b.key, from, state)
+	}
+}
+
+// newGeneration 开始新的一代
+func (b *circuitBreaker) newGeneration(now time.Time) {
+	b.generation++
+	b.counts = circuitCounts{}
+	b.inFlight = 0
+	switch b.state {
+	case CircuitStateClosed:
+		b.expiry = now.Add(b.config.Window)
+	case CircuitStateOpen:
+		b.expiry = now.Add(b.config.CoolDown)
+	default:
+		b.expiry = time.Time{}
+	}
+}
+
+// DefaultCircuitBreakerFailureCondition 默认熔断失败判断函数
+//
+//	取消的请求、被本地拒绝的请求和非服务端原因导致的 HTTP 错误（如 400、401、404）不计入失败
+func DefaultCircuitBreakerFailureCondition(err error) (ok bool) {
+	if err == nil || isLocalRejectionError(err) {
+		return false
+	}
+	te := Classify(err)
+	if te.Kind == ErrorKindCanceled {
+		return false
+	}
+	// HTTP 错误只有服务端错误和可重试的状态码计入失败
+	if te.StatusCode >= 400 {
+		return te.StatusCode >= 500 || isRetryableHTTPError(err)
+	}
+	return true
+}
@@ -0,0 +1,209 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 15:52:14
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 15:52:14
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixedRequestIDGenerator 固定请求ID生成器
+type fixedRequestIDGenerator struct{}
+
+func (g *fixedRequestIDGenerator) RequestID() (requestId string, err error) {
+	return "test-request-id", nil
+}
+
+func TestCircuitBreakerMiddleware(t *testing.T) {
+	var (
+		assert    = assert.New(t)
+		ctx       = context.Background()
+		mu        sync.Mutex
+		changes   []gtkhttp.CircuitState
+		collector = gtkhttp.NewDefaultMetricsCollector()
+		cb        = gtkhttp.NewCircuitBreakerMiddleware(gtkhttp.CircuitBreakerMiddlewareConfig{
+			FailureThreshold: 3,
+			CoolDown:         50 * time.Millisecond,
+			OnStateChange: func(key string, from, to gtkhttp.CircuitState) {
+				mu.Lock()
+				defer mu.Unlock()
+				changes = append(changes, to)
+			},
+			Collector: collector,
+		})
+		client = gtkhttp.NewMWClient(
+			gtkhttp.WithMiddleware(cb),
+			gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+		)
+		calls   int
+		failErr = &gtkhttp.APIError{HTTPStatusCode: http.StatusServiceUnavailable, Message: "unavailable"}
+		handler = func(fail bool) func(ctx context.Context, req any) (resp any, err error) {
+			return func(ctx context.Context, req any) (resp any, err error) {
+				calls++
+				if fail {
+					return nil, failErr
+				}
+				return "ok", nil
+			}
+		}
+	)
+	// 连续失败达到阈值后打开熔断器
+	for range 3 {
+		_, err := client.HandlerRequest(ctx, "user", "Chat", nil, handler(true))
+		assert.ErrorIs(err, failErr)
+	}
+	assert.Equal(gtkhttp.CircuitStateOpen, cb.State("Chat"))
+	assert.Equal(gtkhttp.CircuitStateClosed, cb.State("Other"))
+	// 打开状态下请求被直接拒绝
+	_, err := client.HandlerRequest(ctx, "user", "Chat", nil, handler(false))
+	assert.True(gtkhttp.IsCircuitOpenError(err))
+	assert.Equal(3, calls)
+	// 不同键的请求不受影响
+	resp, err := client.HandlerRequest(ctx, "user", "Other", nil, handler(false))
+	assert.NoError(err)
+	assert.Equal("ok", resp)
+	// 冷却结束后进入半开状态，探测成功后关闭熔断器
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(gtkhttp.CircuitStateHalfOpen, cb.State("Chat"))
+	resp, err = client.HandlerRequest(ctx, "user", "Chat", nil, handler(false))
+	assert.NoError(err)
+	assert.Equal("ok", resp)
+	assert.Equal(gtkhttp.CircuitStateClosed, cb.State("Chat"))
+	// 客户端错误不计入失败
+	for range 5 {
+		_, err = client.HandlerRequest(ctx, "user", "Chat", nil, func(ctx context.Context, req any) (resp any, err error) {
+			return nil, &gtkhttp.APIError{HTTPStatusCode: http.StatusBadRequest, Message: "bad request"}
+		})
+		assert.Error(err)
+	}
+	assert.Equal(gtkhttp.CircuitStateClosed, cb.State("Chat"))
+
+	mu.Lock()
+	assert.Equal([]gtkhttp.CircuitState{gtkhttp.CircuitStateOpen, gtkhttp.CircuitStateHalfOpen, gtkhttp.CircuitStateClosed}, changes)
+	mu.Unlock()
+	metrics := collector.GetMetrics()
+	assert.Equal(gtkhttp.CircuitStateClosed, metrics["circuit_states"].(map[string]gtkhttp.CircuitState)["Chat"])
+	assert.Equal(int64(1), metrics["error_counts"].(map[string]int64)["Chat:circuit_open"])
+}
+
+func TestCircuitBreakerHalfOpenFailure(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		cb     = gtkhttp.NewCircuitBreakerMiddleware(gtkhttp.CircuitBreakerMiddlewareConfig{
+			KeyFunc: func(ctx context.Context, requestInfo *gtkhttp.RequestInfo) (key string) {
+				return "upstream"
+			},
+			FailureThreshold:   100,
+			ErrorRateThreshold: 0.5,
+			MinRequests:        4,
+			CoolDown:           50 * time.Millisecond,
+		})
+		client = gtkhttp.NewMWClient(
+			gtkhttp.WithMiddleware(cb),
+			gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+		)
+		errUpstream = errors.New("upstream error")
+	)
+	// 错误率达到阈值后打开熔断器
+	for i := range 4 {
+		_, _ = client.HandlerRequest(ctx, "user", "A", nil, func(ctx context.Context, req any) (resp any, err error) {
+			if i%2 == 1 {
+				return nil, errUpstream
+			}
+			return "ok", nil
+		})
+	}
+	assert.Equal(gtkhttp.CircuitStateOpen, cb.State("upstream"))
+	// 半开状态下探测失败，重新打开熔断器
+	time.Sleep(60 * time.Millisecond)
+	_, err := client.HandlerRequest(ctx, "user", "B", nil, func(ctx context.Context, req any) (resp any, err error) {
+		return nil, errUpstream
+	})
+	assert.ErrorIs(err, errUpstream)
+	assert.Equal(gtkhttp.CircuitStateOpen, cb.State("upstream"))
+
+	cb.Reset("upstream")
+	assert.Equal(gtkhttp.CircuitStateClosed, cb.State("upstream"))
+}
+
+func TestCircuitBreakerWithRetry(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		client = gtkhttp.NewMWClient(
+			gtkhttp.WithCircuitBreaker(gtkhttp.CircuitBreakerMiddlewareConfig{
+				FailureThreshold: 2,
+				CoolDown:         time.Minute,
+			}),
+			gtkhttp.WithRetry(gtkhttp.RetryMiddlewareConfig{
+				MaxAttempts: 5,
+				Strategy:    gtkhttp.RetryStrategyFixed,
+				BaseDelay:   time.Millisecond,
+				Condition:   gtkhttp.RetryConditions.Always,
+			}),
+			gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+		)
+		calls int
+	)
+	// 熔断器打开后重试中间件不再重试
+	_, err := client.HandlerRequest(ctx, "user", "Chat", nil, func(ctx context.Context, req any) (resp any, err error) {
+		calls++
+		return nil, errors.New("upstream error")
+	})
+	assert.True(gtkhttp.IsCircuitOpenError(err))
+	assert.Equal(2, calls)
+}
+
+func TestCircuitBreakerHalfOpenLocalRejection(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		cb     = gtkhttp.NewCircuitBreakerMiddleware(gtkhttp.CircuitBreakerMiddlewareConfig{
+			FailureThreshold: 1,
+			CoolDown:         50 * time.Millisecond,
+		})
+		client = gtkhttp.NewMWClient(
+			gtkhttp.WithMiddleware(cb),
+			gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+		)
+		calls int
+	)
+	_, err := client.HandlerRequest(ctx, "user", "Chat", nil, func(ctx context.Context, req any) (resp any, err error) {
+		return nil, errors.New("upstream error")
+	})
+	assert.Error(err)
+	assert.Equal(gtkhttp.CircuitStateOpen, cb.State("Chat"))
+	// 半开状态下探测请求被内层的限流、并发数限制拒绝或被取消，熔断器保持半开状态
+	time.Sleep(60 * time.Millisecond)
+	for _, rejectErr := range []error{gtkhttp.ErrRateLimited, gtkhttp.ErrBulkheadFull, context.Canceled} {
+		_, err = client.HandlerRequest(ctx, "user", "Chat", nil, func(ctx context.Context, req any) (resp any, err error) {
+			return nil, fmt.Errorf("%w: Chat", rejectErr)
+		})
+		assert.ErrorIs(err, rejectErr)
+		assert.Equal(gtkhttp.CircuitStateHalfOpen, cb.State("Chat"))
+	}
+	// 探测请求到达上游并成功后关闭熔断器
+	resp, err := client.HandlerRequest(ctx, "user", "Chat", nil, func(ctx context.Context, req any) (resp any, err error) {
+		calls++
+		return "ok", nil
+	})
+	assert.NoError(err)
+	assert.Equal("ok", resp)
+	assert.Equal(1, calls)
+	assert.Equal(gtkhttp.CircuitStateClosed, cb.State("Chat"))
+}
@@ -72,6 +72,27 @@ func WithMetrics(config MetricsMiddlewareConfig) (opt MWClientOption) {
 	}
 }
 
+// WithCircuitBreaker 添加熔断中间件
+func WithCircuitBreaker(config CircuitBreakerMiddlewareConfig) (opt MWClientOption) {
+	return func(c *MWClientConfig) {
+		c.middlewares = append(c.middlewares, NewCircuitBreakerMiddleware(config))
+	}
+}
+
+// WithRateLimit 添加限流中间件
+func WithRateLimit(config RateLimitMiddlewareConfig) (opt MWClientOption) {
+	return func(c *MWClientConfig) {
+		c.middlewares = append(c.middlewares, NewRateLimitMiddleware(config))
+	}
+}
+
+// WithBulkhead 添加舱壁中间件
+func WithBulkhead(config BulkheadMiddlewareConfig) (opt MWClientOption) {
+	return func(c *MWClientConfig) {
+		c.middlewares = append(c.middlewares, NewBulkheadMiddleware(config))
+	}
+}
+
 // NewMWClient 创建一个带中间件的客户端
 func NewMWClient(opts ...MWClientOption) (client *MWClient) {
 	// 处理选项
@@ -152,6 +173,11 @@ func (e *MWClientError) Error() (errStr string) {
 	return fmt.Sprintf("request_id: %s, error: %v", e.RequestID, e.Err)
 }
 
+// Unwrap 解包错误，使 errors.Is 和 errors.As 可以匹配原始错误
+func (e *MWClientError) Unwrap() (err error) {
+	return e.Err
+}
+
 // RequestID 获取请求ID
 func RequestID(err error) (requestId string) {
 	if err == nil {
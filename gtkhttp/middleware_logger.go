@@ -158,6 +158,11 @@ func (m *LoggingMiddleware) logRequestEnd(ctx context.Context, processingStartTi
 
 // sanitizeData 脱敏数据
 func (m *LoggingMiddleware) sanitizeData(data any) (newData any) {
+	return sanitizeData(data, m.config.SensitiveFields)
+}
+
+// sanitizeData 脱敏数据，键名包含任一敏感字段（不区分大小写）的值会被替换为 ***
+func sanitizeData(data any, sensitiveFields []string) (newData any) {
 	if data == nil {
 		return nil
 	}
@@ -174,11 +179,21 @@ func (m *LoggingMiddleware) sanitizeData(data any) (newData any) {
 		return string(jsonData) // 如果无法解析，直接返回字符串
 	}
 	// 递归脱敏，从深度0开始
-	return m.sanitizeValue(result, 0)
+	return sanitizeValue(result, sensitiveFields, 0)
+}
+
+// isSensitiveField 判断键名是否为敏感字段
+func isSensitiveField(key string, sensitiveFields []string) (ok bool) {
+	for _, field := range sensitiveFields {
+		if strings.Contains(strings.ToLower(key), strings.ToLower(field)) {
+			return true
+		}
+	}
+	return false
 }
 
 // sanitizeValue 递归脱敏值，添加深度限制
-func (m *LoggingMiddleware) sanitizeValue(value any, depth int) (newValue any) {
+func sanitizeValue(value any, sensitiveFields []string, depth int) (newValue any) {
 	// 防止无限递归
 	if depth > maxSanitizeDepth {
 		return "<max_depth_reached>"
@@ -189,24 +204,17 @@ func (m *LoggingMiddleware) sanitizeValue(value any, depth int) (newValue any) {
 		result := make(map[string]any)
 		for key, val := range v {
 			// 检查是否为敏感字段
-			isSensitive := false
-			for _, field := range m.config.SensitiveFields {
-				if strings.Contains(strings.ToLower(key), strings.ToLower(field)) {
-					isSensitive = true
-					break
-				}
-			}
-			if isSensitive {
+			if isSensitiveField(key, sensitiveFields) {
 				result[key] = "***"
 			} else {
-				result[key] = m.sanitizeValue(val, depth+1) // 递归处理，深度+1
+				result[key] = sanitizeValue(val, sensitiveFields, depth+1) // 递归处理，深度+1
 			}
 		}
 		return result
 	case []any:
 		result := make([]any, len(v))
 		for i, val := range v {
-			result[i] = m.sanitizeValue(val, depth+1) // 递归处理数组元素，深度+1
+			result[i] = sanitizeValue(val, sensitiveFields, depth+1) // 递归处理数组元素，深度+1
 		}
 		return result
 	default:
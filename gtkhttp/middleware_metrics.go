@@ -55,6 +55,8 @@ type DefaultMetricsCollector struct {
 	retryCounts map[string]int64 // 重试计数
 	// 活跃请求数
 	activeRequests map[string]int64 // 当前活跃请求数
+	// 熔断器状态
+	circuitStates map[string]CircuitState // 熔断器当前状态
 	// 时间范围内的统计
 	startTime time.Time // 统计开始时间
 }
@@ -69,6 +71,7 @@ func NewDefaultMetricsCollector() (metricsCollector *DefaultMetricsCollector) {
 		errorCounts:     make(map[string]int64),
 		retryCounts:     make(map[string]int64),
 		activeRequests:  make(map[string]int64),
+		circuitStates:   make(map[string]CircuitState),
 		startTime:       time.Now(),
 	}
 }
@@ -129,6 +132,14 @@ func (c *DefaultMetricsCollector) RecordRetry(method string, retryCount int) {
 	c.retryCounts[key] += int64(retryCount)
 }
 
+// RecordCircuitStateChange 记录熔断器状态变化
+func (c *DefaultMetricsCollector) RecordCircuitStateChange(key string, from, to CircuitState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.circuitStates[c.getKey(key)] = to
+}
+
 // GetMetrics 获取指标数据
 func (c *DefaultMetricsCollector) GetMetrics() (metrics map[string]any) {
 	c.mu.RLock()
@@ -159,6 +170,10 @@ func (c *DefaultMetricsCollector) GetMetrics() (metrics map[string]any) {
 	activeRequests := make(map[string]int64)
 	maps.Copy(activeRequests, c.activeRequests)
 	metrics["active_requests"] = activeRequests
+	// 深拷贝熔断器状态数据
+	circuitStates := make(map[string]CircuitState)
+	maps.Copy(circuitStates, c.circuitStates)
+	metrics["circuit_states"] = circuitStates
 	// 统计开始时间
 	metrics["start_time"] = c.startTime
 	// 计算成功率和平均响应时间
@@ -197,6 +212,7 @@ func (c *DefaultMetricsCollector) Reset() {
 	c.errorCounts = make(map[string]int64)
 	c.retryCounts = make(map[string]int64)
 	c.activeRequests = make(map[string]int64)
+	c.circuitStates = make(map[string]CircuitState)
 	c.startTime = time.Now()
 }
 
@@ -278,6 +294,15 @@ func (m *MetricsMiddleware) classifyError(err error) (errorType string) {
 	if err == nil {
 		return "none"
 	}
+	// 检查是否为本地拒绝的错误
+	switch {
+	case IsCircuitOpenError(err):
+		return "circuit_open"
+	case IsRateLimitedError(err):
+		return "rate_limited"
+	case IsBulkheadFullError(err):
+		return "bulkhead_full"
+	}
 	var netErr net.Error
 	if errors.As(err, &netErr) {
 		return "net_err"
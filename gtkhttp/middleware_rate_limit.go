@@ -0,0 +1,230 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 16:34:08
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 16:34:08
+ * @Description: 限流中间件（令牌桶）
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// MiddlewareKeyFunc 中间件键函数，相同键的请求共享同一份限流或并发配额
+type MiddlewareKeyFunc func(ctx context.Context, requestInfo *RequestInfo) (key string)
+
+// KeyByMethod 按 RequestInfo.Method 区分键
+func KeyByMethod(ctx context.Context, requestInfo *RequestInfo) (key string) {
+	return requestInfo.Method
+}
+
+// KeyByUser 按 RequestInfo.User 区分键
+func KeyByUser(ctx context.Context, requestInfo *RequestInfo) (key string) {
+	return requestInfo.User
+}
+
+// RateLimit 限流配额
+type RateLimit struct {
+	Rate  float64 // 每秒生成的令牌数（QPS）
+	Burst int     // 令牌桶容量，即允许的最大突发请求数
+}
+
+// RateLimitMiddlewareConfig 限流中间件配置
+type RateLimitMiddlewareConfig struct {
+	KeyFunc   MiddlewareKeyFunc    // 限流键函数，默认 KeyByMethod
+	Rate      float64              // 每秒生成的令牌数（QPS），默认 10
+	Burst     int                  // 令牌桶容量，默认为 Rate 向上取整，最小为 1
+	Limits    map[string]RateLimit // 指定键的限流配额，优先于 Rate 和 Burst
+	Wait      bool                 // 令牌不足时是否等待，false 表示直接拒绝
+	MaxWait   time.Duration        // 等待令牌的最长时间，预计等待时间超过该值时直接拒绝，默认 0 表示仅受上下文控制，超过上下文截止时间时返回的错误同时包含 context.DeadlineExceeded
+	Collector MetricsCollector     // 指标收集器，记录被拒绝的请求
+}
+
+// RateLimitMiddleware 限流中间件
+type RateLimitMiddleware struct {
+	config  RateLimitMiddlewareConfig
+	buckets map[string]*tokenBucket // 限流键 -> 令牌桶
+	mu      sync.Mutex              // 互斥锁
+}
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	rate   float64    // 每秒生成的令牌数
+	burst  float64    // 令牌桶容量
+	tokens float64    // 当前令牌数，为负数时表示已被预约的令牌
+	last   time.Time  // 上次更新令牌数的时间
+	mu     sync.Mutex // 互斥锁
+}
+
+// NewRateLimitMiddleware 创建限流中间件
+func NewRateLimitMiddleware(config RateLimitMiddlewareConfig) (rl *RateLimitMiddleware) {
+	// 设置限流键函数，默认 KeyByMethod
+	if config.KeyFunc == nil {
+		config.KeyFunc = KeyByMethod
+	}
+	// 设置每秒生成的令牌数，默认 10
+	if config.Rate <= 0 {
+		config.Rate = 10
+	}
+	// 设置令牌桶容量，默认为 Rate 向上取整，最小为 1
+	if config.Burst <= 0 {
+		config.Burst = max(int(math.Ceil(config.Rate)), 1)
+	}
+	// 设置等待令牌的最长时间
+	if config.MaxWait < 0 {
+		config.MaxWait = 0
+	}
+	return &RateLimitMiddleware{
+		config:  config,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Process 处理请求
+func (m *RateLimitMiddleware) Process(ctx context.Context, request any, next MWHandler) (response any, err error) {
+	// 从上下文中获取请求信息
+	requestInfo := GetRequestInfo(ctx)
+	key := m.config.KeyFunc(ctx, requestInfo)
+	if err = m.wait(ctx, key); err != nil {
+		if m.config.Collector != nil && IsRateLimitedError(err) {
+			m.config.Collector.RecordError(requestInfo.Method, "rate_limited")
+		}
+		return
+	}
+	// 执行下一个处理器
+	return next(ctx, request)
+}
+
+// Name 返回中间件名称
+func (m *RateLimitMiddleware) Name() (name string) {
+	return "rate_limit"
+}
+
+// Priority 返回中间件优先级
+func (m *RateLimitMiddleware) Priority() (priority int) {
+	return 40 // 限流中间件在重试和熔断中间件之后执行，每次重试都会消耗令牌，熔断时不消耗令牌
+}
+
+// Allow 判断指定键当前是否有可用令牌，有则消耗一个令牌
+func (m *RateLimitMiddleware) Allow(key string) (ok bool) {
+	_, ok = m.getBucket(key).reserve(time.Now(), 0, false)
+	return
+}
+
+// wait 获取令牌，令牌不足时按配置等待或拒绝
+func (m *RateLimitMiddleware) wait(ctx context.Context, key string) (err error) {
+	var (
+		bucket = m.getBucket(key)
+		now    = time.Now()
+	)
+	if !m.config.Wait {
+		if _, ok := bucket.reserve(now, 0, false); !ok {
+			return fmt.Errorf("%w: %s", ErrRateLimited, key)
+		}
+		return
+	}
+	// 上下文已取消或超时时不再预约令牌
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	// 计算允许的最长等待时间
+	var (
+		maxWait          = time.Duration(math.MaxInt64)
+		limitedByContext bool
+	)
+	if m.config.MaxWait > 0 {
+		maxWait = m.config.MaxWait
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(now) < maxWait {
+		maxWait, limitedByContext = deadline.Sub(now), true
+	}
+	delay, ok := bucket.reserve(now, maxWait, true)
+	if !ok {
+		if limitedByContext {
+			// 等待时间超过上下文的截止时间，同时可以用 errors.Is 判断 context.DeadlineExceeded
+			return fmt.Errorf("%w: %s: %w", ErrRateLimited, key, context.DeadlineExceeded)
+		}
+		return fmt.Errorf("%w: %s", ErrRateLimited, key)
+	}
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// 归还预约的令牌
+		bucket.cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return
+	}
+}
+
+// getBucket 获取指定键的令牌桶，不存在时创建
+func (m *RateLimitMiddleware) getBucket(key string) (bucket *tokenBucket) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ok bool
+	if bucket, ok = m.buckets[key]; !ok {
+		limit := RateLimit{Rate: m.config.Rate, Burst: m.config.Burst}
+		if v, exists := m.config.Limits[key]; exists && v.Rate > 0 {
+			limit = v
+			if limit.Burst <= 0 {
+				limit.Burst = max(int(math.Ceil(limit.Rate)), 1)
+			}
+		}
+		bucket = &tokenBucket{
+			rate:   limit.Rate,
+			burst:  float64(limit.Burst),
+			tokens: float64(limit.Burst),
+			last:   time.Now(),
+		}
+		m.buckets[key] = bucket
+	}
+	return
+}
+
+// reserve 预约一个令牌，返回需要等待的时间
+//
+//	allowWait 为 false 时仅在有可用令牌时成功，为 true 时在等待时间不超过 maxWait 时成功
+func (b *tokenBucket) reserve(now time.Time, maxWait time.Duration, allowWait bool) (delay time.Duration, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 补充令牌
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*b.rate, b.burst)
+		b.last = now
+	}
+	tokens := b.tokens - 1
+	if tokens >= 0 {
+		b.tokens = tokens
+		return 0, true
+	}
+	if !allowWait {
+		return 0, false
+	}
+	delay = time.Duration(-tokens / b.rate * float64(time.Second))
+	if delay > maxWait {
+		return 0, false
+	}
+	b.tokens = tokens
+	return delay, true
+}
+
+// cancel 归还一个预约的令牌
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.tokens+1, b.burst)
+}
@@ -0,0 +1,130 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 17:31:40
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 17:31:40
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"context"
+	"errors"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func okHandler(ctx context.Context, req any) (resp any, err error) {
+	return "ok", nil
+}
+
+func TestRateLimitMiddlewareReject(t *testing.T) {
+	var (
+		assert    = assert.New(t)
+		ctx       = context.Background()
+		collector = gtkhttp.NewDefaultMetricsCollector()
+		client    = gtkhttp.NewMWClient(
+			gtkhttp.WithRateLimit(gtkhttp.RateLimitMiddlewareConfig{
+				KeyFunc:   gtkhttp.KeyByUser,
+				Rate:      10,
+				Burst:     2,
+				Limits:    map[string]gtkhttp.RateLimit{"vip": {Rate: 100, Burst: 5}},
+				Collector: collector,
+			}),
+			gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+		)
+	)
+	// 令牌桶容量内的请求直接通过
+	for range 2 {
+		_, err := client.HandlerRequest(ctx, "user", "Chat", nil, okHandler)
+		assert.NoError(err)
+	}
+	// 令牌耗尽后直接拒绝
+	_, err := client.HandlerRequest(ctx, "user", "Chat", nil, okHandler)
+	assert.True(gtkhttp.IsRateLimitedError(err))
+	// 不同用户使用各自的配额
+	for range 5 {
+		_, err = client.HandlerRequest(ctx, "vip", "Chat", nil, okHandler)
+		assert.NoError(err)
+	}
+	_, err = client.HandlerRequest(ctx, "vip", "Chat", nil, okHandler)
+	assert.True(gtkhttp.IsRateLimitedError(err))
+	// 令牌补充后可以继续请求
+	time.Sleep(110 * time.Millisecond)
+	_, err = client.HandlerRequest(ctx, "user", "Chat", nil, okHandler)
+	assert.NoError(err)
+	assert.Equal(int64(2), collector.GetMetrics()["error_counts"].(map[string]int64)["Chat:rate_limited"])
+}
+
+func TestRateLimitMiddlewareWait(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		rl     = gtkhttp.NewRateLimitMiddleware(gtkhttp.RateLimitMiddlewareConfig{
+			Rate:    20,
+			Burst:   1,
+			Wait:    true,
+			MaxWait: 200 * time.Millisecond,
+		})
+		client = gtkhttp.NewMWClient(
+			gtkhttp.WithMiddleware(rl),
+			gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+		)
+	)
+	// 令牌不足时等待令牌
+	start := time.Now()
+	for range 3 {
+		_, err := client.HandlerRequest(ctx, "user", "Chat", nil, okHandler)
+		assert.NoError(err)
+	}
+	assert.GreaterOrEqual(time.Since(start), 90*time.Millisecond)
+	// 预计等待时间超过上下文截止时间时直接拒绝
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err := client.HandlerRequest(timeoutCtx, "user", "Chat", nil, okHandler)
+	assert.True(gtkhttp.IsRateLimitedError(err))
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	// 上下文已超时或已取消时返回上下文的错误
+	<-timeoutCtx.Done()
+	_, err = client.HandlerRequest(timeoutCtx, "user", "Chat", nil, okHandler)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.False(gtkhttp.IsRateLimitedError(err))
+	canceledCtx, cancelNow := context.WithCancel(ctx)
+	cancelNow()
+	_, err = client.HandlerRequest(canceledCtx, "user", "Chat", nil, okHandler)
+	assert.ErrorIs(err, context.Canceled)
+	assert.False(gtkhttp.IsRateLimitedError(err))
+	assert.False(rl.Allow("Chat"))
+}
+
+func TestRateLimitWithRetry(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		client = gtkhttp.NewMWClient(
+			gtkhttp.WithRetry(gtkhttp.RetryMiddlewareConfig{
+				MaxAttempts: 5,
+				Strategy:    gtkhttp.RetryStrategyFixed,
+				BaseDelay:   time.Millisecond,
+				Condition:   gtkhttp.RetryConditions.Always,
+			}),
+			gtkhttp.WithRateLimit(gtkhttp.RateLimitMiddlewareConfig{
+				Rate:  1,
+				Burst: 2,
+			}),
+			gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+		)
+		calls int
+	)
+	// 每次重试都会消耗令牌，令牌耗尽后不再重试
+	_, err := client.HandlerRequest(ctx, "user", "Chat", nil, func(ctx context.Context, req any) (resp any, err error) {
+		calls++
+		return nil, errors.New("upstream error")
+	})
+	assert.True(gtkhttp.IsRateLimitedError(err))
+	assert.Equal(2, calls)
+}
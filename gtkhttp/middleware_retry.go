@@ -11,7 +11,6 @@ package gtkhttp
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"math"
 	"math/rand/v2"
@@ -103,8 +102,8 @@ func (m *RetryMiddleware) Process(ctx context.Context, request any, next MWHandl
 		requestInfo.Attempt = attempt
 		// 执行请求
 		response, err = next(ctx, request)
-		// 如果成功或者不需要重试，直接返回
-		if err == nil || !m.config.Condition(attempt, err) {
+		// 如果成功、被本地拒绝（熔断、限流、并发数已满）或者不需要重试，直接返回
+		if err == nil || isLocalRejectionError(err) || !m.config.Condition(attempt, err) {
 			return
 		}
 		// 如果重试回调不为空，则执行重试回调
@@ -121,8 +120,11 @@ func (m *RetryMiddleware) Process(ctx context.Context, request any, next MWHandl
 			break
 		}
 		// MaxAttempts = -1: 无限重试，继续执行
-		// 计算延迟时间
+		// 计算延迟时间，错误响应指定了 Retry-After 时不小于该时间（不超过最大延迟时间）
 		delay := m.calculateDelay(attempt + 1)
+		if te := Classify(err); te.RetryAfter > delay {
+			delay = min(te.RetryAfter, m.config.MaxDelay)
+		}
 		// 等待延迟时间
 		select {
 		case <-ctx.Done():
@@ -219,17 +221,8 @@ func DefaultRetryCondition(attempt int, err error) (ok bool) {
 
 // isRetryableHTTPError 判断是否为可重试的HTTP错误
 func isRetryableHTTPError(err error) (ok bool) {
-	// 检查是否为API错误
-	var apiError *APIError
-	if errors.As(err, &apiError) {
-		return slices.Contains(retryableHTTPStatusCodes, apiError.HTTPStatusCode)
-	}
-	// 检查是否为请求错误
-	var requestError *RequestError
-	if errors.As(err, &requestError) {
-		return slices.Contains(retryableHTTPStatusCodes, requestError.HTTPStatusCode)
-	}
-	return false
+	te := Classify(err)
+	return te != nil && te.StatusCode >= 400 && slices.Contains(retryableHTTPStatusCodes, te.StatusCode)
 }
 
 // 可重试的HTTP状态码列表
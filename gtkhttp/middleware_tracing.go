@@ -0,0 +1,115 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 10:42:05
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 10:42:05
+ * @Description: 链路追踪中间件
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"github.com/liusuxian/go-toolkit/gtktrace"
+	"net/http"
+	"strconv"
+)
+
+// TracingMiddlewareConfig 链路追踪中间件配置
+type TracingMiddlewareConfig struct {
+	Tracer gtktrace.Tracer // 追踪器，默认使用 gtktrace.GetTracer()
+}
+
+// TracingMiddleware 链路追踪中间件，为每次调用创建客户端跨度（包含所有重试）
+//
+//	跨度存入上下文，处理函数中使用该上下文发出的 HTTP 请求（配合 TracingInterceptor）会成为它的子跨度；
+//	请求参数是 *http.Request 时直接在请求头中注入 traceparent
+type TracingMiddleware struct {
+	config TracingMiddlewareConfig
+}
+
+// NewTracingMiddleware 创建链路追踪中间件
+func NewTracingMiddleware(config TracingMiddlewareConfig) (tm *TracingMiddleware) {
+	return &TracingMiddleware{
+		config: config,
+	}
+}
+
+// WithTracing 添加链路追踪中间件
+func WithTracing(config TracingMiddlewareConfig) (opt MWClientOption) {
+	return func(c *MWClientConfig) {
+		c.middlewares = append(c.middlewares, NewTracingMiddleware(config))
+	}
+}
+
+// Process 处理请求
+func (m *TracingMiddleware) Process(ctx context.Context, request any, next MWHandler) (response any, err error) {
+	tracer := m.config.Tracer
+	if tracer == nil {
+		tracer = gtktrace.GetTracer()
+	}
+	requestInfo := GetRequestInfo(ctx)
+	ctx, span := tracer.Start(ctx, requestInfo.Method,
+		gtktrace.WithSpanKind(gtktrace.SpanKindClient),
+		gtktrace.WithAttributes(
+			gtktrace.Attr("request_id", requestInfo.RequestID),
+			gtktrace.Attr("user", requestInfo.User),
+		))
+	if req, ok := request.(*http.Request); ok {
+		gtktrace.Inject(ctx, gtktrace.HeaderCarrier(req.Header))
+	}
+	// 执行下一个处理器
+	response, err = next(ctx, request)
+	if requestInfo.Attempt > 0 {
+		span.SetAttributes(gtktrace.Attr("retry_count", requestInfo.Attempt))
+	}
+	gtktrace.EndSpan(span, err)
+	return
+}
+
+// Name 返回中间件名称
+func (m *TracingMiddleware) Name() (name string) {
+	return "tracing"
+}
+
+// Priority 返回中间件优先级
+func (m *TracingMiddleware) Priority() (priority int) {
+	return 5 // 链路追踪中间件优先级最高，跨度覆盖其他中间件的耗时
+}
+
+// TracingHandlerConfig 服务端链路追踪中间件配置
+type TracingHandlerConfig struct {
+	Tracer gtktrace.Tracer // 追踪器，默认使用 gtktrace.GetTracer()
+}
+
+// NewTracingHandler 创建服务端链路追踪中间件，从请求头中解析 traceparent 并创建服务端跨度，5xx 响应标记为错误
+func NewTracingHandler(config TracingHandlerConfig) (mw HandlerMiddleware) {
+	return func(next http.Handler) (handler http.Handler) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tracer := config.Tracer
+			if tracer == nil {
+				tracer = gtktrace.GetTracer()
+			}
+			ctx := gtktrace.Extract(r.Context(), gtktrace.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method+" "+r.URL.Path,
+				gtktrace.WithSpanKind(gtktrace.SpanKindServer),
+				gtktrace.WithAttributes(
+					gtktrace.Attr("http.request.method", r.Method),
+					gtktrace.Attr("url.path", r.URL.Path),
+					gtktrace.Attr("client.address", GetRealIP(r)),
+				))
+			defer span.End()
+			if requestInfo := GetRequestInfo(ctx); requestInfo.RequestID != "unknown" {
+				span.SetAttributes(gtktrace.Attr("request_id", requestInfo.RequestID))
+			}
+
+			sw := wrapStatusWriter(w)
+			next.ServeHTTP(sw, r.WithContext(ctx))
+			span.SetAttributes(gtktrace.Attr("http.response.status_code", sw.Status()))
+			if sw.Status() >= http.StatusInternalServerError {
+				span.SetStatus(gtktrace.StatusError, strconv.Itoa(sw.Status()))
+			}
+		})
+	}
+}
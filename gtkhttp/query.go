@@ -0,0 +1,227 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-20 15:02:44
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-20 15:02:44
+ * @Description: URL 拼接与查询参数编码
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JoinURL 拼接 URL，path 为绝对地址（包含 scheme）时直接返回 path
+//
+//	JoinURL("https://api.example.com/v1/", "/models") => "https://api.example.com/v1/models"
+func JoinURL(baseURL, path string) (fullURL string) {
+	if path == "" {
+		return baseURL
+	}
+	if u, err := url.Parse(path); err == nil && u.IsAbs() {
+		return path
+	}
+	if baseURL == "" {
+		return path
+	}
+	if strings.HasPrefix(path, "?") {
+		return strings.TrimRight(baseURL, "/") + path
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// EncodeQuery 将查询参数编码为 url.Values
+//
+//	支持 url.Values、map[string]string、map[string][]string、map[string]any 以及结构体（或结构体指针）
+//	结构体字段优先使用 url 标签，其次使用 json 标签，标签为 "-" 时忽略该字段，标签包含 omitempty 时忽略零值
+//	切片和数组字段会编码为多个同名参数，time.Time 编码为 RFC3339 格式，实现了 encoding.TextMarshaler 的字段使用 MarshalText 编码
+func EncodeQuery(query any) (values url.Values, err error) {
+	values = url.Values{}
+	if query == nil {
+		return
+	}
+
+	switch q := query.(type) {
+	case url.Values:
+		for k, v := range q {
+			values[k] = append([]string(nil), v...)
+		}
+		return
+	case map[string]string:
+		for k, v := range q {
+			values.Set(k, v)
+		}
+		return
+	case map[string][]string:
+		for k, v := range q {
+			values[k] = append([]string(nil), v...)
+		}
+		return
+	case map[string]any:
+		for k, v := range q {
+			if err = addQueryValue(values, k, reflect.ValueOf(v), false); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	rv := reflect.ValueOf(query)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		err = fmt.Errorf("unsupported query type: %T", query)
+		return
+	}
+	err = encodeQueryStruct(values, rv)
+	return
+}
+
+// mergeQuery 将查询参数合并到 URL 中
+func mergeQuery(rawURL string, query any) (fullURL string, err error) {
+	var values url.Values
+	if values, err = EncodeQuery(query); err != nil || len(values) == 0 {
+		return rawURL, err
+	}
+
+	var u *url.URL
+	if u, err = url.Parse(rawURL); err != nil {
+		return
+	}
+	q := u.Query()
+	for k, v := range values {
+		q[k] = append(q[k], v...)
+	}
+	u.RawQuery = q.Encode()
+	fullURL = u.String()
+	return
+}
+
+// encodeQueryStruct 编码结构体查询参数
+func encodeQueryStruct(values url.Values, rv reflect.Value) (err error) {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		var (
+			field = rt.Field(i)
+			fv    = rv.Field(i)
+		)
+		if !field.IsExported() {
+			continue
+		}
+		name, omitEmpty, skip := parseQueryTag(field)
+		if skip {
+			continue
+		}
+		// 匿名结构体字段展开
+		if field.Anonymous && name == "" {
+			for fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err = encodeQueryStruct(values, fv); err != nil {
+					return
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if err = addQueryValue(values, name, fv, omitEmpty); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// parseQueryTag 解析查询参数标签
+func parseQueryTag(field reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag, ok := field.Tag.Lookup("url")
+	if !ok {
+		tag = field.Tag.Get("json")
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return
+}
+
+// addQueryValue 添加查询参数
+func addQueryValue(values url.Values, name string, fv reflect.Value, omitEmpty bool) (err error) {
+	if !fv.IsValid() {
+		return
+	}
+	for fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return
+		}
+		fv = fv.Elem()
+	}
+	if omitEmpty && fv.IsZero() {
+		return
+	}
+
+	switch v := fv.Interface().(type) {
+	case time.Time:
+		values.Add(name, v.Format(time.RFC3339))
+		return
+	case encoding.TextMarshaler:
+		var b []byte
+		if b, err = v.MarshalText(); err != nil {
+			return
+		}
+		values.Add(name, string(b))
+		return
+	}
+
+	switch fv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8 {
+			values.Add(name, string(fv.Bytes()))
+			return
+		}
+		for i := range fv.Len() {
+			if err = addQueryValue(values, name, fv.Index(i), false); err != nil {
+				return
+			}
+		}
+		return
+	case reflect.String:
+		values.Add(name, fv.String())
+	case reflect.Bool:
+		values.Add(name, strconv.FormatBool(fv.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		values.Add(name, strconv.FormatInt(fv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		values.Add(name, strconv.FormatUint(fv.Uint(), 10))
+	case reflect.Float32:
+		values.Add(name, strconv.FormatFloat(fv.Float(), 'f', -1, 32))
+	case reflect.Float64:
+		values.Add(name, strconv.FormatFloat(fv.Float(), 'f', -1, 64))
+	default:
+		err = fmt.Errorf("unsupported query field type: %s %s", name, fv.Type())
+	}
+	return
+}
@@ -0,0 +1,551 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-22 16:47:12
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-22 16:47:12
+ * @Description: 服务端中间件，与路由无关，适用于任何 http.Handler
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"errors"
+	"github.com/liusuxian/go-toolkit/gtkflake"
+	"github.com/liusuxian/go-toolkit/gtklog"
+	"github.com/liusuxian/go-toolkit/gtkresp"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRequestIDHeader = "X-Request-Id" // 默认的请求ID头键
+	maxRequestIDLength     = 128            // 允许透传的请求ID最大长度
+)
+
+const (
+	realIPKey ContextKey = "toolkit_server_real_ip" // 客户端真实IP在上下文中的键
+)
+
+// HandlerMiddleware 服务端中间件
+type HandlerMiddleware func(next http.Handler) (handler http.Handler)
+
+// ChainHandler 使用中间件包装处理器，第一个中间件位于最外层，最先处理请求
+func ChainHandler(handler http.Handler, middlewares ...HandlerMiddleware) (h http.Handler) {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// statusWriter 记录响应状态码和响应体大小的 http.ResponseWriter
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+// wrapStatusWriter 包装 http.ResponseWriter，已包装的直接返回
+func wrapStatusWriter(w http.ResponseWriter) (sw *statusWriter) {
+	if sw, ok := w.(*statusWriter); ok {
+		return sw
+	}
+	return &statusWriter{ResponseWriter: w}
+}
+
+// WriteHeader 写入状态码
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write 写入响应体
+func (w *statusWriter) Write(b []byte) (n int, err error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err = w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return
+}
+
+// Status 获取响应状态码，未写入时返回 200
+func (w *statusWriter) Status() (status int) {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Flush 立即发送缓冲的数据，支持流式响应
+func (w *statusWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack 接管连接，支持 websocket
+func (w *statusWriter) Hijack() (conn net.Conn, rw *bufio.ReadWriter, err error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap 返回原始的 http.ResponseWriter，供 http.ResponseController 使用
+func (w *statusWriter) Unwrap() (rw http.ResponseWriter) {
+	return w.ResponseWriter
+}
+
+// writeFail 以指定的状态码返回失败响应
+func writeFail(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	var data any
+	if requestInfo := GetRequestInfo(r.Context()); requestInfo.RequestID != "unknown" {
+		data = map[string]any{"request_id": requestInfo.RequestID}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(gtkresp.Fail(status, msg, data).MustJson())
+}
+
+// RequestIDHandlerConfig 请求ID中间件配置
+type RequestIDHandlerConfig struct {
+	Generator      IRequestIDGenerator // 唯一请求ID生成器，默认使用 gtkflake 生成
+	Header         string              // 请求ID头键，默认 X-Request-Id
+	IgnoreIncoming bool                // 是否忽略请求中携带的请求ID，总是重新生成
+}
+
+// NewRequestIDHandler 创建请求ID中间件
+//
+//	请求ID会写入响应头，并以 RequestInfo 的形式存入请求上下文，可以通过 GetRequestInfo 获取，使用该上下文发出的请求也会携带同一个请求ID
+func NewRequestIDHandler(config RequestIDHandlerConfig) (mw HandlerMiddleware) {
+	if config.Header == "" {
+		config.Header = defaultRequestIDHeader
+	}
+	if config.Generator == nil {
+		config.Generator = newDefaultRequestIDGenerator()
+	}
+	return func(next http.Handler) (handler http.Handler) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestId := r.Header.Get(config.Header)
+			if config.IgnoreIncoming || !isValidRequestID(requestId) {
+				var err error
+				if requestId, err = config.Generator.RequestID(); err != nil || requestId == "" {
+					// 生成失败时退化为随机ID，不影响请求处理
+					requestId = rand.Text()
+				}
+			}
+			w.Header().Set(config.Header, requestId)
+			ctx := SetRequestInfo(r.Context(), &RequestInfo{
+				Method:    r.Method + " " + r.URL.Path,
+				StartTime: time.Now(),
+				RequestID: requestId,
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// defaultRequestIDGenerator 默认的唯一请求ID生成器，延迟创建 gtkflake
+type defaultRequestIDGenerator struct {
+	once  sync.Once
+	flake *gtkflake.Flake
+	err   error
+}
+
+// newDefaultRequestIDGenerator 创建默认的唯一请求ID生成器
+func newDefaultRequestIDGenerator() (g *defaultRequestIDGenerator) {
+	return &defaultRequestIDGenerator{}
+}
+
+// RequestID 生成唯一请求ID
+func (g *defaultRequestIDGenerator) RequestID() (requestId string, err error) {
+	g.once.Do(func() {
+		g.flake, g.err = gtkflake.New(gtkflake.Settings{})
+	})
+	if g.err != nil {
+		return "", g.err
+	}
+	return g.flake.RequestID()
+}
+
+// isValidRequestID 是否是可以透传的请求ID，只允许可打印的 ASCII 字符
+func isValidRequestID(requestId string) (ok bool) {
+	if requestId == "" || len(requestId) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestId); i++ {
+		if requestId[i] <= ' ' || requestId[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RecoveryHandlerConfig 异常恢复中间件配置
+type RecoveryHandlerConfig struct {
+	Logger  gtklog.ILogger // 日志器
+	Message string         // 返回给客户端的错误消息，默认 Internal Server Error
+}
+
+// NewRecoveryHandler 创建异常恢复中间件，捕获处理器中的 panic，记录堆栈并返回 500 状态码的 gtkresp.Fail 响应
+//
+//	如果响应头已经发送，则只记录日志；http.ErrAbortHandler 会被继续抛出
+func NewRecoveryHandler(config RecoveryHandlerConfig) (mw HandlerMiddleware) {
+	if config.Logger == nil {
+		config.Logger = gtklog.NewDefaultLogger(gtklog.InfoLevel)
+	}
+	if config.Message == "" {
+		config.Message = http.StatusText(http.StatusInternalServerError)
+	}
+	return func(next http.Handler) (handler http.Handler) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := wrapStatusWriter(w)
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(rec)
+				}
+				config.Logger.Errorf(r.Context(), "http server panic: %s %s, request_id: %s, error: %v\n%s",
+					r.Method, r.URL.Path, GetRequestInfo(r.Context()).RequestID, rec, debug.Stack())
+				if !sw.wroteHeader {
+					writeFail(sw, r, http.StatusInternalServerError, config.Message)
+				}
+			}()
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+// AccessLogHandlerConfig 访问日志中间件配置
+type AccessLogHandlerConfig struct {
+	Logger          gtklog.ILogger // 日志器
+	SkipPaths       []string       // 不记录日志的路径，如健康检查
+	SkipSuccessLog  bool           // 是否跳过成功请求的日志
+	SlowThreshold   time.Duration  // 慢请求阈值，超过时记录警告日志，0 表示不检查
+	SensitiveFields []string       // 敏感字段，查询参数中的敏感字段会被脱敏
+}
+
+// accessLogEntry 访问日志
+type accessLogEntry struct {
+	RequestID  string `json:"request_id,omitempty"`
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Size       int64  `json:"size"`
+	ClientIP   string `json:"client_ip"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// NewAccessLogHandler 创建访问日志中间件，5xx 记录错误日志，4xx 和慢请求记录警告日志，其余记录信息日志
+func NewAccessLogHandler(config AccessLogHandlerConfig) (mw HandlerMiddleware) {
+	if config.Logger == nil {
+		config.Logger = gtklog.NewDefaultLogger(gtklog.InfoLevel)
+	}
+	return func(next http.Handler) (handler http.Handler) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(config.SkipPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var (
+				startTime = time.Now()
+				sw        = wrapStatusWriter(w)
+			)
+			next.ServeHTTP(sw, r)
+
+			var (
+				ctx      = r.Context()
+				duration = time.Since(startTime)
+				entry    = &accessLogEntry{
+					Method:     r.Method,
+					URL:        sanitizeQuery(r.URL, config.SensitiveFields),
+					StatusCode: sw.Status(),
+					Size:       sw.size,
+					ClientIP:   GetRealIP(r),
+					UserAgent:  r.UserAgent(),
+					DurationMs: duration.Milliseconds(),
+				}
+			)
+			if requestInfo := GetRequestInfo(ctx); requestInfo.RequestID != "unknown" {
+				entry.RequestID = requestInfo.RequestID
+			}
+			switch {
+			case entry.StatusCode >= http.StatusInternalServerError:
+				config.Logger.Errorf(ctx, "http server request failed: %s", toMustString(entry))
+			case entry.StatusCode >= http.StatusBadRequest:
+				config.Logger.Warnf(ctx, "http server request failed: %s", toMustString(entry))
+			case config.SlowThreshold > 0 && duration >= config.SlowThreshold:
+				config.Logger.Warnf(ctx, "http server request slow: %s", toMustString(entry))
+			case !config.SkipSuccessLog:
+				config.Logger.Infof(ctx, "http server request completed: %s", toMustString(entry))
+			}
+		})
+	}
+}
+
+// sanitizeQuery 脱敏 URL 查询参数中的敏感字段
+func sanitizeQuery(u *url.URL, sensitiveFields []string) (rawURL string) {
+	if u.RawQuery == "" || len(sensitiveFields) == 0 {
+		return u.RequestURI()
+	}
+	query := u.Query()
+	for key := range query {
+		if isSensitiveField(key, sensitiveFields) {
+			query.Set(key, "***")
+		}
+	}
+	c := *u
+	c.RawQuery = query.Encode()
+	return c.RequestURI()
+}
+
+// CORSHandlerConfig 跨域中间件配置
+type CORSHandlerConfig struct {
+	AllowOrigins     []string      // 允许的来源，支持 * 和 https://*.example.com 形式的子域名通配，默认 *
+	AllowMethods     []string      // 允许的方法，默认 GET、POST、PUT、PATCH、DELETE、HEAD、OPTIONS
+	AllowHeaders     []string      // 允许的请求头，默认允许预检请求中声明的所有请求头
+	ExposeHeaders    []string      // 允许客户端读取的响应头
+	AllowCredentials bool          // 是否允许携带凭证，开启时不会返回 * 而是返回请求的来源
+	MaxAge           time.Duration // 预检请求结果的缓存时间，0 表示不设置
+}
+
+// NewCORSHandler 创建跨域中间件，预检请求会直接返回 204 状态码，不再调用后续处理器
+func NewCORSHandler(config CORSHandlerConfig) (mw HandlerMiddleware) {
+	if len(config.AllowOrigins) == 0 {
+		config.AllowOrigins = []string{"*"}
+	}
+	if len(config.AllowMethods) == 0 {
+		config.AllowMethods = []string{
+			http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+			http.MethodDelete, http.MethodHead, http.MethodOptions,
+		}
+	}
+	var (
+		allowAll      = slices.Contains(config.AllowOrigins, "*")
+		allowMethods  = strings.Join(config.AllowMethods, ", ")
+		allowHeaders  = strings.Join(config.AllowHeaders, ", ")
+		exposeHeaders = strings.Join(config.ExposeHeaders, ", ")
+		maxAge        = strconv.Itoa(int(config.MaxAge.Seconds()))
+	)
+	return func(next http.Handler) (handler http.Handler) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			header := w.Header()
+			header.Add("Vary", "Origin")
+			// 非跨域请求或来源不被允许时，不设置跨域响应头
+			if origin == "" || (!allowAll && !matchOrigin(origin, config.AllowOrigins)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if allowAll && !config.AllowCredentials {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if config.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+			// 预检请求
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				header.Add("Vary", "Access-Control-Request-Method")
+				header.Add("Vary", "Access-Control-Request-Headers")
+				header.Set("Access-Control-Allow-Methods", allowMethods)
+				if allowHeaders != "" {
+					header.Set("Access-Control-Allow-Headers", allowHeaders)
+				} else if reqHeaders := r.Header.Get("Access-Control-Request-Headers"); reqHeaders != "" {
+					header.Set("Access-Control-Allow-Headers", reqHeaders)
+				}
+				if config.MaxAge > 0 {
+					header.Set("Access-Control-Max-Age", maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// matchOrigin 来源是否匹配允许的来源列表
+func matchOrigin(origin string, allowOrigins []string) (ok bool) {
+	for _, allow := range allowOrigins {
+		if strings.EqualFold(allow, origin) {
+			return true
+		}
+		// 子域名通配，如 https://*.example.com
+		if prefix, suffix, found := strings.Cut(allow, "*"); found {
+			if len(origin) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) &&
+				strings.HasSuffix(strings.ToLower(origin), strings.ToLower(suffix)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RealIPHandlerConfig 客户端真实IP中间件配置
+type RealIPHandlerConfig struct {
+	TrustedProxies []string // 受信任的代理IP或网段（CIDR），默认信任回环地址和私有网段
+}
+
+// NewRealIPHandler 创建客户端真实IP中间件
+//
+//	只有直接连接的对端是受信任的代理时，才会从 X-Forwarded-For（从右向左跳过受信任的代理）或 X-Real-IP 中解析客户端IP，
+//	避免客户端伪造请求头。解析结果存入请求上下文，并替换 r.RemoteAddr，可以通过 GetRealIP 获取
+func NewRealIPHandler(config RealIPHandlerConfig) (mw HandlerMiddleware) {
+	trusted := parseTrustedProxies(config.TrustedProxies)
+	return func(next http.Handler) (handler http.Handler) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, port, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			clientIp := host
+			if isTrustedProxy(net.ParseIP(host), trusted) {
+				clientIp = forwardedClientIP(r, trusted, host)
+			}
+			r = r.WithContext(context.WithValue(r.Context(), realIPKey, clientIp))
+			if port != "" {
+				r.RemoteAddr = net.JoinHostPort(clientIp, port)
+			} else {
+				r.RemoteAddr = clientIp
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetRealIP 获取客户端真实IP，优先使用 NewRealIPHandler 解析的结果，否则返回直接连接的对端IP
+func GetRealIP(r *http.Request) (clientIp string) {
+	if ip, ok := r.Context().Value(realIPKey).(string); ok && ip != "" {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// parseTrustedProxies 解析受信任的代理
+func parseTrustedProxies(proxies []string) (networks []*net.IPNet) {
+	if len(proxies) == 0 {
+		proxies = []string{"127.0.0.0/8", "::1/128", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
+	}
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return
+}
+
+// isTrustedProxy 是否是受信任的代理
+func isTrustedProxy(ip net.IP, trusted []*net.IPNet) (ok bool) {
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedClientIP 从转发请求头中解析客户端IP，解析失败时返回 remoteIp
+func forwardedClientIP(r *http.Request, trusted []*net.IPNet, remoteIp string) (clientIp string) {
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		ips := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(ips[i]))
+			if ip == nil {
+				break
+			}
+			clientIp = ip.String()
+			if !isTrustedProxy(ip, trusted) {
+				return
+			}
+		}
+		if clientIp != "" {
+			return
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return remoteIp
+}
+
+// NewBodyLimitHandler 创建请求体大小限制中间件，超出限制时返回 413 状态码的 gtkresp.Fail 响应
+//
+//	Content-Length 超出限制的请求会被直接拒绝；未声明长度的请求，处理器读取请求体超出限制时会得到 *http.MaxBytesError
+func NewBodyLimitHandler(maxBytes int64) (mw HandlerMiddleware) {
+	return func(next http.Handler) (handler http.Handler) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				writeFail(w, r, http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+				return
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// NewTimeoutHandler 创建超时中间件，可以为不同的路由设置不同的超时时间
+//
+//	超时通过请求上下文传递，处理器需要响应 ctx.Done()；超时且处理器还没有写入响应时，返回 503 状态码的 gtkresp.Fail 响应。
+//	与 http.TimeoutHandler 不同，响应不会被缓冲，流式响应不受影响
+func NewTimeoutHandler(timeout time.Duration, msg ...string) (mw HandlerMiddleware) {
+	message := "request timeout"
+	if len(msg) > 0 && msg[0] != "" {
+		message = msg[0]
+	}
+	return func(next http.Handler) (handler http.Handler) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			sw := wrapStatusWriter(w)
+			next.ServeHTTP(sw, r.WithContext(ctx))
+			if !sw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				writeFail(sw, r, http.StatusServiceUnavailable, message)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-22 16:47:12
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-22 16:47:12
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/liusuxian/go-toolkit/gtkresp"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serve 使用处理器处理请求
+func serve(h http.Handler, r *http.Request) (w *httptest.ResponseRecorder) {
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return
+}
+
+// decodeResp 解析 gtkresp.Response 响应
+func decodeResp(w *httptest.ResponseRecorder) (resp gtkresp.Response) {
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return
+}
+
+func TestServerRequestIDAndRecovery(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		logger = newCaptureLogger()
+		seen   string
+		h      = gtkhttp.ChainHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = gtkhttp.GetRequestInfo(r.Context()).RequestID
+			if r.URL.Path == "/panic" {
+				panic("boom")
+			}
+			gtkresp.RespSucc(w, "ok")
+		}),
+			gtkhttp.NewRequestIDHandler(gtkhttp.RequestIDHandlerConfig{}),
+			gtkhttp.NewAccessLogHandler(gtkhttp.AccessLogHandlerConfig{Logger: logger, SensitiveFields: []string{"token"}}),
+			gtkhttp.NewRecoveryHandler(gtkhttp.RecoveryHandlerConfig{Logger: logger}),
+		)
+	)
+	// 生成请求ID
+	w := serve(h, httptest.NewRequest(http.MethodGet, "/ok?token=abc", nil))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.NotEmpty(seen)
+	assert.Equal(seen, w.Header().Get("X-Request-Id"))
+	// 透传请求ID
+	r := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	r.Header.Set("X-Request-Id", "req-1")
+	w = serve(h, r)
+	assert.Equal("req-1", seen)
+	assert.Equal("req-1", w.Header().Get("X-Request-Id"))
+	// 捕获 panic
+	r = httptest.NewRequest(http.MethodGet, "/panic", nil)
+	r.Header.Set("X-Request-Id", "req-2")
+	w = serve(h, r)
+	assert.Equal(http.StatusInternalServerError, w.Code)
+	resp := decodeResp(w)
+	assert.Equal(http.StatusInternalServerError, resp.Code)
+	assert.Equal("req-2", resp.GetString("request_id"))
+
+	logs := logger.Logs()
+	if assert.Len(logs, 4) {
+		assert.Contains(logs[0], `"url":"/ok?token=%2A%2A%2A"`)
+		assert.NotContains(logs[0], "abc")
+		assert.Contains(logs[2], "boom")
+		assert.Contains(logs[3], `"status_code":500`)
+		assert.Contains(logs[3], `"request_id":"req-2"`)
+	}
+}
+
+func TestServerCORS(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		calls  int
+		h      = gtkhttp.NewCORSHandler(gtkhttp.CORSHandlerConfig{
+			AllowOrigins:     []string{"https://*.example.com"},
+			AllowCredentials: true,
+			ExposeHeaders:    []string{"X-Request-Id"},
+			MaxAge:           time.Hour,
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+		}))
+	)
+	// 预检请求
+	r := httptest.NewRequest(http.MethodOptions, "/", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	r.Header.Set("Access-Control-Request-Headers", "Authorization")
+	w := serve(h, r)
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Equal("https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal("true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal("Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal("3600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(0, calls)
+	// 普通请求
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	w = serve(h, r)
+	assert.Equal("X-Request-Id", w.Header().Get("Access-Control-Expose-Headers"))
+	assert.Equal(1, calls)
+	// 来源不被允许
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "https://evil.com")
+	w = serve(h, r)
+	assert.Empty(w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(2, calls)
+}
+
+func TestServerRealIP(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ip     string
+		h      = gtkhttp.NewRealIPHandler(gtkhttp.RealIPHandlerConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip = gtkhttp.GetRealIP(r)
+		}))
+	)
+	// 受信任的代理
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1, 2.2.2.2, 10.0.0.2")
+	serve(h, r)
+	assert.Equal("2.2.2.2", ip)
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "127.0.0.1:1234"
+	r.Header.Set("X-Real-IP", "3.3.3.3")
+	serve(h, r)
+	assert.Equal("3.3.3.3", ip)
+	// 不受信任的对端，忽略转发请求头
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "8.8.8.8:1234"
+	r.Header.Set("X-Forwarded-For", "1.1.1.1")
+	serve(h, r)
+	assert.Equal("8.8.8.8", ip)
+}
+
+func TestServerBodyLimitAndTimeout(t *testing.T) {
+	assert := assert.New(t)
+	// 请求体大小限制
+	var readErr error
+	h := gtkhttp.NewBodyLimitHandler(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+	w := serve(h, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789")))
+	assert.Equal(http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(http.StatusRequestEntityTooLarge, decodeResp(w).Code)
+	r := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(strings.NewReader("0123456789")))
+	r.ContentLength = -1
+	serve(h, r)
+	var maxBytesErr *http.MaxBytesError
+	assert.True(errors.As(readErr, &maxBytesErr))
+	serve(h, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("01234567")))
+	assert.NoError(readErr)
+	// 超时
+	h = gtkhttp.NewTimeoutHandler(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+			gtkresp.RespSucc(w, "ok")
+		}
+	}))
+	w = serve(h, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(http.StatusServiceUnavailable, w.Code)
+	assert.Equal("request timeout", decodeResp(w).Message)
+}
@@ -0,0 +1,206 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-20 17:26:05
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-20 17:26:05
+ * @Description: 流式数据分帧器
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	defaultMaxFrameSize = 16 << 20 // 默认最大帧大小 16MB
+)
+
+var (
+	ErrFrameTooLarge = errors.New("stream frame is too large") // 帧大小超出限制
+)
+
+// StreamFramer 流式数据分帧器接口，将响应体切分为一个个数据帧，每个数据帧会被解码为 StreamReader[T] 的 T
+//
+//	分帧器是有状态的，每个流需要使用独立的分帧器实例
+type StreamFramer interface {
+	ReadFrame(reader *bufio.Reader) (frame []byte, err error) // 读取下一帧数据，流结束时返回 io.EOF
+	Accept() (accept string)                                  // 请求头 Accept 的默认值
+}
+
+// WithStreamFramer 设置流式数据分帧器，默认（或为空时）使用 SSE 格式
+func WithStreamFramer(framer StreamFramer) (opt StreamOption) {
+	return func(opts *streamOptions) {
+		opts.framer = framer
+	}
+}
+
+// NDJSONFramer 换行分隔的 JSON（NDJSON / JSON Lines）分帧器，忽略空行
+type NDJSONFramer struct {
+	maxFrameSize int
+}
+
+// NewNDJSONFramer 新建 NDJSON 分帧器
+//
+//	maxFrameSize 为每行（包括换行符）的最大字节数，默认 16MB
+func NewNDJSONFramer(maxFrameSize int) (framer *NDJSONFramer) {
+	if maxFrameSize <= 0 {
+		maxFrameSize = defaultMaxFrameSize
+	}
+	return &NDJSONFramer{
+		maxFrameSize: maxFrameSize,
+	}
+}
+
+// ReadFrame 读取下一帧数据，一行超出最大帧大小时立即返回 ErrFrameTooLarge，不会继续缓存该行
+func (f *NDJSONFramer) ReadFrame(reader *bufio.Reader) (frame []byte, err error) {
+	for {
+		var line []byte
+		for {
+			var slice []byte
+			slice, err = reader.ReadSlice('\n')
+			if len(line)+len(slice) > f.maxFrameSize {
+				return nil, fmt.Errorf("%w: more than %d bytes", ErrFrameTooLarge, f.maxFrameSize)
+			}
+			// ReadSlice 返回的数据在下次读取时会被覆盖，需要拷贝
+			line = append(line, slice...)
+			if err != bufio.ErrBufferFull {
+				break
+			}
+		}
+		if err != nil && err != io.EOF {
+			// 读取中断时不完整的行不能作为一帧返回
+			return nil, err
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			// 最后一行没有换行符时同样视为一帧
+			return line, nil
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Accept 请求头 Accept 的默认值
+func (f *NDJSONFramer) Accept() (accept string) {
+	return "application/x-ndjson"
+}
+
+// JSONArrayFramer JSON 数组分帧器，将流式返回的 JSON 数组中的每个元素作为一帧
+type JSONArrayFramer struct {
+	reader  *bufio.Reader
+	decoder *json.Decoder
+}
+
+// NewJSONArrayFramer 新建 JSON 数组分帧器
+func NewJSONArrayFramer() (framer *JSONArrayFramer) {
+	return &JSONArrayFramer{}
+}
+
+// ReadFrame 读取下一帧数据
+func (f *JSONArrayFramer) ReadFrame(reader *bufio.Reader) (frame []byte, err error) {
+	if f.decoder == nil || f.reader != reader {
+		f.reader = reader
+		f.decoder = json.NewDecoder(reader)
+		// 读取数组开始符
+		var token json.Token
+		if token, err = f.decoder.Token(); err != nil {
+			return
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return nil, fmt.Errorf("expected JSON array, got %v", token)
+		}
+	}
+	if !f.decoder.More() {
+		// 读取数组结束符
+		if _, err = f.decoder.Token(); err != nil {
+			return
+		}
+		return nil, io.EOF
+	}
+
+	var raw json.RawMessage
+	if err = f.decoder.Decode(&raw); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return
+	}
+	return raw, nil
+}
+
+// Accept 请求头 Accept 的默认值
+func (f *JSONArrayFramer) Accept() (accept string) {
+	return "application/json"
+}
+
+// LengthPrefixedFramer 长度前缀分帧器，每帧由固定字节数的长度头和对应长度的数据组成
+type LengthPrefixedFramer struct {
+	prefixSize   int
+	byteOrder    binary.ByteOrder
+	maxFrameSize int
+}
+
+// NewLengthPrefixedFramer 新建长度前缀分帧器
+//
+//	prefixSize 为长度头的字节数，可选值: 1、2、4、8，默认 4；byteOrder 默认 binary.BigEndian；maxFrameSize 默认 16MB
+func NewLengthPrefixedFramer(prefixSize int, byteOrder binary.ByteOrder, maxFrameSize int) (framer *LengthPrefixedFramer) {
+	switch prefixSize {
+	case 1, 2, 4, 8:
+	default:
+		prefixSize = 4
+	}
+	if byteOrder == nil {
+		byteOrder = binary.BigEndian
+	}
+	if maxFrameSize <= 0 {
+		maxFrameSize = defaultMaxFrameSize
+	}
+	return &LengthPrefixedFramer{
+		prefixSize:   prefixSize,
+		byteOrder:    byteOrder,
+		maxFrameSize: maxFrameSize,
+	}
+}
+
+// ReadFrame 读取下一帧数据
+func (f *LengthPrefixedFramer) ReadFrame(reader *bufio.Reader) (frame []byte, err error) {
+	header := make([]byte, f.prefixSize)
+	if _, err = io.ReadFull(reader, header); err != nil {
+		return
+	}
+
+	var size uint64
+	switch f.prefixSize {
+	case 1:
+		size = uint64(header[0])
+	case 2:
+		size = uint64(f.byteOrder.Uint16(header))
+	case 4:
+		size = uint64(f.byteOrder.Uint32(header))
+	default:
+		size = f.byteOrder.Uint64(header)
+	}
+	if size > uint64(f.maxFrameSize) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, size)
+	}
+
+	frame = make([]byte, size)
+	if _, err = io.ReadFull(reader, frame); err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return
+}
+
+// Accept 请求头 Accept 的默认值
+func (f *LengthPrefixedFramer) Accept() (accept string) {
+	return "application/octet-stream"
+}
@@ -0,0 +1,167 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-20 18:05:31
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-20 18:05:31
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type chunk struct {
+	Text  string              `json:"text"`
+	Stats gtkhttp.StreamStats `json:"-"`
+}
+
+func (c *chunk) SetStreamStats(stats gtkhttp.StreamStats) {
+	c.Stats = stats
+}
+
+func lengthPrefixed(frames ...string) (body []byte) {
+	var buf bytes.Buffer
+	for _, frame := range frames {
+		binary.Write(&buf, binary.BigEndian, uint32(len(frame)))
+		buf.WriteString(frame)
+	}
+	return buf.Bytes()
+}
+
+// endlessReader 无限返回不包含换行符的数据
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (n int, err error) {
+	for i := range p {
+		p[i] = 'x'
+	}
+	return len(p), nil
+}
+
+func TestStreamFramers(t *testing.T) {
+	tests := []struct {
+		name   string
+		framer gtkhttp.StreamFramer
+		accept string
+		body   []byte
+	}{
+		{
+			name:   "ndjson",
+			framer: gtkhttp.NewNDJSONFramer(0),
+			accept: "application/x-ndjson",
+			body:   []byte("{\"text\":\"a\"}\n\n{\"text\":\"b\"}\r\n{\"text\":\"c\"}"),
+		},
+		{
+			name:   "json array",
+			framer: gtkhttp.NewJSONArrayFramer(),
+			accept: "application/json",
+			body:   []byte("[\n {\"text\":\"a\"},\n {\"text\":\"b\"} ,{\"text\":\"c\"}\n]\n"),
+		},
+		{
+			name:   "length prefixed",
+			framer: gtkhttp.NewLengthPrefixedFramer(4, nil, 0),
+			accept: "application/octet-stream",
+			body:   lengthPrefixed(`{"text":"a"}`, `{"text":"b"}`, `{"text":"c"}`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(tt.accept, r.Header.Get("Accept"))
+				w.Write(tt.body)
+			}))
+			defer server.Close()
+
+			client := gtkhttp.NewHTTPClient(server.URL)
+			req, err := client.NewRequest(context.Background(), http.MethodPost, server.URL)
+			assert.NoError(err)
+			stream, err := gtkhttp.SendRequestStream[chunk](client, req, gtkhttp.WithStreamFramer(tt.framer))
+			assert.NoError(err)
+
+			var (
+				texts    []string
+				finished bool
+			)
+			err = stream.ForEach(func(c chunk, isFinished bool) (err error) {
+				if isFinished {
+					finished = true
+					return
+				}
+				texts = append(texts, c.Text)
+				assert.Equal(len(texts), c.Stats.ChunkCount)
+				return
+			})
+			assert.NoError(err)
+			assert.True(finished)
+			assert.Equal([]string{"a", "b", "c"}, texts)
+		})
+	}
+}
+
+func TestStreamFramerErrors(t *testing.T) {
+	assert := assert.New(t)
+	// 错误帧
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{\"text\":\"a\"}\n{\"error\":{\"message\":\"overloaded\"}}\n"))
+	}))
+	defer server.Close()
+	client := gtkhttp.NewHTTPClient(server.URL)
+	req, err := client.NewRequest(context.Background(), http.MethodGet, server.URL)
+	assert.NoError(err)
+	stream, err := gtkhttp.SendRequestStream[chunk](client, req, gtkhttp.WithStreamFramer(gtkhttp.NewNDJSONFramer(0)))
+	assert.NoError(err)
+	defer stream.Close()
+	c, _, err := stream.Recv()
+	assert.NoError(err)
+	assert.Equal("a", c.Text)
+	_, _, err = stream.Recv()
+	assert.ErrorContains(err, "overloaded")
+	// JSON 数组不完整
+	framer := gtkhttp.NewJSONArrayFramer()
+	reader := bufio.NewReader(strings.NewReader(`[{"text":"a"},{"text":`))
+	frame, err := framer.ReadFrame(reader)
+	assert.NoError(err)
+	assert.JSONEq(`{"text":"a"}`, string(frame))
+	_, err = framer.ReadFrame(reader)
+	assert.ErrorIs(err, io.ErrUnexpectedEOF)
+	// 不是 JSON 数组
+	_, err = gtkhttp.NewJSONArrayFramer().ReadFrame(bufio.NewReader(strings.NewReader(`{"text":"a"}`)))
+	assert.Error(err)
+	// 帧大小超出限制，没有换行符的超长行不会被完整缓存
+	frame, err = gtkhttp.NewNDJSONFramer(8).ReadFrame(bufio.NewReader(strings.NewReader("{\"a\":1}\n")))
+	assert.NoError(err)
+	assert.Equal(`{"a":1}`, string(frame))
+	_, err = gtkhttp.NewNDJSONFramer(8).ReadFrame(bufio.NewReader(strings.NewReader("{\"a\":12}\n")))
+	assert.ErrorIs(err, gtkhttp.ErrFrameTooLarge)
+	_, err = gtkhttp.NewNDJSONFramer(64 << 10).ReadFrame(bufio.NewReader(endlessReader{}))
+	assert.ErrorIs(err, gtkhttp.ErrFrameTooLarge)
+	// 读取中断时不返回不完整的行
+	errBroken := errors.New("connection reset")
+	_, err = gtkhttp.NewNDJSONFramer(0).ReadFrame(bufio.NewReader(io.MultiReader(strings.NewReader(`{"a":`), iotest.ErrReader(errBroken))))
+	assert.ErrorIs(err, errBroken)
+	frame, err = gtkhttp.NewNDJSONFramer(0).ReadFrame(bufio.NewReader(strings.NewReader(`{"a":1}`)))
+	assert.NoError(err)
+	assert.Equal(`{"a":1}`, string(frame))
+	_, err = gtkhttp.NewLengthPrefixedFramer(2, binary.LittleEndian, 4).ReadFrame(bufio.NewReader(bytes.NewReader([]byte{5, 0, 'h', 'e', 'l', 'l', 'o'})))
+	assert.True(errors.Is(err, gtkhttp.ErrFrameTooLarge))
+	// 数据不完整
+	_, err = gtkhttp.NewLengthPrefixedFramer(1, nil, 0).ReadFrame(bufio.NewReader(bytes.NewReader([]byte{5, 'h'})))
+	assert.ErrorIs(err, io.ErrUnexpectedEOF)
+}
@@ -12,9 +12,13 @@ package gtkhttp
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -23,6 +27,10 @@ var (
 	headerData2  = []byte("data:")
 	errorPrefix  = []byte(`data: {"error":`)
 	errorPrefix2 = []byte(`data:{"error":`)
+	// errorFramePrefix 非 SSE 格式的错误帧前缀
+	errorFramePrefix = []byte(`{"error":`)
+	// errStreamNoReconnect 服务端要求停止重连
+	errStreamNoReconnect = errors.New("stream reconnect is not allowed")
 )
 
 // Streamable 可流式传输的类型
@@ -41,13 +49,62 @@ type StreamReader[T Streamable] struct {
 	streamReturnIntervalTimer   *time.Timer
 	errAccumulator              ErrorAccumulator
 	responseDecoder             ResponseDecoder
+	framer                      StreamFramer // 分帧器，为空时使用 SSE 格式
 	// 统计字段
 	startTime  time.Time
 	chunkCount int
+	// SSE 状态
+	lastEventID string        // 最后一次收到的事件ID
+	retry       time.Duration // 服务端指定的重连间隔
+	pendingData []byte        // 不使用空行分隔事件时，属于下一个事件的 data 行
+	// 断线重连
+	ctx             context.Context
+	reconnectConfig StreamReconnectConfig
+	reconnectFn     func(lastEventID string) (resp *http.Response, err error)
+	reconnects      int // 连续重连次数
 	// 响应头
 	HttpHeader
 }
 
+// Event SSE 事件
+type Event struct {
+	ID    string        // 事件ID，未指定时为最后一次收到的事件ID
+	Event string        // 事件类型，未指定时为空（即 message）
+	Data  []byte        // 事件数据，多行 data 使用换行符连接
+	Retry time.Duration // 服务端指定的重连间隔
+}
+
+// StreamReconnectConfig 流式传输断线重连配置
+type StreamReconnectConfig struct {
+	MaxRetries int           // 最大连续重连次数，收到事件后重新计数，默认 3
+	Delay      time.Duration // 重连间隔，服务端通过 retry 字段指定时以服务端为准，默认 1s
+	OnEOF      bool          // 连接正常关闭（未收到 [DONE]）时是否也重连，默认仅在读取出错时重连
+}
+
+// StreamOption 流式传输选项
+type StreamOption func(opts *streamOptions)
+
+// streamOptions 流式传输选项
+type streamOptions struct {
+	reconnect *StreamReconnectConfig
+	framer    StreamFramer
+}
+
+// WithStreamReconnect 开启断线重连，重连时会重放请求并携带 Last-Event-ID 请求头
+//
+//	请求体必须可重放（req.GetBody 不为空），服务端返回 204 No Content 时停止重连
+func WithStreamReconnect(config StreamReconnectConfig) (opt StreamOption) {
+	return func(opts *streamOptions) {
+		if config.MaxRetries <= 0 {
+			config.MaxRetries = 3
+		}
+		if config.Delay <= 0 {
+			config.Delay = time.Second
+		}
+		opts.reconnect = &config
+	}
+}
+
 // StreamStatsReceiver 流式传输统计信息接收器
 type StreamStatsReceiver interface {
 	SetStreamStats(stats StreamStats) // 设置流式传输统计信息
@@ -154,70 +211,235 @@ func (stream *StreamReader[T]) RecvRaw() (b []byte, err error) {
 	return stream.processLines()
 }
 
+// RecvEvent 接收 SSE 事件，流结束时返回 io.EOF
+//
+//	使用非 SSE 格式的分帧器时，每一帧作为一个只有 Data 的事件返回
+func (stream *StreamReader[T]) RecvEvent() (event Event, err error) {
+	if stream.framer != nil {
+		event.Data, err = stream.readFrame()
+		return
+	}
+	return stream.nextEvent()
+}
+
+// LastEventID 获取最后一次收到的事件ID
+func (stream *StreamReader[T]) LastEventID() (id string) {
+	return stream.lastEventID
+}
+
 // processLines 处理行数据
 func (stream *StreamReader[T]) processLines() (b []byte, err error) {
+	if stream.framer != nil {
+		return stream.readFrame()
+	}
+	var event Event
+	if event, err = stream.nextEvent(); err != nil {
+		return
+	}
+	return event.Data, nil
+}
+
+// readFrame 通过分帧器读取下一帧数据
+func (stream *StreamReader[T]) readFrame() (frame []byte, err error) {
+	if frame, err = stream.framer.ReadFrame(stream.reader); err != nil {
+		if err == io.EOF {
+			stream.isFinished = true
+		}
+		return nil, err
+	}
+	// 错误帧
+	if noSpaceFrame := bytes.TrimSpace(frame); bytes.HasPrefix(noSpaceFrame, errorFramePrefix) {
+		var errResp map[string]any
+		if e := stream.responseDecoder.Decode(bytes.NewReader(noSpaceFrame), &errResp); e == nil {
+			return nil, fmt.Errorf("error, %v", errResp)
+		}
+	}
+	return
+}
+
+// nextEvent 读取下一个 SSE 事件
+//
+//	支持 data、event、id、retry 字段和注释行，多行 data 使用换行符连接，空行表示事件结束
+//	兼容不使用空行分隔事件的服务端：已读取的 data 是完整的 JSON 对象、数组或 [DONE] 时，下一个 data 行属于新的事件
+//	未知字段会被当作错误内容累积，并计入空消息数量
+func (stream *StreamReader[T]) nextEvent() (event Event, err error) {
 	var (
 		emptyMessagesCount uint
 		hasErrorPrefix     bool
+		hasData            bool
+		data               bytes.Buffer
 	)
+	if stream.pendingData != nil {
+		data.Write(stream.pendingData)
+		hasData, stream.pendingData = true, nil
+	}
 
 	for {
 		rawLine, readErr := stream.reader.ReadBytes('\n')
+		if readErr != nil && !hasErrorPrefix {
+			// 处理最后一个没有换行符的数据行
+			if readErr == io.EOF && len(bytes.TrimSpace(rawLine)) > 0 {
+				readErr = nil
+			} else if hasData && readErr == io.EOF {
+				// 连接关闭时分发未结束的事件
+				return stream.dispatchEvent(event, data.Bytes())
+			} else if stream.reconnect(readErr) {
+				// 重连成功后丢弃未结束的事件
+				event, hasData = Event{}, false
+				data.Reset()
+				continue
+			}
+		}
 		if readErr != nil || hasErrorPrefix {
 			if readErr == io.EOF {
 				stream.isFinished = true
-				return nil, io.EOF
+				return event, io.EOF
 			}
 			if respErr := stream.unmarshalError(); respErr != nil {
-				return nil, fmt.Errorf("error, %v", respErr)
+				return event, fmt.Errorf("error, %v", respErr)
 			}
-			return nil, readErr
+			return event, readErr
 		}
 
+		// 只去掉行结束符，字段值中的空白（如逐字输出的文本中的空格）需要保留
 		var (
-			noSpaceLine     = bytes.TrimSpace(rawLine)
+			line            = bytes.TrimRight(rawLine, "\r\n")
 			headerDataBytes []byte
 		)
-		if bytes.HasPrefix(noSpaceLine, errorPrefix) {
+		if bytes.HasPrefix(line, errorPrefix) {
 			headerDataBytes = headerData
 			hasErrorPrefix = true
-		} else if bytes.HasPrefix(noSpaceLine, errorPrefix2) {
+		} else if bytes.HasPrefix(line, errorPrefix2) {
 			headerDataBytes = headerData2
 			hasErrorPrefix = true
 		}
-		// 如果不是数据行（既不是"data: "也不是"data:"开头）或者有错误
-		if (!bytes.HasPrefix(noSpaceLine, headerData) && !bytes.HasPrefix(noSpaceLine, headerData2)) || hasErrorPrefix {
-			if hasErrorPrefix {
-				noSpaceLine = bytes.TrimPrefix(noSpaceLine, headerDataBytes)
+		if hasErrorPrefix {
+			line = bytes.TrimPrefix(line, headerDataBytes)
+			if writeErr := stream.errAccumulator.Write(line); writeErr != nil {
+				return event, writeErr
+			}
+			continue
+		}
+		// 空行表示事件结束
+		if len(line) == 0 {
+			if hasData {
+				return stream.dispatchEvent(event, data.Bytes())
 			}
-			if writeErr := stream.errAccumulator.Write(noSpaceLine); writeErr != nil {
-				return nil, writeErr
+			if event.ID != "" || event.Event != "" || event.Retry > 0 {
+				// 没有 data 字段的事件不分发
+				event = Event{}
+				continue
 			}
 			emptyMessagesCount++
 			if emptyMessagesCount > stream.emptyMessagesLimit {
-				return nil, ErrTooManyEmptyStreamMessages
+				return event, ErrTooManyEmptyStreamMessages
 			}
 			continue
 		}
-		// 处理"data: "格式
-		if noPrefixLine, ok := bytes.CutPrefix(noSpaceLine, headerData); ok {
-			if string(noPrefixLine) == "[DONE]" {
-				stream.isFinished = true
-				return nil, io.EOF
-			}
-			return noPrefixLine, nil
+		// 注释行，通常用于保持连接
+		if line[0] == ':' {
+			continue
 		}
-		// 处理"data:"格式
-		if noPrefixLine, ok := bytes.CutPrefix(noSpaceLine, headerData2); ok {
-			if string(noPrefixLine) == "[DONE]" {
-				stream.isFinished = true
-				return nil, io.EOF
+		// 解析字段
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "data":
+			if hasData && isCompleteData(data.Bytes()) {
+				stream.pendingData = append([]byte{}, value...)
+				return stream.dispatchEvent(event, data.Bytes())
+			}
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.Write(value)
+			hasData = true
+		case "event":
+			event.Event = string(value)
+		case "id":
+			if !bytes.ContainsRune(value, 0) {
+				event.ID = string(value)
+				stream.lastEventID = event.ID
+			}
+		case "retry":
+			if ms, e := strconv.ParseUint(string(value), 10, 64); e == nil {
+				event.Retry = time.Duration(ms) * time.Millisecond
+				stream.retry = event.Retry
+			}
+		default:
+			if writeErr := stream.errAccumulator.Write(line); writeErr != nil {
+				return event, writeErr
+			}
+			emptyMessagesCount++
+			if emptyMessagesCount > stream.emptyMessagesLimit {
+				return event, ErrTooManyEmptyStreamMessages
 			}
-			return noPrefixLine, nil
 		}
 	}
 }
 
+// dispatchEvent 分发事件
+func (stream *StreamReader[T]) dispatchEvent(event Event, data []byte) (e Event, err error) {
+	if string(data) == "[DONE]" {
+		stream.isFinished = true
+		return event, io.EOF
+	}
+	event.Data = bytes.Clone(data)
+	if event.ID == "" {
+		event.ID = stream.lastEventID
+	}
+	stream.reconnects = 0
+	return event, nil
+}
+
+// isCompleteData 事件数据是否已经完整，即 [DONE] 或完整的 JSON 对象、数组
+func isCompleteData(data []byte) (ok bool) {
+	if string(data) == "[DONE]" {
+		return true
+	}
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed)
+}
+
+// reconnect 断线重连，重连成功返回 true
+func (stream *StreamReader[T]) reconnect(readErr error) (ok bool) {
+	if stream.reconnectFn == nil || stream.isFinished {
+		return
+	}
+	if readErr == io.EOF && !stream.reconnectConfig.OnEOF {
+		return
+	}
+
+	for stream.reconnects < stream.reconnectConfig.MaxRetries {
+		stream.reconnects++
+		delay := stream.reconnectConfig.Delay
+		if stream.retry > 0 {
+			delay = stream.retry
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-stream.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		resp, err := stream.reconnectFn(stream.lastEventID)
+		if errors.Is(err, errStreamNoReconnect) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		stream.response.Body.Close()
+		stream.response = resp
+		stream.reader = bufio.NewReader(resp.Body)
+		stream.HttpHeader = HttpHeader(resp.Header)
+		return true
+	}
+	return
+}
+
 // unmarshalError 解析错误响应数据
 func (stream *StreamReader[T]) unmarshalError() (errResp map[string]any) {
 	var errBytes []byte
@@ -11,11 +11,17 @@ package gtkhttp
 
 import (
 	"bufio"
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestStreamReader_Recv(t *testing.T) {
@@ -163,3 +169,155 @@ func TestStreamReader_ErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamReader_RecvEvent(t *testing.T) {
+	input := ": keep-alive\n" +
+		"event: message_start\n" +
+		"id: 1\n" +
+		"retry: 1500\n" +
+		"data: {\"type\":\"message_start\"}\n" +
+		"\n" +
+		"event: ping\n" +
+		"\n" +
+		"data: line1\n" +
+		"data:line2\n" +
+		"\n" +
+		"event: message_stop\n" +
+		"data: {}"
+	reader := strings.NewReader(input)
+	stream := &StreamReader[map[string]any]{
+		reader:          bufio.NewReader(reader),
+		response:        &http.Response{Body: io.NopCloser(reader)},
+		responseDecoder: &DefaultResponseDecoder{},
+		errAccumulator:  NewErrorAccumulator(),
+	}
+
+	want := []Event{
+		{ID: "1", Event: "message_start", Data: []byte(`{"type":"message_start"}`), Retry: 1500 * time.Millisecond},
+		{ID: "1", Data: []byte("line1\nline2")},
+		{ID: "1", Event: "message_stop", Data: []byte("{}")},
+	}
+	for i, w := range want {
+		event, err := stream.RecvEvent()
+		if err != nil {
+			t.Fatalf("event %d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(event, w) {
+			t.Errorf("event %d: expected %+v, got %+v", i, w, event)
+		}
+	}
+	if _, err := stream.RecvEvent(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected EOF, got %v", err)
+	}
+	if stream.LastEventID() != "1" {
+		t.Errorf("Expected last event id 1, got %s", stream.LastEventID())
+	}
+}
+
+func TestStreamReader_RecvEventWhitespace(t *testing.T) {
+	// 字段值只去掉冒号后的一个空格，保留其余的前后空白；只包含空白的行不是空行，不会结束事件
+	input := "data:  foo \r\n" +
+		"   \n" +
+		"data:\tbar\n" +
+		"\n" +
+		"data: end"
+	reader := strings.NewReader(input)
+	stream := &StreamReader[map[string]any]{
+		reader:             bufio.NewReader(reader),
+		response:           &http.Response{Body: io.NopCloser(reader)},
+		responseDecoder:    &DefaultResponseDecoder{},
+		errAccumulator:     NewErrorAccumulator(),
+		emptyMessagesLimit: 10,
+	}
+
+	want := []Event{
+		{Data: []byte(" foo \n\tbar")},
+		{Data: []byte("end")},
+	}
+	for i, w := range want {
+		event, err := stream.RecvEvent()
+		if err != nil {
+			t.Fatalf("event %d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(event, w) {
+			t.Errorf("event %d: expected %q, got %q", i, w.Data, event.Data)
+		}
+	}
+	if _, err := stream.RecvEvent(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected EOF, got %v", err)
+	}
+}
+
+func TestStreamReader_RecvWithoutBlankLines(t *testing.T) {
+	// 兼容不使用空行分隔事件的服务端，每个完整的 JSON 或 [DONE] 作为一个事件，不完整的 data 行仍然合并
+	input := "data: {\"a\":1}\n" +
+		"data: {\"a\":2}\n" +
+		"data: {\"a\":\n" +
+		"data: 3}\n" +
+		"data: [DONE]\n"
+	reader := strings.NewReader(input)
+	stream := &StreamReader[map[string]any]{
+		reader:          bufio.NewReader(reader),
+		response:        &http.Response{Body: io.NopCloser(reader)},
+		responseDecoder: &DefaultResponseDecoder{},
+		errAccumulator:  NewErrorAccumulator(),
+	}
+
+	for i, want := range []string{"{\"a\":1}", "{\"a\":2}", "{\"a\":\n3}"} {
+		data, err := stream.RecvRaw()
+		if err != nil {
+			t.Fatalf("event %d: unexpected error: %v", i, err)
+		}
+		if string(data) != want {
+			t.Errorf("event %d: expected %q, got %q", i, want, data)
+		}
+	}
+	if _, isFinished, err := stream.Recv(); err != nil || !isFinished {
+		t.Errorf("Expected finished, got %v, %v", isFinished, err)
+	}
+}
+
+func TestSendRequestStreamReconnect(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		body, _ := io.ReadAll(r.Body)
+		switch calls.Add(1) {
+		case 1:
+			fmt.Fprintf(w, "retry: 1\nid: 7\ndata: %s\n\n", body)
+		default:
+			fmt.Fprintf(w, "data: %s\n\ndata: [DONE]\n\n", r.Header.Get("Last-Event-ID"))
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL)
+	req, err := client.NewRequest(context.Background(), http.MethodPost, server.URL, WithBody("hello"))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	stream, err := SendRequestStream[string](client, req, WithStreamReconnect(StreamReconnectConfig{OnEOF: true}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stream.Close()
+
+	var got []string
+	for {
+		data, err := stream.RecvRaw()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got = append(got, string(data))
+	}
+	// 重连时重放请求体并携带 Last-Event-ID
+	if !reflect.DeepEqual(got, []string{`"hello"`, "7"}) {
+		t.Errorf("Unexpected data: %v", got)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 connections, got %d", calls.Load())
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 10:42:05
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 10:42:05
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"context"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/liusuxian/go-toolkit/gtktrace"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTracing(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		ctx      = context.Background()
+		exporter = gtktrace.NewInMemoryExporter()
+		tracer   = gtktrace.NewRecordingTracer(exporter)
+		calls    atomic.Int32
+	)
+	// 服务端解析 traceparent，第一次请求失败
+	server := httptest.NewServer(gtkhttp.NewTracingHandler(gtkhttp.TracingHandlerConfig{Tracer: tracer})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"message":"ok"}`))
+	})))
+	defer server.Close()
+
+	httpClient := newInterceptorClient(server.URL,
+		gtkhttp.NewRetryInterceptor(gtkhttp.RetryInterceptorConfig{MaxAttempts: 2}),
+		gtkhttp.NewTracingInterceptor(gtkhttp.TracingInterceptorConfig{Tracer: tracer}),
+	)
+	client := gtkhttp.NewMWClient(
+		gtkhttp.WithTracing(gtkhttp.TracingMiddlewareConfig{Tracer: tracer}),
+		gtkhttp.WithRequestIDGenerator(&fixedRequestIDGenerator{}),
+	)
+	_, err := client.HandlerRequest(ctx, "user", "Chat", nil, func(ctx context.Context, _ any) (resp any, err error) {
+		req, err := httpClient.NewRequest(ctx, http.MethodGet, httpClient.FullURL("/chat?api_key=secret"))
+		if err != nil {
+			return
+		}
+		result := &interceptorResponse{}
+		err = httpClient.SendRequest(req, result)
+		return result, err
+	})
+	assert.NoError(err)
+
+	spans := exporter.Spans()
+	byKind := make(map[gtktrace.SpanKind][]*gtktrace.SpanData)
+	for _, span := range spans {
+		byKind[span.Kind] = append(byKind[span.Kind], span)
+	}
+	var (
+		servers = byKind[gtktrace.SpanKindServer]
+		clients = byKind[gtktrace.SpanKindClient]
+	)
+	if assert.Len(servers, 2) && assert.Len(clients, 3) {
+		// 中间件跨度最后结束，是两次实际请求的父跨度
+		root := clients[2]
+		assert.Equal("Chat", root.Name)
+		assert.Equal("test-request-id", root.Attributes["request_id"])
+		assert.False(root.Parent.IsValid())
+		for i, attempt := range clients[:2] {
+			assert.Equal("HTTP GET", attempt.Name)
+			assert.Equal(root.SpanContext, attempt.Parent)
+			assert.Equal(server.URL+"/chat", attempt.Attributes["url.full"])
+			// 服务端跨度的父跨度是对应的客户端跨度
+			assert.Equal(attempt.SpanContext.SpanID, servers[i].Parent.SpanID)
+			assert.Equal(root.SpanContext.TraceID, servers[i].SpanContext.TraceID)
+		}
+		assert.Equal(gtktrace.StatusError, clients[0].Status)
+		assert.Equal(http.StatusServiceUnavailable, servers[0].Attributes["http.response.status_code"])
+		assert.Equal(gtktrace.StatusError, servers[0].Status)
+		assert.Equal(gtktrace.StatusUnset, clients[1].Status)
+	}
+}
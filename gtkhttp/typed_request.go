@@ -0,0 +1,138 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-20 15:40:17
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-20 15:40:17
+ * @Description: 泛型请求/响应辅助函数
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Get 发送 GET 请求并将响应解码为 T
+//
+//	path 会与客户端的 BaseURL 拼接（path 为绝对地址时直接使用），查询参数通过 WithQuery 设置
+//	T 为 string 或 []byte 时返回原始响应体，否则根据响应的 Content-Type 解码（JSON 或 XML）
+//	状态码非 2xx 时返回 *APIError 或 *RequestError，可以通过 Classify 获取错误类型
+func Get[T any](ctx context.Context, client *HTTPClient, path string, setters ...RequestOption) (result T, header http.Header, err error) {
+	return Do[T](ctx, client, http.MethodGet, path, setters...)
+}
+
+// Delete 发送 DELETE 请求并将响应解码为 T
+func Delete[T any](ctx context.Context, client *HTTPClient, path string, setters ...RequestOption) (result T, header http.Header, err error) {
+	return Do[T](ctx, client, http.MethodDelete, path, setters...)
+}
+
+// PostJSON 发送请求体为 JSON 的 POST 请求并将响应解码为 Resp
+func PostJSON[Req, Resp any](ctx context.Context, client *HTTPClient, path string, body Req, setters ...RequestOption) (result Resp, header http.Header, err error) {
+	return DoJSON[Req, Resp](ctx, client, http.MethodPost, path, body, setters...)
+}
+
+// PutJSON 发送请求体为 JSON 的 PUT 请求并将响应解码为 Resp
+func PutJSON[Req, Resp any](ctx context.Context, client *HTTPClient, path string, body Req, setters ...RequestOption) (result Resp, header http.Header, err error) {
+	return DoJSON[Req, Resp](ctx, client, http.MethodPut, path, body, setters...)
+}
+
+// PatchJSON 发送请求体为 JSON 的 PATCH 请求并将响应解码为 Resp
+func PatchJSON[Req, Resp any](ctx context.Context, client *HTTPClient, path string, body Req, setters ...RequestOption) (result Resp, header http.Header, err error) {
+	return DoJSON[Req, Resp](ctx, client, http.MethodPatch, path, body, setters...)
+}
+
+// DoJSON 发送请求体为 JSON 的请求并将响应解码为 Resp
+func DoJSON[Req, Resp any](ctx context.Context, client *HTTPClient, method, path string, body Req, setters ...RequestOption) (result Resp, header http.Header, err error) {
+	setters = append([]RequestOption{WithBody(body), WithContentType("application/json")}, setters...)
+	return Do[Resp](ctx, client, method, path, setters...)
+}
+
+// PostForm 发送请求体为 application/x-www-form-urlencoded 表单的 POST 请求并将响应解码为 Resp
+//
+//	form 支持的类型同 EncodeQuery
+func PostForm[Resp any](ctx context.Context, client *HTTPClient, path string, form any, setters ...RequestOption) (result Resp, header http.Header, err error) {
+	values, err := EncodeQuery(form)
+	if err != nil {
+		return
+	}
+	setters = append([]RequestOption{
+		WithBody(strings.NewReader(values.Encode())),
+		WithContentType("application/x-www-form-urlencoded"),
+	}, setters...)
+	return Do[Resp](ctx, client, http.MethodPost, path, setters...)
+}
+
+// Do 发送请求并将响应解码为 T
+func Do[T any](ctx context.Context, client *HTTPClient, method, path string, setters ...RequestOption) (result T, header http.Header, err error) {
+	var req *http.Request
+	if req, err = client.NewRequest(ctx, method, JoinURL(client.config.BaseURL, path), setters...); err != nil {
+		return
+	}
+	return SendTyped[T](client, req)
+}
+
+// SendTyped 发送已构建的请求并将响应解码为 T
+func SendTyped[T any](client *HTTPClient, req *http.Request) (result T, header http.Header, err error) {
+	// 设置默认请求头
+	if req.Header.Get("Accept") == "" {
+		switch any(&result).(type) {
+		case *string, *[]byte:
+			req.Header.Set("Accept", "*/*")
+		default:
+			req.Header.Set("Accept", "application/json")
+		}
+	}
+
+	var resp *http.Response
+	if resp, err = client.do(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	client.setRequestID(req, resp)
+	header = resp.Header
+	if v, ok := any(&result).(Response); ok {
+		v.SetHeader(resp.Header)
+	}
+
+	if isFailureStatusCode(resp) {
+		err = client.handleErrorResp(resp)
+		return
+	}
+
+	if err = client.decodeTyped(resp, &result); err != nil {
+		err = newDecodeError(resp, err)
+	}
+	return
+}
+
+// decodeTyped 根据响应的 Content-Type 解码响应数据
+func (c *HTTPClient) decodeTyped(resp *http.Response, v any) (err error) {
+	switch o := v.(type) {
+	case *[]byte:
+		*o, err = io.ReadAll(resp.Body)
+		return
+	case *string:
+		return decodeString(resp.Body, o)
+	}
+
+	if resp.StatusCode == http.StatusNoContent || resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return
+	}
+	if contentType := strings.ToLower(resp.Header.Get("Content-Type")); strings.Contains(contentType, "xml") {
+		err = xml.NewDecoder(resp.Body).Decode(v)
+	} else {
+		err = c.config.ResponseDecoder.Decode(resp.Body, v)
+	}
+	// 空响应体不视为错误
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return
+}
@@ -0,0 +1,131 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-20 16:18:52
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-20 16:18:52
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type listModelsQuery struct {
+	Page    int       `url:"page"`
+	Tags    []string  `url:"tag"`
+	Owner   string    `json:"owner,omitempty"`
+	Since   time.Time `url:"since,omitempty"`
+	Secret  string    `url:"-"`
+	private string
+}
+
+type model struct {
+	ID    string `json:"id" xml:"id"`
+	Owner string `json:"owner" xml:"owner"`
+}
+
+func TestJoinURL(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("https://api.example.com/v1/models", gtkhttp.JoinURL("https://api.example.com/v1/", "/models"))
+	assert.Equal("https://api.example.com/v1/models", gtkhttp.JoinURL("https://api.example.com/v1", "models"))
+	assert.Equal("https://api.example.com/v1?a=1", gtkhttp.JoinURL("https://api.example.com/v1/", "?a=1"))
+	assert.Equal("https://other.example.com/x", gtkhttp.JoinURL("https://api.example.com/v1", "https://other.example.com/x"))
+	assert.Equal("https://api.example.com", gtkhttp.JoinURL("https://api.example.com", ""))
+}
+
+func TestEncodeQuery(t *testing.T) {
+	assert := assert.New(t)
+	values, err := gtkhttp.EncodeQuery(&listModelsQuery{
+		Page:    2,
+		Tags:    []string{"a", "b"},
+		Secret:  "s",
+		private: "p",
+	})
+	assert.NoError(err)
+	assert.Equal(url.Values{"page": {"2"}, "tag": {"a", "b"}}, values)
+
+	values, err = gtkhttp.EncodeQuery(map[string]any{"limit": 10, "ok": true, "nil": nil})
+	assert.NoError(err)
+	assert.Equal(url.Values{"limit": {"10"}, "ok": {"true"}}, values)
+
+	_, err = gtkhttp.EncodeQuery(10)
+	assert.Error(err)
+}
+
+func TestTypedRequest(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode([]model{{ID: r.URL.Query().Get("page"), Owner: r.URL.Query().Get("owner")}})
+		case "/v1/models/xml":
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<model><id>m1</id><owner>me</owner></model>`))
+		case "/v1/echo":
+			w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+			io.Copy(w, r.Body)
+		case "/v1/form":
+			r.ParseForm()
+			json.NewEncoder(w).Encode(model{ID: r.PostForm.Get("id"), Owner: r.Header.Get("Content-Type")})
+		case "/v1/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"model not found","type":"invalid_request_error"}}`))
+		}
+	}))
+	defer server.Close()
+	client := gtkhttp.NewHTTPClient(server.URL + "/v1/")
+
+	// GET 请求，结构体编码查询参数
+	models, header, err := gtkhttp.Get[[]model](ctx, client, "/models", gtkhttp.WithQuery(listModelsQuery{Page: 3, Owner: "me"}))
+	assert.NoError(err)
+	assert.Equal([]model{{ID: "3", Owner: "me"}}, models)
+	assert.Equal("application/json", header.Get("Content-Type"))
+	// 根据 Content-Type 解码 XML
+	m, _, err := gtkhttp.Get[model](ctx, client, "models/xml")
+	assert.NoError(err)
+	assert.Equal(model{ID: "m1", Owner: "me"}, m)
+	// POST JSON 请求
+	echo, header, err := gtkhttp.PostJSON[model, *model](ctx, client, "echo", model{ID: "m2"})
+	assert.NoError(err)
+	assert.Equal(&model{ID: "m2"}, echo)
+	assert.Equal("application/json", header.Get("X-Content-Type"))
+	// 返回原始响应体
+	raw, _, err := gtkhttp.PostJSON[map[string]string, string](ctx, client, "echo", map[string]string{"id": "m3"})
+	assert.NoError(err)
+	assert.JSONEq(`{"id":"m3"}`, raw)
+	// POST 表单请求
+	m, _, err = gtkhttp.PostForm[model](ctx, client, "form", url.Values{"id": {"m4"}})
+	assert.NoError(err)
+	assert.Equal(model{ID: "m4", Owner: "application/x-www-form-urlencoded"}, m)
+	// 空响应体
+	m, _, err = gtkhttp.Delete[model](ctx, client, "empty")
+	assert.NoError(err)
+	assert.Equal(model{}, m)
+	// 错误响应解码为 APIError
+	_, header, err = gtkhttp.Get[model](ctx, client, "missing")
+	var apiErr *gtkhttp.APIError
+	if assert.True(errors.As(err, &apiErr)) {
+		assert.Equal(http.StatusNotFound, apiErr.HTTPStatusCode)
+		assert.Equal("model not found", apiErr.Message)
+	}
+	assert.NotNil(header)
+}
@@ -0,0 +1,523 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-21 17:03:36
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-21 17:03:36
+ * @Description: 断点续传上传（tus 协议风格）
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/gtkcache"
+	"github.com/liusuxian/go-toolkit/gtkconv"
+	"github.com/liusuxian/go-toolkit/internal/utils"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tusResumable        = "1.0.0"                           // tus 协议版本
+	tusContentType      = "application/offset+octet-stream" // PATCH 请求的 Content-Type
+	uploadPartExt       = ".part"                           // 未完成上传的数据文件扩展名
+	defaultUploadPrefix = "gtkhttp:upload:"                 // 上传状态缓存 key 的默认前缀
+)
+
+var (
+	ErrUploadNotFound       = errors.New("upload not found")                    // 上传不存在或已过期
+	ErrUploadOffsetMismatch = errors.New("upload offset mismatch")              // 上传偏移量不一致
+	ErrUploadLocked         = errors.New("upload is locked by another request") // 上传正在被其他请求写入
+)
+
+// ResumableUpload 断点续传的上传状态
+type ResumableUpload struct {
+	ID        string            `json:"id"`         // 上传ID
+	FileName  string            `json:"file_name"`  // 文件名
+	FileType  string            `json:"file_type"`  // 文件类型
+	Size      int64             `json:"size"`       // 文件总大小
+	Offset    int64             `json:"offset"`     // 已上传的大小
+	Metadata  map[string]string `json:"metadata"`   // 创建上传时携带的元数据
+	CreatedAt time.Time         `json:"created_at"` // 创建时间
+	ExpiresAt time.Time         `json:"expires_at"` // 过期时间，每次写入数据后顺延
+}
+
+// IsComplete 是否已上传完成
+func (u *ResumableUpload) IsComplete() (ok bool) {
+	return u.Offset >= u.Size
+}
+
+// UploadStore 断点续传的上传状态存储
+type UploadStore interface {
+	Get(ctx context.Context, id string) (upload *ResumableUpload, err error) // 获取上传状态，不存在时返回 ErrUploadNotFound
+	Save(ctx context.Context, upload *ResumableUpload) (err error)           // 保存上传状态，应在 upload.ExpiresAt 后过期
+	Delete(ctx context.Context, id string) (err error)                       // 删除上传状态
+}
+
+// CacheUploadStore 基于 gtkcache.ICache 的上传状态存储，上传状态序列化为 JSON 字符串保存
+type CacheUploadStore struct {
+	cache  gtkcache.ICache
+	prefix string
+}
+
+// NewCacheUploadStore 新建基于 gtkcache.ICache 的上传状态存储，prefix 为缓存 key 的前缀，默认 gtkhttp:upload:
+func NewCacheUploadStore(cache gtkcache.ICache, prefix ...string) (store *CacheUploadStore) {
+	store = &CacheUploadStore{cache: cache, prefix: defaultUploadPrefix}
+	if len(prefix) > 0 && prefix[0] != "" {
+		store.prefix = prefix[0]
+	}
+	return
+}
+
+// Get 获取上传状态
+func (store *CacheUploadStore) Get(ctx context.Context, id string) (upload *ResumableUpload, err error) {
+	var val any
+	if val, err = store.cache.Get(ctx, store.prefix+id); err != nil {
+		return
+	}
+	if val == nil {
+		return nil, ErrUploadNotFound
+	}
+	var str string
+	if str, err = gtkconv.ToStringE(val); err != nil {
+		return
+	}
+	upload = &ResumableUpload{}
+	if err = json.Unmarshal([]byte(str), upload); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Save 保存上传状态
+func (store *CacheUploadStore) Save(ctx context.Context, upload *ResumableUpload) (err error) {
+	var data []byte
+	if data, err = json.Marshal(upload); err != nil {
+		return
+	}
+	return store.cache.Set(ctx, store.prefix+upload.ID, string(data), time.Until(upload.ExpiresAt))
+}
+
+// Delete 删除上传状态
+func (store *CacheUploadStore) Delete(ctx context.Context, id string) (err error) {
+	return store.cache.Delete(ctx, store.prefix+id)
+}
+
+// ResumableUploadConfig 断点续传上传配置
+type ResumableUploadConfig struct {
+	BasePath   string        // 路由前缀，默认 /files/
+	TempDir    string        // 未完成上传的数据保存目录，默认 os.TempDir()/gtkhttp-uploads
+	DirPath    string        // 上传完成后文件的保存目录
+	Expiration time.Duration // 未完成上传的过期时间，默认 24 小时
+	Store      UploadStore   // 上传状态存储，默认使用内存缓存
+	Sink       UploadSink    // 上传完成后文件的写入目标，默认 LocalDirSink
+	// OnComplete 上传完成并写入 Sink 后的回调，写入失败时 fileInfo.GetErr() 不为空
+	OnComplete func(ctx context.Context, upload *ResumableUpload, fileInfo *UploadFileInfo)
+}
+
+// ResumableUploadHandler 断点续传上传处理器，实现了 tus 1.0.0 协议的 core、creation、expiration、termination 部分
+//
+//	POST   {BasePath}      创建上传，请求头 Upload-Length 为文件大小，Upload-Metadata 中的 filename 为文件名
+//	HEAD   {BasePath}{id}  查询已上传的偏移量
+//	PATCH  {BasePath}{id}  从 Upload-Offset 处追加数据，数据全部上传后自动合并写入 Sink
+//	DELETE {BasePath}{id}  终止上传
+//
+//	文件类型和大小的限制沿用 UploadFileService 的配置，同一上传的并发写入只在单个进程内互斥
+type ResumableUploadHandler struct {
+	service *UploadFileService
+	config  ResumableUploadConfig
+	locks   sync.Map // 上传ID -> *sync.Mutex
+}
+
+// NewResumableUploadHandler 新建断点续传上传处理器
+func NewResumableUploadHandler(service *UploadFileService, config ResumableUploadConfig) (h *ResumableUploadHandler) {
+	h = &ResumableUploadHandler{service: service, config: config}
+	// 设置配置默认值
+	if h.config.BasePath == "" {
+		h.config.BasePath = "/files/"
+	}
+	if !strings.HasSuffix(h.config.BasePath, "/") {
+		h.config.BasePath += "/"
+	}
+	if h.config.TempDir == "" {
+		h.config.TempDir = filepath.Join(os.TempDir(), "gtkhttp-uploads")
+	}
+	if h.config.Expiration <= 0 {
+		h.config.Expiration = 24 * time.Hour
+	}
+	if h.config.Store == nil {
+		h.config.Store = NewCacheUploadStore(gtkcache.NewMemoryCache())
+	}
+	if h.config.Sink == nil {
+		h.config.Sink = NewLocalDirSink()
+	}
+	return
+}
+
+// ServeHTTP 处理断点续传请求
+func (h *ResumableUploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusResumable)
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, h.config.BasePath), "/")
+	// 上传ID只包含字母和数字，防止路径穿越
+	if strings.ContainsAny(id, `/\.`) {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case r.Method == http.MethodOptions:
+		w.Header().Set("Tus-Version", tusResumable)
+		w.Header().Set("Tus-Extension", "creation,expiration,termination")
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(h.maxSize(), 10))
+		w.WriteHeader(http.StatusNoContent)
+	case id == "" && r.Method == http.MethodPost:
+		h.create(w, r)
+	case id != "" && r.Method == http.MethodHead:
+		h.head(w, r, id)
+	case id != "" && r.Method == http.MethodPatch:
+		h.patch(w, r, id)
+	case id != "" && r.Method == http.MethodDelete:
+		h.terminate(w, r, id)
+	default:
+		http.Error(w, ErrUnsupportedMethod.Error(), http.StatusMethodNotAllowed)
+	}
+}
+
+// Cleanup 清理过期的未完成上传，返回清理的数量，正在写入的上传会被跳过
+func (h *ResumableUploadHandler) Cleanup(ctx context.Context) (removed int, err error) {
+	var entries []os.DirEntry
+	if entries, err = os.ReadDir(h.config.TempDir); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), uploadPartExt) {
+			continue
+		}
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		id := strings.TrimSuffix(entry.Name(), uploadPartExt)
+		unlock, ok := h.lock(id)
+		if !ok {
+			continue
+		}
+		upload, e := h.config.Store.Get(ctx, id)
+		switch {
+		case errors.Is(e, ErrUploadNotFound), e == nil && now.After(upload.ExpiresAt):
+			h.remove(ctx, id)
+			removed++
+		}
+		unlock()
+	}
+	return
+}
+
+// StartCleanup 启动定时清理过期的未完成上传，ctx 结束时停止
+func (h *ResumableUploadHandler) StartCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.Cleanup(ctx)
+			}
+		}
+	}()
+}
+
+// create 创建上传
+func (h *ResumableUploadHandler) create(w http.ResponseWriter, r *http.Request) {
+	size, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		http.Error(w, "invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+	// 检查上传文件大小是否合法
+	if !h.service.checkSize(size) {
+		http.Error(w, ErrUnsupportedFileSize.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	// 判断上传文件类型是否合法
+	metadata := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	fileName := filepath.Base(metadata["filename"])
+	if metadata["filename"] == "" {
+		http.Error(w, ErrMissingFile.Error(), http.StatusBadRequest)
+		return
+	}
+	if !h.service.checkFileType(fileName) {
+		http.Error(w, ErrUnsupportedFileType.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	// 保存上传状态并创建数据文件
+	now := time.Now()
+	upload := &ResumableUpload{
+		ID:        rand.Text(),
+		FileName:  fileName,
+		FileType:  metadata["filetype"],
+		Size:      size,
+		Metadata:  metadata,
+		CreatedAt: now,
+		ExpiresAt: now.Add(h.config.Expiration),
+	}
+	if err = h.config.Store.Save(r.Context(), upload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err = h.createPartFile(upload.ID); err != nil {
+		h.config.Store.Delete(r.Context(), upload.ID)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Location", path.Join(h.config.BasePath, upload.ID))
+	h.writeUploadHeader(w, upload)
+	// 空文件直接完成
+	if upload.IsComplete() {
+		if status, err := h.finalize(r.Context(), upload); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// head 查询上传偏移量
+func (h *ResumableUploadHandler) head(w http.ResponseWriter, r *http.Request, id string) {
+	upload, err := h.getUpload(r.Context(), id)
+	if err != nil {
+		w.WriteHeader(uploadErrorStatus(err))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	h.writeUploadHeader(w, upload)
+	w.WriteHeader(http.StatusOK)
+}
+
+// patch 追加数据
+func (h *ResumableUploadHandler) patch(w http.ResponseWriter, r *http.Request, id string) {
+	if ct := r.Header.Get("Content-Type"); ct != tusContentType {
+		http.Error(w, "Content-Type must be "+tusContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+	unlock, ok := h.lock(id)
+	if !ok {
+		http.Error(w, ErrUploadLocked.Error(), http.StatusLocked)
+		return
+	}
+	defer unlock()
+
+	var upload *ResumableUpload
+	if upload, err = h.getUpload(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), uploadErrorStatus(err))
+		return
+	}
+	if offset != upload.Offset {
+		http.Error(w, ErrUploadOffsetMismatch.Error(), http.StatusConflict)
+		return
+	}
+	// 写入数据，客户端中断时保留已写入的部分
+	var n int64
+	if n, err = h.writePart(upload, r.Body); errors.Is(err, ErrUnsupportedFileSize) {
+		http.Error(w, ErrUnsupportedFileSize.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	upload.Offset += n
+	upload.ExpiresAt = time.Now().Add(h.config.Expiration)
+	if e := h.config.Store.Save(r.Context(), upload); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeUploadHeader(w, upload)
+	// 数据全部上传后合并
+	if upload.IsComplete() {
+		if status, err := h.finalize(r.Context(), upload); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// terminate 终止上传
+func (h *ResumableUploadHandler) terminate(w http.ResponseWriter, r *http.Request, id string) {
+	unlock, ok := h.lock(id)
+	if !ok {
+		http.Error(w, ErrUploadLocked.Error(), http.StatusLocked)
+		return
+	}
+	defer unlock()
+
+	if _, err := h.config.Store.Get(r.Context(), id); err != nil {
+		w.WriteHeader(uploadErrorStatus(err))
+		return
+	}
+	h.remove(r.Context(), id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// finalize 上传完成，校验文件内容后写入 Sink，并清理上传状态和数据文件
+func (h *ResumableUploadHandler) finalize(ctx context.Context, upload *ResumableUpload) (status int, err error) {
+	defer h.remove(ctx, upload.ID)
+
+	fileInfo := &UploadFileInfo{
+		FileName: upload.FileName,
+		FileSize: upload.Size,
+		FileType: upload.FileType,
+	}
+	defer func() {
+		fileInfo.err = err
+		if h.config.OnComplete != nil {
+			h.config.OnComplete(ctx, upload, fileInfo)
+		}
+	}()
+
+	var file *os.File
+	if file, err = os.Open(h.partPath(upload.ID)); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer file.Close()
+	// 根据文件头部判断文件内容是否与扩展名一致
+	head := make([]byte, sniffLen)
+	n, _ := io.ReadFull(file, head)
+	var ok bool
+	if fileInfo.FileType, ok = checkFileContent(upload.FileName, head[:n]); !ok {
+		return http.StatusUnsupportedMediaType, ErrUnsupportedFileType
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	// 写入 Sink
+	if fileInfo.FilePath, err = h.config.Sink.Save(ctx, h.config.DirPath, h.service.uploadFileNameFn(upload.FileName), file); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return
+}
+
+// getUpload 获取上传状态，已过期时清理并返回 ErrUploadNotFound
+func (h *ResumableUploadHandler) getUpload(ctx context.Context, id string) (upload *ResumableUpload, err error) {
+	if upload, err = h.config.Store.Get(ctx, id); err != nil {
+		return
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		h.remove(ctx, id)
+		return nil, ErrUploadNotFound
+	}
+	return
+}
+
+// writePart 从上传状态的偏移量处写入数据，数据超出文件总大小时丢弃本次写入并返回 ErrUnsupportedFileSize
+func (h *ResumableUploadHandler) writePart(upload *ResumableUpload, reader io.Reader) (n int64, err error) {
+	var file *os.File
+	if file, err = os.OpenFile(h.partPath(upload.ID), os.O_WRONLY|os.O_CREATE, 0644); err != nil {
+		return
+	}
+	defer file.Close()
+	// 丢弃上次写入后未能记录到上传状态中的数据
+	if err = file.Truncate(upload.Offset); err != nil {
+		return
+	}
+	sizeReader := &sizeLimitReader{reader: reader, limit: upload.Size - upload.Offset}
+	n, err = io.Copy(io.NewOffsetWriter(file, upload.Offset), sizeReader)
+	if sizeReader.exceeded {
+		file.Truncate(upload.Offset)
+		return 0, ErrUnsupportedFileSize
+	}
+	return
+}
+
+// createPartFile 创建数据文件
+func (h *ResumableUploadHandler) createPartFile(id string) (err error) {
+	if err = utils.MakeDirAll(h.config.TempDir); err != nil {
+		return fmt.Errorf("failed to create directory: %s, error: %w", h.config.TempDir, err)
+	}
+	var file *os.File
+	if file, err = os.Create(h.partPath(id)); err != nil {
+		return
+	}
+	return file.Close()
+}
+
+// remove 删除上传状态和数据文件
+func (h *ResumableUploadHandler) remove(ctx context.Context, id string) {
+	h.config.Store.Delete(ctx, id)
+	os.Remove(h.partPath(id))
+	h.locks.Delete(id)
+}
+
+// lock 获取上传的写入锁，已被其他请求持有时返回 false
+func (h *ResumableUploadHandler) lock(id string) (unlock func(), ok bool) {
+	val, _ := h.locks.LoadOrStore(id, &sync.Mutex{})
+	mu := val.(*sync.Mutex)
+	if !mu.TryLock() {
+		return nil, false
+	}
+	return mu.Unlock, true
+}
+
+// partPath 数据文件路径
+func (h *ResumableUploadHandler) partPath(id string) (filePath string) {
+	return filepath.Join(h.config.TempDir, id+uploadPartExt)
+}
+
+// maxSize 单个文件最大上传大小
+func (h *ResumableUploadHandler) maxSize() (size int64) {
+	return int64(h.service.config.MaxSize * 1024 * 1024)
+}
+
+// writeUploadHeader 写入上传状态相关的响应头
+func (h *ResumableUploadHandler) writeUploadHeader(w http.ResponseWriter, upload *ResumableUpload) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	w.Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
+
+// uploadErrorStatus 上传错误对应的状态码
+func uploadErrorStatus(err error) (status int) {
+	if errors.Is(err, ErrUploadNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// parseUploadMetadata 解析 Upload-Metadata 请求头，格式为逗号分隔的 "key base64(value)"
+func parseUploadMetadata(header string) (metadata map[string]string) {
+	metadata = make(map[string]string)
+	for pair := range strings.SplitSeq(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		metadata[key] = string(decoded)
+	}
+	return
+}
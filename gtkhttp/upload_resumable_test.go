@@ -0,0 +1,153 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-21 17:48:15
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-21 17:48:15
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"github.com/liusuxian/go-toolkit/gtkcache"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// tusRequest 发送断点续传请求
+func tusRequest(h http.Handler, method, target string, header map[string]string, body []byte) (resp *http.Response) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	r := httptest.NewRequest(method, target, reader)
+	r.Header.Set("Tus-Resumable", "1.0.0")
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Result()
+}
+
+// tusCreate 创建上传，返回上传地址
+func tusCreate(h http.Handler, fileName string, size int) (location string, status int) {
+	resp := tusRequest(h, http.MethodPost, "/files/", map[string]string{
+		"Upload-Length":   strconv.Itoa(size),
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte(fileName)),
+	}, nil)
+	return resp.Header.Get("Location"), resp.StatusCode
+}
+
+// tusPatch 追加数据
+func tusPatch(h http.Handler, location string, offset int, data []byte) (resp *http.Response) {
+	return tusRequest(h, http.MethodPatch, location, map[string]string{
+		"Content-Type":  "application/offset+octet-stream",
+		"Upload-Offset": strconv.Itoa(offset),
+	}, data)
+}
+
+func TestResumableUpload(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		dirPath  = t.TempDir()
+		content  = append([]byte("%PDF-1.7\n"), bytes.Repeat([]byte("0123456789"), 100)...)
+		fileInfo *gtkhttp.UploadFileInfo
+		h        = gtkhttp.NewResumableUploadHandler(gtkhttp.NewUploadFileService(gtkhttp.UploadFileConfig{}), gtkhttp.ResumableUploadConfig{
+			TempDir: t.TempDir(),
+			DirPath: dirPath,
+			OnComplete: func(ctx context.Context, upload *gtkhttp.ResumableUpload, info *gtkhttp.UploadFileInfo) {
+				fileInfo = info
+			},
+		})
+	)
+	// 创建上传
+	location, status := tusCreate(h, "report.pdf", len(content))
+	assert.Equal(http.StatusCreated, status)
+	assert.Regexp(`^/files/\w+$`, location)
+	// 上传第一段
+	resp := tusPatch(h, location, 0, content[:500])
+	assert.Equal(http.StatusNoContent, resp.StatusCode)
+	assert.Equal("500", resp.Header.Get("Upload-Offset"))
+	// 偏移量不一致
+	resp = tusPatch(h, location, 100, content[500:])
+	assert.Equal(http.StatusConflict, resp.StatusCode)
+	// 查询偏移量后续传
+	resp = tusRequest(h, http.MethodHead, location, nil, nil)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("500", resp.Header.Get("Upload-Offset"))
+	assert.Equal(strconv.Itoa(len(content)), resp.Header.Get("Upload-Length"))
+	assert.Nil(fileInfo)
+	resp = tusPatch(h, location, 500, content[500:])
+	assert.Equal(http.StatusNoContent, resp.StatusCode)
+	// 上传完成
+	if assert.NotNil(fileInfo) {
+		assert.NoError(fileInfo.GetErr())
+		assert.Equal(&gtkhttp.UploadFileInfo{FileName: "report.pdf", FileSize: int64(len(content)), FilePath: filepath.Join(dirPath, "report.pdf"), FileType: "application/pdf"}, fileInfo)
+		data, _ := os.ReadFile(fileInfo.FilePath)
+		assert.Equal(content, data)
+	}
+	resp = tusRequest(h, http.MethodHead, location, nil, nil)
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func TestResumableUploadErrors(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		tempDir  = t.TempDir()
+		fileInfo *gtkhttp.UploadFileInfo
+		h        = gtkhttp.NewResumableUploadHandler(gtkhttp.NewUploadFileService(gtkhttp.UploadFileConfig{}), gtkhttp.ResumableUploadConfig{
+			TempDir:    tempDir,
+			DirPath:    t.TempDir(),
+			Expiration: 100 * time.Millisecond,
+			Store:      gtkhttp.NewCacheUploadStore(gtkcache.NewMemoryCache()),
+			OnComplete: func(ctx context.Context, upload *gtkhttp.ResumableUpload, info *gtkhttp.UploadFileInfo) {
+				fileInfo = info
+			},
+		})
+	)
+	// 文件类型和大小不合法
+	_, status := tusCreate(h, "a.exe", 10)
+	assert.Equal(http.StatusUnsupportedMediaType, status)
+	_, status = tusCreate(h, "a.png", 2*1024*1024)
+	assert.Equal(http.StatusRequestEntityTooLarge, status)
+	// 数据超出文件大小
+	location, _ := tusCreate(h, "a.png", 10)
+	resp := tusPatch(h, location, 0, bytes.Repeat([]byte("x"), 11))
+	assert.Equal(http.StatusRequestEntityTooLarge, resp.StatusCode)
+	// 文件内容与扩展名不一致
+	resp = tusPatch(h, location, 0, []byte("%PDF-1.4\nx"))
+	assert.Equal(http.StatusUnsupportedMediaType, resp.StatusCode)
+	if assert.NotNil(fileInfo) {
+		assert.ErrorIs(fileInfo.GetErr(), gtkhttp.ErrUnsupportedFileType)
+	}
+	// 终止上传
+	location, _ = tusCreate(h, "b.png", 10)
+	resp = tusRequest(h, http.MethodDelete, location, nil, nil)
+	assert.Equal(http.StatusNoContent, resp.StatusCode)
+	resp = tusPatch(h, location, 0, []byte("x"))
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+	// 清理过期的上传
+	location, _ = tusCreate(h, "c.png", 10)
+	assert.Equal(http.StatusNoContent, tusPatch(h, location, 0, []byte("x")).StatusCode)
+	entries, _ := os.ReadDir(tempDir)
+	assert.Len(entries, 1)
+	time.Sleep(150 * time.Millisecond)
+	removed, err := h.Cleanup(context.Background())
+	assert.NoError(err)
+	assert.Equal(1, removed)
+	entries, _ = os.ReadDir(tempDir)
+	assert.Len(entries, 0)
+}
@@ -0,0 +1,326 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-21 15:18:42
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-21 15:18:42
+ * @Description: 流式上传
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/internal/utils"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	sniffLen = 512 // 嗅探文件类型时读取的字节数
+)
+
+// UploadSink 流式上传的写入目标，文件数据边读取边写入，不会在内存或临时文件中缓存整个请求
+//
+//	reader 在文件大小超出限制时会返回 ErrUnsupportedFileSize，写入目标应放弃本次写入并清理已写入的数据
+type UploadSink interface {
+	Save(ctx context.Context, dirPath, fileName string, reader io.Reader) (filePath string, err error) // 保存文件，返回文件路径
+}
+
+// UploadSinkFunc 函数形式的写入目标
+type UploadSinkFunc func(ctx context.Context, dirPath, fileName string, reader io.Reader) (filePath string, err error)
+
+// Save 保存文件
+func (f UploadSinkFunc) Save(ctx context.Context, dirPath, fileName string, reader io.Reader) (filePath string, err error) {
+	return f(ctx, dirPath, fileName, reader)
+}
+
+// LocalDirSink 本地目录写入目标，先写入同目录下的临时文件，写入完成后再重命名，失败时删除临时文件
+type LocalDirSink struct{}
+
+// NewLocalDirSink 新建本地目录写入目标
+func NewLocalDirSink() (sink *LocalDirSink) {
+	return &LocalDirSink{}
+}
+
+// Save 保存文件
+func (sink *LocalDirSink) Save(ctx context.Context, dirPath, fileName string, reader io.Reader) (filePath string, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	// 创建目录
+	if err = utils.MakeDirAll(dirPath); err != nil {
+		err = fmt.Errorf("failed to create directory: %s, error: %w", dirPath, err)
+		return
+	}
+	// 构建文件完整路径
+	filePath = filepath.Join(dirPath, fileName)
+	// 创建临时文件
+	var tmpFile *os.File
+	if tmpFile, err = os.CreateTemp(dirPath, ".upload-*"); err != nil {
+		err = fmt.Errorf("failed to create file: %s, error: %w", filePath, err)
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmpFile.Name())
+			filePath = ""
+		}
+	}()
+	// 写入文件
+	if _, err = io.Copy(tmpFile, reader); err != nil {
+		tmpFile.Close()
+		err = fmt.Errorf("failed to write file: %s, error: %w", filePath, err)
+		return
+	}
+	if err = tmpFile.Close(); err != nil {
+		err = fmt.Errorf("failed to write file: %s, error: %w", filePath, err)
+		return
+	}
+	if err = os.Rename(tmpFile.Name(), filePath); err != nil {
+		err = fmt.Errorf("failed to rename file: %s, error: %w", filePath, err)
+		return
+	}
+	return
+}
+
+// WriterSink io.Writer 写入目标，所有文件依次写入同一个 io.Writer，返回的文件路径为 dirPath/fileName
+//
+//	文件大小超出限制时已写入的数据无法撤回，由调用方根据返回的错误自行处理
+type WriterSink struct {
+	w io.Writer
+}
+
+// NewWriterSink 新建 io.Writer 写入目标
+func NewWriterSink(w io.Writer) (sink *WriterSink) {
+	return &WriterSink{w: w}
+}
+
+// Save 保存文件
+func (sink *WriterSink) Save(ctx context.Context, dirPath, fileName string, reader io.Reader) (filePath string, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if _, err = io.Copy(sink.w, reader); err != nil {
+		return
+	}
+	filePath = path.Join(dirPath, fileName)
+	return
+}
+
+// StreamUpload 流式上传，基于 multipart.Reader 逐个读取字段名为 file 的文件并直接写入 sink
+//
+//	与 Upload、BatchUpload 不同，请求体不会被解析到内存或临时文件中，文件大小在写入过程中检查，
+//	文件类型除了检查扩展名外还会根据文件头部的魔数进行校验；文件按顺序处理，单个文件失败不影响其他文件，
+//	超出最大数量时返回已处理文件的信息和一条错误信息
+func (s *UploadFileService) StreamUpload(r *http.Request, dirPath string, sink UploadSink) (fileInfos []*UploadFileInfo) {
+	if r.Method != "POST" {
+		fileInfos = []*UploadFileInfo{{err: ErrUnsupportedMethod}}
+		return
+	}
+	var (
+		reader *multipart.Reader
+		err    error
+	)
+	if reader, err = r.MultipartReader(); err != nil {
+		fileInfos = []*UploadFileInfo{{err: err}}
+		return
+	}
+	for {
+		var part *multipart.Part
+		if part, err = reader.NextPart(); err != nil {
+			if err != io.EOF {
+				fileInfos = append(fileInfos, &UploadFileInfo{err: err})
+			}
+			break
+		}
+		// 跳过非文件字段
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+		// 检查文件数量
+		if len(fileInfos) >= s.config.MaxCount {
+			part.Close()
+			fileInfos = append(fileInfos, &UploadFileInfo{err: fmt.Errorf("only allowed to upload a maximum of %v files at a time", s.config.MaxCount)})
+			break
+		}
+		fileInfos = append(fileInfos, s.streamUploadPart(r.Context(), part, dirPath, sink))
+		part.Close()
+	}
+	if len(fileInfos) == 0 {
+		fileInfos = []*UploadFileInfo{{err: ErrMissingFile}}
+	}
+	return
+}
+
+// streamUploadPart 流式上传单个文件
+func (s *UploadFileService) streamUploadPart(ctx context.Context, part *multipart.Part, dirPath string, sink UploadSink) (fileInfo *UploadFileInfo) {
+	fileInfo = &UploadFileInfo{
+		FileName: part.FileName(),
+		FileType: part.Header.Get("Content-type"),
+	}
+	// 判断上传文件类型是否合法
+	if !s.checkFileType(fileInfo.FileName) {
+		fileInfo.err = ErrUnsupportedFileType
+		return
+	}
+	// 根据文件头部判断文件内容是否与扩展名一致
+	var (
+		reader = bufio.NewReaderSize(part, sniffLen)
+		head   []byte
+		err    error
+		ok     bool
+	)
+	if head, err = reader.Peek(sniffLen); err != nil && err != io.EOF {
+		fileInfo.err = err
+		return
+	}
+	if fileInfo.FileType, ok = checkFileContent(fileInfo.FileName, head); !ok {
+		fileInfo.err = ErrUnsupportedFileType
+		return
+	}
+	// 写入文件，同时检查上传文件大小是否合法
+	sizeReader := &sizeLimitReader{reader: reader, limit: int64(s.config.MaxSize * 1024 * 1024)}
+	filePath, err := sink.Save(ctx, dirPath, s.uploadFileNameFn(fileInfo.FileName), sizeReader)
+	fileInfo.FileSize = sizeReader.size
+	if sizeReader.exceeded {
+		fileInfo.err = ErrUnsupportedFileSize
+		return
+	}
+	if err != nil {
+		fileInfo.err = err
+		return
+	}
+	fileInfo.FilePath = filePath
+	return
+}
+
+// sizeLimitReader 限制读取大小的 io.Reader，超出限制时返回 ErrUnsupportedFileSize
+type sizeLimitReader struct {
+	reader   io.Reader
+	limit    int64
+	size     int64
+	exceeded bool
+}
+
+// Read 读取数据
+func (r *sizeLimitReader) Read(p []byte) (n int, err error) {
+	if r.exceeded {
+		return 0, ErrUnsupportedFileSize
+	}
+	// 最多多读取 1 字节用于判断是否超出限制
+	if remain := r.limit - r.size + 1; int64(len(p)) > remain {
+		p = p[:remain]
+	}
+	n, err = r.reader.Read(p)
+	r.size += int64(n)
+	if r.size > r.limit {
+		r.exceeded = true
+		return n, ErrUnsupportedFileSize
+	}
+	return
+}
+
+// fileMagic 文件魔数
+type fileMagic struct {
+	offset int                         // 魔数在文件中的偏移量
+	magic  []byte                      // 魔数
+	check  func(head []byte) (ok bool) // 魔数匹配后的附加校验，用于过滤过短的魔数造成的误判，可为空
+	exts   []string                    // 魔数对应的文件扩展名
+}
+
+// fileMagics 常见文件类型的魔数，docx、xlsx、pptx 等 Office Open XML 文件本质是 zip，doc、xls、ppt 本质是 OLE2 复合文档
+var fileMagics = []fileMagic{
+	{magic: []byte{0xFF, 0xD8, 0xFF}, exts: []string{"jpg", "jpeg"}},
+	{magic: []byte("\x89PNG\r\n\x1a\n"), exts: []string{"png"}},
+	{magic: []byte("GIF87a"), exts: []string{"gif"}},
+	{magic: []byte("GIF89a"), exts: []string{"gif"}},
+	{magic: []byte("BM"), check: isBMPHeader, exts: []string{"bmp"}},
+	{offset: 8, magic: []byte("WEBP"), exts: []string{"webp"}},
+	{magic: []byte("%PDF-"), exts: []string{"pdf"}},
+	{magic: []byte("PK\x03\x04"), exts: []string{"zip", "docx", "xlsx", "pptx"}},
+	{magic: []byte("PK\x05\x06"), exts: []string{"zip", "docx", "xlsx", "pptx"}}, // 空 zip
+	{magic: []byte("PK\x07\x08"), exts: []string{"zip"}},                         // 分卷 zip
+	{magic: []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}, exts: []string{"doc", "xls", "ppt"}},
+	{magic: []byte{0x1F, 0x8B}, exts: []string{"gz", "tgz"}},
+	{magic: []byte("Rar!\x1a\x07"), exts: []string{"rar"}},
+	{magic: []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}, exts: []string{"7z"}},
+	{magic: []byte("ID3"), check: isID3Header, exts: []string{"mp3"}},
+	{magic: []byte{0xFF, 0xFB}, exts: []string{"mp3"}}, // 没有 ID3v2 标签的 mp3 以帧同步字节开头
+	{magic: []byte{0xFF, 0xF3}, exts: []string{"mp3"}},
+	{magic: []byte{0xFF, 0xF2}, exts: []string{"mp3"}},
+	{offset: 4, magic: []byte("ftyp"), exts: []string{"mp4", "m4a", "m4v", "mov"}},
+	{offset: 4, magic: []byte("moov"), check: isQuickTimeAtom, exts: []string{"mov", "mp4", "m4a", "m4v"}}, // 没有 ftyp 的 QuickTime 文件以其他 atom 开头
+	{offset: 4, magic: []byte("mdat"), check: isQuickTimeAtom, exts: []string{"mov", "mp4", "m4a", "m4v"}},
+	{offset: 4, magic: []byte("wide"), check: isQuickTimeAtom, exts: []string{"mov"}},
+	{offset: 4, magic: []byte("free"), check: isQuickTimeAtom, exts: []string{"mov", "mp4", "m4a", "m4v"}},
+	{offset: 4, magic: []byte("skip"), check: isQuickTimeAtom, exts: []string{"mov", "mp4", "m4a", "m4v"}},
+	{offset: 4, magic: []byte("pnot"), check: isQuickTimeAtom, exts: []string{"mov"}},
+}
+
+// isBMPHeader 判断是否是 BMP 文件头，"BM" 之后的保留字段为 0，偏移 14 处为已知长度的 DIB 头
+func isBMPHeader(head []byte) (ok bool) {
+	if len(head) < 18 || binary.LittleEndian.Uint32(head[6:10]) != 0 {
+		return false
+	}
+	switch binary.LittleEndian.Uint32(head[14:18]) {
+	case 12, 40, 52, 56, 64, 108, 124:
+		return true
+	}
+	return false
+}
+
+// isID3Header 判断是否是 ID3v2 标签头，"ID3" 之后为主版本号 2-4，标签长度的每个字节最高位为 0
+func isID3Header(head []byte) (ok bool) {
+	if len(head) < 10 || head[3] < 2 || head[3] > 4 {
+		return false
+	}
+	return head[6]|head[7]|head[8]|head[9] < 0x80
+}
+
+// isQuickTimeAtom 判断文件开头是否是 QuickTime atom，atom 长度不超过 16MB，且为 1 时表示使用 64 位长度
+//
+//	文本文件开头的 4 个字节按长度解析通常超过 16MB，可以避免如 "I'm free" 被误判
+func isQuickTimeAtom(head []byte) (ok bool) {
+	size := binary.BigEndian.Uint32(head[:4])
+	return size == 1 || (size >= 8 && size < 1<<24)
+}
+
+// match 判断文件头部是否与魔数匹配
+func (m fileMagic) match(head []byte) (ok bool) {
+	if len(head) < m.offset+len(m.magic) || !bytes.Equal(head[m.offset:m.offset+len(m.magic)], m.magic) {
+		return false
+	}
+	return m.check == nil || m.check(head)
+}
+
+// checkFileContent 根据文件头部的魔数判断文件内容是否与扩展名一致，返回嗅探到的文件类型
+//
+//	只有识别出的魔数明确属于其他文件类型时才视为不一致，无法识别魔数时（如文本文件或未收录的格式变体）视为一致
+func checkFileContent(fileName string, head []byte) (fileType string, ok bool) {
+	fileType = http.DetectContentType(head)
+	ext := strings.ToLower(utils.ExtName(fileName))
+	matched := false
+	for _, m := range fileMagics {
+		if !m.match(head) {
+			continue
+		}
+		// 不同文件类型的魔数可能相同，任一匹配的魔数对应该扩展名即视为一致
+		if slices.Contains(m.exts, ext) {
+			return fileType, true
+		}
+		matched = true
+	}
+	return fileType, !matched
+}
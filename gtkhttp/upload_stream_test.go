@@ -0,0 +1,164 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-21 15:46:20
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-21 15:46:20
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkhttp_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// newMultipartRequest 构建 multipart 上传请求，files 的 key 为文件名，value 为文件内容
+func newMultipartRequest(files ...[2][]byte) (r *http.Request) {
+	var (
+		body   bytes.Buffer
+		writer = multipart.NewWriter(&body)
+	)
+	writer.WriteField("name", "test")
+	for _, file := range files {
+		part, _ := writer.CreateFormFile("file", string(file[0]))
+		part.Write(file[1])
+	}
+	writer.Close()
+	r = httptest.NewRequest(http.MethodPost, "/upload", &body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return
+}
+
+func TestStreamUpload(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		dirPath = t.TempDir()
+		service = gtkhttp.NewUploadFileService(gtkhttp.UploadFileConfig{
+			AllowTypeList: []string{"png", "txt", "docx"},
+			MaxCount:      3,
+		})
+		png   = append(bytes.Clone(pngHeader), bytes.Repeat([]byte{0}, 100)...)
+		large = append(bytes.Clone(pngHeader), bytes.Repeat([]byte{0}, 1024*1024)...)
+	)
+
+	fileInfos := service.StreamUpload(newMultipartRequest(
+		[2][]byte{[]byte("a.png"), png},
+		[2][]byte{[]byte("b.txt"), []byte("hello world")},
+		[2][]byte{[]byte("fake.png"), []byte("%PDF-1.4 not a png")},
+		[2][]byte{[]byte("c.png"), large},
+	), dirPath, gtkhttp.NewLocalDirSink())
+	if assert.Len(fileInfos, 4) {
+		// 上传成功
+		assert.NoError(fileInfos[0].GetErr())
+		assert.Equal(&gtkhttp.UploadFileInfo{FileName: "a.png", FileSize: int64(len(png)), FilePath: filepath.Join(dirPath, "a.png"), FileType: "image/png"}, fileInfos[0])
+		data, _ := os.ReadFile(filepath.Join(dirPath, "a.png"))
+		assert.Equal(png, data)
+		assert.NoError(fileInfos[1].GetErr())
+		assert.Equal("text/plain; charset=utf-8", fileInfos[1].FileType)
+		// 文件内容与扩展名不一致
+		assert.ErrorIs(fileInfos[2].GetErr(), gtkhttp.ErrUnsupportedFileType)
+		assert.NoFileExists(filepath.Join(dirPath, "fake.png"))
+		// 超出最大数量
+		assert.ErrorContains(fileInfos[3].GetErr(), "maximum of 3 files")
+	}
+
+	// 文件大小超出限制，不保留已写入的数据
+	fileInfos = service.StreamUpload(newMultipartRequest([2][]byte{[]byte("c.png"), large}), dirPath, gtkhttp.NewLocalDirSink())
+	if assert.Len(fileInfos, 1) {
+		assert.ErrorIs(fileInfos[0].GetErr(), gtkhttp.ErrUnsupportedFileSize)
+		assert.Equal(int64(1024*1024+1), fileInfos[0].FileSize)
+	}
+	entries, _ := os.ReadDir(dirPath)
+	assert.Len(entries, 2)
+}
+
+func TestStreamUploadFileContent(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		service = gtkhttp.NewUploadFileService(gtkhttp.UploadFileConfig{
+			AllowTypeList: []string{"png", "bmp", "mp3", "zip", "mov", "txt", "csv"},
+			MaxCount:      20,
+		})
+		bmp = append([]byte("BM\x46\x00\x00\x00\x00\x00\x00\x00\x36\x00\x00\x00\x28\x00\x00\x00"), bytes.Repeat([]byte{0}, 32)...)
+	)
+	fileInfos := service.StreamUpload(newMultipartRequest(
+		// 魔数与扩展名一致
+		[2][]byte{[]byte("a.bmp"), bmp},
+		[2][]byte{[]byte("a.mp3"), []byte("ID3\x04\x00\x00\x00\x00\x00\x0a frames")},
+		[2][]byte{[]byte("b.mp3"), []byte("\xff\xfb\x90\x64 frame")},
+		[2][]byte{[]byte("c.mp3"), []byte("\xff\xf3\x90\x64 frame")},
+		[2][]byte{[]byte("empty.zip"), append([]byte("PK\x05\x06"), bytes.Repeat([]byte{0}, 18)...)},
+		[2][]byte{[]byte("spanned.zip"), []byte("PK\x07\x08PK\x03\x04")},
+		[2][]byte{[]byte("a.mov"), []byte("\x00\x00\x00\x08wide\x00\x00\x10\x00mdat")},
+		[2][]byte{[]byte("b.mov"), []byte("\x00\x00\x00\x6cmoov\x00\x00\x00\x6cmvhd")},
+		// 文本文件的开头与过短的魔数相同
+		[2][]byte{[]byte("a.txt"), []byte("BMW,Audi\n")},
+		[2][]byte{[]byte("a.csv"), []byte("BM,120\n")},
+		[2][]byte{[]byte("b.txt"), []byte("I'm free\n")},
+		[2][]byte{[]byte("c.txt"), []byte("ID3 tags\n")},
+		// 无法识别魔数的文件不视为不一致
+		[2][]byte{[]byte("unknown.png"), []byte("\x00\x01 png variant")},
+		// 魔数明确属于其他文件类型
+		[2][]byte{[]byte("fake.mp3"), bmp},
+		[2][]byte{[]byte("fake.txt"), []byte("\xff\xfb\x90\x64 frame")},
+		[2][]byte{[]byte("fake.mov"), []byte("PK\x05\x06")},
+	), t.TempDir(), gtkhttp.NewLocalDirSink())
+	if assert.Len(fileInfos, 16) {
+		for _, fileInfo := range fileInfos[:13] {
+			assert.NoError(fileInfo.GetErr(), fileInfo.FileName)
+		}
+		for _, fileInfo := range fileInfos[13:] {
+			assert.ErrorIs(fileInfo.GetErr(), gtkhttp.ErrUnsupportedFileType, fileInfo.FileName)
+		}
+	}
+}
+
+func TestStreamUploadSinks(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		service = gtkhttp.NewUploadFileService(gtkhttp.UploadFileConfig{}, gtkhttp.WithUploadFileNameFn(func(filename string) (newFilename string) {
+			return "new_" + filename
+		}))
+		docx = append([]byte("PK\x03\x04"), bytes.Repeat([]byte{1}, 64)...)
+		buf  bytes.Buffer
+	)
+	// io.Writer
+	fileInfos := service.StreamUpload(newMultipartRequest([2][]byte{[]byte("a.docx"), docx}), "files", gtkhttp.NewWriterSink(&buf))
+	if assert.Len(fileInfos, 1) {
+		assert.NoError(fileInfos[0].GetErr())
+		assert.Equal("files/new_a.docx", fileInfos[0].FilePath)
+		assert.Equal(docx, buf.Bytes())
+	}
+	// 自定义写入目标
+	fileInfos = service.StreamUpload(newMultipartRequest([2][]byte{[]byte("b.pdf"), []byte("%PDF-1.7")}), "files", gtkhttp.UploadSinkFunc(func(ctx context.Context, dirPath, fileName string, reader io.Reader) (filePath string, err error) {
+		_, err = io.Copy(io.Discard, reader)
+		return "oss://" + dirPath + "/" + fileName, err
+	}))
+	if assert.Len(fileInfos, 1) {
+		assert.Equal("oss://files/new_b.pdf", fileInfos[0].FilePath)
+		assert.Equal("application/pdf", fileInfos[0].FileType)
+	}
+	// 缺少文件
+	fileInfos = service.StreamUpload(newMultipartRequest(), "files", gtkhttp.NewWriterSink(&buf))
+	if assert.Len(fileInfos, 1) {
+		assert.ErrorIs(fileInfos[0].GetErr(), gtkhttp.ErrMissingFile)
+	}
+	// 不支持的请求方法
+	fileInfos = service.StreamUpload(httptest.NewRequest(http.MethodGet, "/upload", nil), "files", gtkhttp.NewWriterSink(&buf))
+	if assert.Len(fileInfos, 1) {
+		assert.ErrorIs(fileInfos[0].GetErr(), gtkhttp.ErrUnsupportedMethod)
+	}
+}
@@ -14,9 +14,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
 	"github.com/liusuxian/go-toolkit/gtkjson"
 	"github.com/liusuxian/go-toolkit/gtklog"
 	"github.com/liusuxian/go-toolkit/gtkretry"
+	"github.com/liusuxian/go-toolkit/gtktrace"
+	"github.com/liusuxian/go-toolkit/internal/mqstats"
 	"hash/fnv"
 	"math"
 	"slices"
@@ -53,9 +56,10 @@ type TopicConfig struct {
 
 // ProducerMessage 生产者消息
 type ProducerMessage struct {
-	Key       string `json:"key,omitempty"` // 键
-	Data      any    `json:"data"`          // 数据
-	dataBytes []byte // 数据字节数组
+	Key       string            `json:"key,omitempty"`     // 键
+	Data      any               `json:"data"`              // 数据
+	Headers   map[string]string `json:"headers,omitempty"` // 消息头，开启链路追踪时会自动注入 traceparent
+	dataBytes []byte            // 数据字节数组
 }
 
 // Config kafka 客户端配置
@@ -86,8 +90,28 @@ type Config struct {
 type KafkaClient struct {
 	producerMap map[string]*kafka.Producer
 	consumerMap map[string][]*kafka.Consumer
-	config      *Config        // kafka 客户端配置
-	logger      gtklog.ILogger // 日志接口
+	config      *Config         // kafka 客户端配置
+	logger      gtklog.ILogger  // 日志接口
+	tracer      gtktrace.Tracer // 追踪器
+	stats       mqstats.Counter // 统计计数器
+}
+
+// Stats kafka 客户端统计数据，可以通过 NewStatsCollector 注册到 gtkhttp.MetricsRegistry 中输出
+type Stats struct {
+	SentMessages          uint64 `json:"sent_messages"`           // 发送成功的消息数
+	SendFailedMessages    uint64 `json:"send_failed_messages"`    // 发送失败的消息数
+	ConsumedMessages      uint64 `json:"consumed_messages"`       // 消费成功的消息数
+	ConsumeFailedMessages uint64 `json:"consume_failed_messages"` // 消费失败（重试结束后仍失败）的消息数
+}
+
+// NewStatsCollector 创建 kafka 客户端统计数据的指标采集器，可以注册到 gtkhttp.MetricsRegistry 中输出
+//
+//	输出 gtkkafka_sent_messages_total、gtkkafka_send_failed_messages_total、gtkkafka_consumed_messages_total、gtkkafka_consume_failed_messages_total 计数器
+//	labels 为附加到每个指标的标签，注册多个客户端时用于区分，如 {"client": "order"}，可以为空
+func NewStatsCollector(client *KafkaClient, labels map[string]string) (collector gtkhttp.PrometheusCollector) {
+	return gtkhttp.CollectorFunc(func() (families []*gtkhttp.MetricFamily) {
+		return mqstats.Families("gtkkafka", labels, client.stats.Snapshot())
+	})
 }
 
 const (
@@ -104,7 +128,7 @@ func NewClient(cfg *Config) (client *KafkaClient, err error) {
 		producerMap: make(map[string]*kafka.Producer),
 		consumerMap: make(map[string][]*kafka.Consumer),
 		config:      cfg,
-		logger:      gtklog.NewDefaultLogger(gtklog.TraceLevel),
+		logger:      gtklog.NamedLogger(gtklog.NewDefaultLogger(gtklog.TraceLevel), "gtkkafka"),
 	}
 	// SSL接入点的IP地址以及端口
 	if client.config.BootstrapServers == "" {
@@ -180,9 +204,20 @@ func NewClient(cfg *Config) (client *KafkaClient, err error) {
 	return
 }
 
-// SetLogger 设置日志对象
+// SetLogger 设置日志对象，logger 支持 Named 时使用名为 gtkkafka 的子日志器，可以单独调整日志级别
 func (kc *KafkaClient) SetLogger(logger gtklog.ILogger) {
-	kc.logger = logger
+	kc.logger = gtklog.NamedLogger(logger, "gtkkafka")
+}
+
+// Stats 获取统计数据
+func (kc *KafkaClient) Stats() (stats Stats) {
+	s := kc.stats.Snapshot()
+	return Stats{
+		SentMessages:          s.Sent,
+		SendFailedMessages:    s.SendFailed,
+		ConsumedMessages:      s.Consumed,
+		ConsumeFailedMessages: s.ConsumeFailed,
+	}
 }
 
 // PrintClientConfig 打印消息队列客户端配置
@@ -538,15 +573,25 @@ func (kc *KafkaClient) handelData(ctx context.Context, topicConfig *TopicConfig,
 		}
 		return true
 	}
+	// 创建消费者跨度，通过 MessageContext 获取消息的上下文
+	var (
+		handleErr error
+		span      gtktrace.Span
+	)
+	ctx, span = kc.startConsumerSpan(ctx, *lastMessage.TopicPartition.Topic, msgList)
+	defer func() {
+		kc.stats.RecordConsume(msgSize, handleErr)
+		gtktrace.EndSpan(span, handleErr)
+	}()
 	// 创建重试实例，并且立即执行重试
-	if err := gtkretry.NewRetry(retryConfig).Do(ctx, func(ctx context.Context) error {
+	if handleErr = gtkretry.NewRetry(retryConfig).Do(ctx, func(ctx context.Context) error {
 		// 发送心跳
 		consumer.Poll(0)
 		// 执行业务函数
 		return fn(msgList)
-	}); err != nil {
+	}); handleErr != nil {
 		kc.logger.Errorf(ctx, "handelData finished, consumer: %s %v, error: %+v, topic: %v, partition: %v, offset: %v, key: %s, content: %s, timestamp: %v", consumerName, consumer,
-			err, *lastMessage.TopicPartition.Topic, lastMessage.TopicPartition.Partition, lastMessage.TopicPartition.Offset, string(lastMessage.Key), string(lastMessage.Value), lastMessage.Timestamp)
+			handleErr, *lastMessage.TopicPartition.Topic, lastMessage.TopicPartition.Partition, lastMessage.TopicPartition.Offset, string(lastMessage.Key), string(lastMessage.Value), lastMessage.Timestamp)
 		// 检查是否是因为 context 被取消（退出信号）
 		if ctx.Err() != nil {
 			return
@@ -593,6 +638,21 @@ func (kc *KafkaClient) sendMessage(ctx context.Context, topic string, producerMe
 		}
 		producerName = kc.getProducerName(topic)
 	)
+	for k, v := range producerMessage.Headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	// 创建生产者跨度，并将追踪信息注入消息头
+	var (
+		span    gtktrace.Span
+		skipped bool // 未实际发送的消息不计入统计
+	)
+	ctx, span = kc.startProducerSpan(ctx, fullTopicName, msg)
+	defer func() {
+		if !skipped {
+			kc.stats.RecordSend(err)
+		}
+		gtktrace.EndSpan(span, err)
+	}()
 	// 判断是否配置了全局生产者名称
 	globalProducerName := strings.Trim(kc.config.GlobalProducer, " ")
 	if globalProducerName != "" {
@@ -600,11 +660,13 @@ func (kc *KafkaClient) sendMessage(ctx context.Context, topic string, producerMe
 	}
 	if kc.config.IsClose {
 		kc.logger.Debugf(ctx, "producer: %s, send message(is closed): %s, data: %s", producerName, gtkjson.MustString(msg), string(producerMessage.dataBytes))
+		skipped = true
 		return
 	}
 	// 检测哪些 topic 不发送 Kafka 消息
 	if slices.Contains(kc.config.ExcludeTopics, topic) {
 		kc.logger.Debugf(ctx, "producer: %s, send message(exclude topic): %s, data: %s", producerName, gtkjson.MustString(msg), string(producerMessage.dataBytes))
+		skipped = true
 		return
 	}
 	// 发送消息
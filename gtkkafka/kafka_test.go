@@ -10,10 +10,13 @@
 package gtkkafka_test
 
 import (
+	"bytes"
 	"context"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/liusuxian/go-toolkit/gtkconf"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
 	"github.com/liusuxian/go-toolkit/gtkkafka"
+	"github.com/liusuxian/go-toolkit/gtklog"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -27,6 +30,45 @@ type TestRecordInfo struct {
 	CreatedAt string `json:"created_at,omitempty" dc:"created_at"`
 }
 
+func TestNewClientLogger(t *testing.T) {
+	assert := assert.New(t)
+	_, err := gtkkafka.NewClient(&gtkkafka.Config{})
+	assert.NoError(err)
+	// 默认日志器为名为 gtkkafka 的具名日志器，不调用 SetLogger 也可以单独调整日志级别
+	_, ok := gtklog.GetLevel("gtkkafka")
+	assert.True(ok)
+}
+
+func TestStatsCollector(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		assert   = assert.New(t)
+		registry = gtkhttp.NewMetricsRegistry()
+	)
+	client, err := gtkkafka.NewClient(&gtkkafka.Config{
+		ExcludeTopics: []string{"excluded"},
+		TopicConfig: map[string]gtkkafka.TopicConfig{
+			"stats":    {PartitionNum: 1, Mode: gtkkafka.ModeProducer},
+			"excluded": {PartitionNum: 1, Mode: gtkkafka.ModeProducer},
+		},
+	})
+	if !assert.NoError(err) {
+		return
+	}
+	// 未创建生产者时发送失败，排除的 topic 不计入统计
+	assert.Error(client.SendMessage(ctx, "stats", &gtkkafka.ProducerMessage{Data: "a"}))
+	assert.NoError(client.SendMessage(ctx, "excluded", &gtkkafka.ProducerMessage{Data: "a"}))
+	assert.Equal(gtkkafka.Stats{SendFailedMessages: 1}, client.Stats())
+
+	registry.Register(gtkkafka.NewStatsCollector(client, nil))
+	var buf bytes.Buffer
+	_, err = registry.WriteTo(&buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "# TYPE gtkkafka_send_failed_messages_total counter\n")
+	assert.Contains(buf.String(), "gtkkafka_send_failed_messages_total 1\n")
+	assert.Contains(buf.String(), "gtkkafka_sent_messages_total 0\n")
+}
+
 func TestNewClient(t *testing.T) {
 	var (
 		ctx         = context.Background()
@@ -0,0 +1,110 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 11:28:47
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 11:28:47
+ * @Description: kafka 链路追踪
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkkafka
+
+import (
+	"context"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/liusuxian/go-toolkit/gtktrace"
+	"strings"
+)
+
+// MessageCarrier 基于 kafka 消息头的追踪信息载体
+type MessageCarrier struct {
+	msg *kafka.Message
+}
+
+// NewMessageCarrier 创建基于 kafka 消息头的追踪信息载体
+func NewMessageCarrier(msg *kafka.Message) (carrier *MessageCarrier) {
+	return &MessageCarrier{msg: msg}
+}
+
+// Get 获取消息头的值，键不区分大小写
+func (c *MessageCarrier) Get(key string) (value string) {
+	for _, header := range c.msg.Headers {
+		if strings.EqualFold(header.Key, key) {
+			return string(header.Value)
+		}
+	}
+	return
+}
+
+// Set 设置消息头的值，已存在时覆盖
+func (c *MessageCarrier) Set(key, value string) {
+	for i, header := range c.msg.Headers {
+		if strings.EqualFold(header.Key, key) {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// MessageContext 获取消息的上下文，消费时包含消费者跨度，业务代码可以使用它创建子跨度或继续传递追踪信息
+func MessageContext(msg *kafka.Message) (ctx context.Context) {
+	if ctx, ok := msg.Opaque.(context.Context); ok {
+		return ctx
+	}
+	return gtktrace.Extract(context.Background(), NewMessageCarrier(msg))
+}
+
+// SetTracer 设置追踪器，未设置时使用 gtktrace.GetTracer()
+func (kc *KafkaClient) SetTracer(tracer gtktrace.Tracer) {
+	kc.tracer = tracer
+}
+
+// getTracer 获取追踪器
+func (kc *KafkaClient) getTracer() (tracer gtktrace.Tracer) {
+	if kc.tracer != nil {
+		return kc.tracer
+	}
+	return gtktrace.GetTracer()
+}
+
+// startProducerSpan 创建生产者跨度，并将追踪信息注入消息头
+func (kc *KafkaClient) startProducerSpan(ctx context.Context, topic string, msg *kafka.Message) (newCtx context.Context, span gtktrace.Span) {
+	newCtx, span = kc.getTracer().Start(ctx, topic+" send",
+		gtktrace.WithSpanKind(gtktrace.SpanKindProducer),
+		gtktrace.WithAttributes(
+			gtktrace.Attr("messaging.system", "kafka"),
+			gtktrace.Attr("messaging.destination.name", topic),
+			gtktrace.Attr("messaging.operation", "send"),
+			gtktrace.Attr("messaging.destination.partition.id", msg.TopicPartition.Partition),
+		))
+	gtktrace.Inject(newCtx, NewMessageCarrier(msg))
+	return
+}
+
+// startConsumerSpan 创建消费者跨度，单条消息时以消息头中的追踪信息作为父跨度，批量消息时关联每条消息的追踪信息
+//
+//	跨度上下文保存在消息的 Opaque 中，通过 MessageContext 获取
+func (kc *KafkaClient) startConsumerSpan(ctx context.Context, topic string, msgList []*kafka.Message) (newCtx context.Context, span gtktrace.Span) {
+	opts := []gtktrace.StartOption{
+		gtktrace.WithSpanKind(gtktrace.SpanKindConsumer),
+		gtktrace.WithAttributes(
+			gtktrace.Attr("messaging.system", "kafka"),
+			gtktrace.Attr("messaging.destination.name", topic),
+			gtktrace.Attr("messaging.operation", "process"),
+			gtktrace.Attr("messaging.batch.message_count", len(msgList)),
+		),
+	}
+	if len(msgList) == 1 {
+		ctx = gtktrace.Extract(ctx, NewMessageCarrier(msgList[0]))
+	} else {
+		for _, msg := range msgList {
+			opts = append(opts, gtktrace.WithLinks(gtktrace.SpanContextFromContext(MessageContext(msg))))
+		}
+	}
+	newCtx, span = kc.getTracer().Start(ctx, topic+" process", opts...)
+	for _, msg := range msgList {
+		msg.Opaque = newCtx
+	}
+	return
+}
@@ -0,0 +1,40 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 11:28:47
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 11:28:47
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkkafka_test
+
+import (
+	"context"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/liusuxian/go-toolkit/gtkkafka"
+	"github.com/liusuxian/go-toolkit/gtktrace"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMessageCarrier(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		msg    = &kafka.Message{Headers: []kafka.Header{{Key: "Traceparent", Value: []byte("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")}}}
+	)
+	// 从消息头中解析追踪信息
+	sc := gtktrace.SpanContextFromContext(gtkkafka.MessageContext(msg))
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal("00f067aa0ba902b7", sc.SpanID.String())
+	// 注入时覆盖已存在的消息头
+	ctx, span := gtktrace.NewRecordingTracer(gtktrace.NoopExporter{}).Start(gtkkafka.MessageContext(msg), "child")
+	gtktrace.Inject(ctx, gtkkafka.NewMessageCarrier(msg))
+	if assert.Len(msg.Headers, 1) {
+		assert.Equal(gtktrace.FormatTraceparent(span.SpanContext()), string(msg.Headers[0].Value))
+	}
+	// 消费时保存在 Opaque 中的上下文优先
+	msg.Opaque = ctx
+	assert.Equal(ctx, gtkkafka.MessageContext(msg))
+	assert.Equal(context.Background(), gtkkafka.MessageContext(&kafka.Message{}))
+}
@@ -0,0 +1,458 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-25 15:21:38
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-25 15:21:38
+ * @Description: 错误日志告警
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Alert 告警
+type Alert struct {
+	Time    time.Time // 首次出现的时间
+	Level   Level     // 日志级别
+	Caller  string    // 调用者，格式为 file:line
+	Message string    // 日志内容
+	Fields  []Field   // 日志字段，包括上下文中的请求ID等字段
+	Count   int       // 出现次数，包括合并窗口内重复的次数和上一个去重窗口内被抑制的次数
+}
+
+// AlertBatch 一批告警，合并窗口内的告警合并为一条消息发送
+type AlertBatch struct {
+	Service string   // 服务名称
+	Env     string   // 运行环境
+	Host    string   // 主机名
+	Alerts  []*Alert // 告警列表
+	Omitted int      // 超过单条消息告警数上限或被限流而未发送的告警数
+}
+
+// Alerter 告警发送接口，gtkrobot.FeishuRobot 实现了该接口
+type Alerter interface {
+	SendAlerts(ctx context.Context, batch *AlertBatch) (err error) // 发送一批告警
+}
+
+// AlertConfig 告警配置
+type AlertConfig struct {
+	Service       string          // 服务名称
+	Env           string          // 运行环境，如 prod
+	DedupWindow   time.Duration   // 去重窗口，窗口内相同的告警（级别、调用者、内容相同）只发送一次，默认 1 分钟
+	BatchInterval time.Duration   // 合并窗口，首条告警出现后等待该时长再发送，期间的告警合并为一条消息，默认 5 秒
+	MaxBatchSize  int             // 每条消息最多包含的告警数，默认 20
+	RateLimit     int             // 每分钟最多发送的消息数，默认 10，致命和恐慌日志不受限制
+	SendTimeout   time.Duration   // 发送超时，默认 10 秒
+	OnError       func(err error) // 发送失败的回调
+}
+
+// alertCore 告警状态，同一个 AlertLogger 派生的子日志器共用
+type alertCore struct {
+	alerter    Alerter
+	config     AlertConfig
+	host       string
+	mu         sync.Mutex
+	pending    []*Alert             // 待发送的告警
+	pendingIdx map[string]*Alert    // 待发送的告警索引
+	lastSeen   map[string]time.Time // 告警最近一次进入待发送列表的时间
+	suppressed map[string]*Alert    // 去重窗口内被抑制的告警，Count 为被抑制的次数
+	omitted    int                  // 未发送的告警数
+	sendTimes  []time.Time          // 最近一分钟的发送时间
+	timer      *time.Timer          // 发送定时器，合并窗口或去重窗口结束时触发
+	timerAt    time.Time            // 发送定时器的触发时间
+	closed     bool
+	sendMu     sync.Mutex // 保证告警按顺序发送
+}
+
+// AlertLogger 告警日志器，包装 ILogger，在正常输出日志的同时将错误、致命、恐慌日志发送到 Alerter
+//
+//	相同的告警在去重窗口内只发送一次，合并窗口内的告警合并为一条消息，超过发送频率限制的告警被丢弃
+//	致命和恐慌日志在退出或 panic 前立即发送
+//	如：logger := gtklog.NewAlertLogger(gtklog.NewDefaultLogger(gtklog.InfoLevel), gtkrobot.NewFeishuRobot(url), gtklog.AlertConfig{Env: "prod"})
+type AlertLogger struct {
+	next   ILogger    // 被包装的日志器
+	core   *alertCore // 告警状态
+	fields []Field    // With 附加的字段
+}
+
+// NewAlertLogger 创建告警日志器
+func NewAlertLogger(next ILogger, alerter Alerter, config AlertConfig) (a *AlertLogger) {
+	if config.DedupWindow <= 0 {
+		config.DedupWindow = time.Minute
+	}
+	if config.BatchInterval <= 0 {
+		config.BatchInterval = 5 * time.Second
+	}
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = 20
+	}
+	if config.RateLimit <= 0 {
+		config.RateLimit = 10
+	}
+	if config.SendTimeout <= 0 {
+		config.SendTimeout = 10 * time.Second
+	}
+	host, _ := os.Hostname()
+	return &AlertLogger{
+		next: next,
+		core: &alertCore{
+			alerter:    alerter,
+			config:     config,
+			host:       host,
+			pendingIdx: make(map[string]*Alert),
+			lastSeen:   make(map[string]time.Time),
+			suppressed: make(map[string]*Alert),
+		},
+	}
+}
+
+// Flush 立即发送待发送的告警
+func (a *AlertLogger) Flush() (err error) {
+	return a.core.flush(false)
+}
+
+// Close 发送待发送的告警和去重窗口内被抑制的告警，之后不再发送新的告警
+func (a *AlertLogger) Close() (err error) {
+	a.core.mu.Lock()
+	a.core.closed = true
+	a.core.mu.Unlock()
+	return a.core.flush(false)
+}
+
+// Enabled 是否输出指定级别的日志，被包装的日志器不支持时返回 true
+func (a *AlertLogger) Enabled(level Level) (ok bool) {
+	if l, ok := a.next.(interface{ Enabled(level Level) bool }); ok {
+		return l.Enabled(level)
+	}
+	return true
+}
+
+// With 创建附加了字段的子日志器，字段同时附加到告警中
+func (a *AlertLogger) With(args ...any) (logger IStructuredLogger) {
+	child := *a
+	if sl, ok := a.next.(IStructuredLogger); ok {
+		child.next = sl.With(args...)
+	}
+	child.fields = append(slices.Clip(a.fields), argsToFields(args)...)
+	return &child
+}
+
+// Named 创建具名子日志器，被包装的日志器不支持 Named 时不改变名称
+func (a *AlertLogger) Named(name string) (logger IStructuredLogger) {
+	child := *a
+	child.next = NamedLogger(a.next, name)
+	return &child
+}
+
+// Debug 调试日志
+func (a *AlertLogger) Debug(ctx context.Context, args ...any) {
+	if a.Enabled(DebugLevel) {
+		a.output(ctx, DebugLevel, fileInfo(2), fmt.Sprint(args...), nil)
+	}
+}
+
+// Debugf 调试日志
+func (a *AlertLogger) Debugf(ctx context.Context, format string, args ...any) {
+	if a.Enabled(DebugLevel) {
+		a.output(ctx, DebugLevel, fileInfo(2), fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Info 信息日志
+func (a *AlertLogger) Info(ctx context.Context, args ...any) {
+	if a.Enabled(InfoLevel) {
+		a.output(ctx, InfoLevel, fileInfo(2), fmt.Sprint(args...), nil)
+	}
+}
+
+// Infof 信息日志
+func (a *AlertLogger) Infof(ctx context.Context, format string, args ...any) {
+	if a.Enabled(InfoLevel) {
+		a.output(ctx, InfoLevel, fileInfo(2), fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Warn 警告日志
+func (a *AlertLogger) Warn(ctx context.Context, args ...any) {
+	if a.Enabled(WarnLevel) {
+		a.output(ctx, WarnLevel, fileInfo(2), fmt.Sprint(args...), nil)
+	}
+}
+
+// Warnf 警告日志
+func (a *AlertLogger) Warnf(ctx context.Context, format string, args ...any) {
+	if a.Enabled(WarnLevel) {
+		a.output(ctx, WarnLevel, fileInfo(2), fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Error 错误日志，同时发送告警
+func (a *AlertLogger) Error(ctx context.Context, args ...any) {
+	a.output(ctx, ErrorLevel, fileInfo(2), fmt.Sprint(args...), nil)
+}
+
+// Errorf 错误日志，同时发送告警
+func (a *AlertLogger) Errorf(ctx context.Context, format string, args ...any) {
+	a.output(ctx, ErrorLevel, fileInfo(2), fmt.Sprintf(format, args...), nil)
+}
+
+// Trace 跟踪日志
+func (a *AlertLogger) Trace(ctx context.Context, args ...any) {
+	if a.Enabled(TraceLevel) {
+		a.output(ctx, TraceLevel, fileInfo(2), fmt.Sprint(args...), nil)
+	}
+}
+
+// Tracef 跟踪日志
+func (a *AlertLogger) Tracef(ctx context.Context, format string, args ...any) {
+	if a.Enabled(TraceLevel) {
+		a.output(ctx, TraceLevel, fileInfo(2), fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Fatal 致命日志，立即发送告警
+func (a *AlertLogger) Fatal(ctx context.Context, args ...any) {
+	a.output(ctx, FatalLevel, fileInfo(2), fmt.Sprint(args...), nil)
+}
+
+// Fatalf 致命日志，立即发送告警
+func (a *AlertLogger) Fatalf(ctx context.Context, format string, args ...any) {
+	a.output(ctx, FatalLevel, fileInfo(2), fmt.Sprintf(format, args...), nil)
+}
+
+// Panic 恐慌日志，立即发送告警
+func (a *AlertLogger) Panic(ctx context.Context, args ...any) {
+	a.output(ctx, PanicLevel, fileInfo(2), fmt.Sprint(args...), nil)
+}
+
+// Panicf 恐慌日志，立即发送告警
+func (a *AlertLogger) Panicf(ctx context.Context, format string, args ...any) {
+	a.output(ctx, PanicLevel, fileInfo(2), fmt.Sprintf(format, args...), nil)
+}
+
+// DebugKV 调试日志，args 为键值对
+func (a *AlertLogger) DebugKV(ctx context.Context, msg string, args ...any) {
+	if a.Enabled(DebugLevel) {
+		a.output(ctx, DebugLevel, fileInfo(2), msg, args)
+	}
+}
+
+// InfoKV 信息日志，args 为键值对
+func (a *AlertLogger) InfoKV(ctx context.Context, msg string, args ...any) {
+	if a.Enabled(InfoLevel) {
+		a.output(ctx, InfoLevel, fileInfo(2), msg, args)
+	}
+}
+
+// WarnKV 警告日志，args 为键值对
+func (a *AlertLogger) WarnKV(ctx context.Context, msg string, args ...any) {
+	if a.Enabled(WarnLevel) {
+		a.output(ctx, WarnLevel, fileInfo(2), msg, args)
+	}
+}
+
+// ErrorKV 错误日志，args 为键值对，同时发送告警
+func (a *AlertLogger) ErrorKV(ctx context.Context, msg string, args ...any) {
+	a.output(ctx, ErrorLevel, fileInfo(2), msg, args)
+}
+
+// output 记录告警并输出日志，致命和恐慌日志先发送告警，再退出或 panic
+func (a *AlertLogger) output(ctx context.Context, level Level, caller, msg string, args []any) {
+	if level <= ErrorLevel {
+		fields := make([]Field, 0, len(a.fields)+len(args)/2+2)
+		fields = append(fields, a.fields...)
+		fields = append(fields, ContextFields(ctx)...)
+		fields = append(fields, argsToFields(args)...)
+		a.core.record(&Alert{
+			Time:    time.Now(),
+			Level:   level,
+			Caller:  caller,
+			Message: msg,
+			Fields:  fields,
+		})
+		if level <= FatalLevel {
+			a.core.flush(true)
+		}
+	}
+	// 默认日志器使用原始调用者输出
+	if l, ok := a.next.(*DefaultLogger); ok {
+		if !l.logAt(ctx, level, caller, msg, args) {
+			return
+		}
+		switch level {
+		case FatalLevel:
+			l.fatalExit()
+		case PanicLevel:
+			panic(msg)
+		}
+		return
+	}
+	a.forward(ctx, level, msg, args)
+}
+
+// forward 将日志转发到被包装的日志器
+func (a *AlertLogger) forward(ctx context.Context, level Level, msg string, args []any) {
+	sl, structured := a.next.(IStructuredLogger)
+	if len(args) > 0 && structured {
+		switch level {
+		case DebugLevel:
+			sl.DebugKV(ctx, msg, args...)
+			return
+		case InfoLevel:
+			sl.InfoKV(ctx, msg, args...)
+			return
+		case WarnLevel:
+			sl.WarnKV(ctx, msg, args...)
+			return
+		case ErrorLevel:
+			sl.ErrorKV(ctx, msg, args...)
+			return
+		}
+	}
+	if !structured {
+		msg = appendFieldsText(msg, append(slices.Clip(a.fields), argsToFields(args)...))
+	}
+	switch level {
+	case TraceLevel:
+		a.next.Trace(ctx, msg)
+	case DebugLevel:
+		a.next.Debug(ctx, msg)
+	case InfoLevel:
+		a.next.Info(ctx, msg)
+	case WarnLevel:
+		a.next.Warn(ctx, msg)
+	case ErrorLevel:
+		a.next.Error(ctx, msg)
+	case FatalLevel:
+		a.next.Fatal(ctx, msg)
+	default:
+		a.next.Panic(ctx, msg)
+	}
+}
+
+// record 记录告警，合并窗口内相同的告警合并计数，去重窗口内已发送过的告警只计数不发送，去重窗口结束时汇总发送
+func (c *alertCore) record(alert *Alert) {
+	key := alert.Level.String() + "|" + alert.Caller + "|" + alert.Message
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	if pending, ok := c.pendingIdx[key]; ok {
+		pending.Count++
+		return
+	}
+	if last, ok := c.lastSeen[key]; ok && alert.Time.Sub(last) < c.config.DedupWindow {
+		if suppressed, ok := c.suppressed[key]; ok {
+			suppressed.Count++
+		} else {
+			alert.Count = 1
+			c.suppressed[key] = alert
+		}
+		c.schedule(last.Add(c.config.DedupWindow))
+		return
+	}
+	alert.Count = 1
+	if suppressed, ok := c.suppressed[key]; ok {
+		alert.Count += suppressed.Count
+		delete(c.suppressed, key)
+	}
+	c.lastSeen[key] = alert.Time
+	c.enqueue(key, alert)
+	c.schedule(alert.Time.Add(c.config.BatchInterval))
+}
+
+// enqueue 将告警加入待发送列表，超过单条消息告警数上限时计入未发送的告警数
+func (c *alertCore) enqueue(key string, alert *Alert) {
+	if len(c.pending) >= c.config.MaxBatchSize {
+		c.omitted += alert.Count
+		return
+	}
+	c.pending = append(c.pending, alert)
+	c.pendingIdx[key] = alert
+}
+
+// schedule 在指定时间触发发送，已有更早的定时器时不变
+func (c *alertCore) schedule(at time.Time) {
+	if c.timer != nil {
+		if !c.timerAt.After(at) {
+			return
+		}
+		c.timer.Stop()
+	}
+	c.timerAt = at
+	c.timer = time.AfterFunc(time.Until(at), func() {
+		c.flush(false)
+	})
+}
+
+// flush 发送待发送的告警，force 为 true 时不受发送频率限制
+func (c *alertCore) flush(force bool) (err error) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	c.mu.Lock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	now := time.Now()
+	// 清理过期的去重记录，去重窗口内被抑制的告警汇总发送，关闭时全部发送
+	for key, last := range c.lastSeen {
+		if expired := now.Sub(last) >= c.config.DedupWindow; expired || c.closed {
+			if expired {
+				delete(c.lastSeen, key)
+			}
+			if suppressed, ok := c.suppressed[key]; ok {
+				delete(c.suppressed, key)
+				c.enqueue(key, suppressed)
+			}
+		}
+	}
+	// 还有被抑制的告警时，在其去重窗口结束时再次发送
+	for key := range c.suppressed {
+		c.schedule(c.lastSeen[key].Add(c.config.DedupWindow))
+	}
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	batch := &AlertBatch{
+		Service: c.config.Service,
+		Env:     c.config.Env,
+		Host:    c.host,
+		Alerts:  c.pending,
+	}
+	c.pending, c.pendingIdx = nil, make(map[string]*Alert)
+	// 发送频率限制
+	c.sendTimes = slices.DeleteFunc(c.sendTimes, func(t time.Time) bool {
+		return now.Sub(t) >= time.Minute
+	})
+	if !force && len(c.sendTimes) >= c.config.RateLimit {
+		for _, alert := range batch.Alerts {
+			c.omitted += alert.Count
+		}
+		c.mu.Unlock()
+		return
+	}
+	c.sendTimes = append(c.sendTimes, now)
+	batch.Omitted, c.omitted = c.omitted, 0
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.SendTimeout)
+	defer cancel()
+	if err = c.alerter.SendAlerts(ctx, batch); err != nil && c.config.OnError != nil {
+		c.config.OnError(err)
+	}
+	return
+}
@@ -0,0 +1,177 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-25 15:21:38
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-25 15:21:38
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/liusuxian/go-toolkit/gtklog"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type captureAlerter struct {
+	mu      sync.Mutex
+	batches []*gtklog.AlertBatch
+	err     error
+}
+
+func (a *captureAlerter) SendAlerts(ctx context.Context, batch *gtklog.AlertBatch) (err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.batches = append(a.batches, batch)
+	return a.err
+}
+
+func (a *captureAlerter) Batches() (batches []*gtklog.AlertBatch) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]*gtklog.AlertBatch(nil), a.batches...)
+}
+
+func TestAlertLogger(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		ctx     = context.WithValue(context.Background(), ctxUserKey{}, "u1")
+		buf     bytes.Buffer
+		alerter = &captureAlerter{}
+	)
+	next := gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(&buf))
+	log := gtklog.NewAlertLogger(next, alerter, gtklog.AlertConfig{
+		Service:       "chat",
+		Env:           "prod",
+		BatchInterval: 30 * time.Millisecond,
+		DedupWindow:   time.Hour,
+	})
+	// 非错误日志不告警，调用者保持为业务代码
+	log.Info(ctx, "hello")
+	assert.Regexp(regexp.MustCompile(`\[INFO\] \[alert_test\.go:\d+\] hello ctx_user=u1\n$`), buf.String())
+	// 合并窗口内的告警合并为一条消息，相同告警合并计数
+	consumeFailed := func() { log.Errorf(ctx, "consume failed: %s", "timeout") }
+	for range 3 {
+		consumeFailed()
+	}
+	log.With("queue", "orders").ErrorKV(ctx, "send failed", "partition", 2)
+	assert.Eventually(func() bool { return len(alerter.Batches()) == 1 }, time.Second, 5*time.Millisecond)
+	batch := alerter.Batches()[0]
+	assert.Equal("chat", batch.Service)
+	assert.Equal("prod", batch.Env)
+	assert.NotEmpty(batch.Host)
+	assert.Len(batch.Alerts, 2)
+	assert.Equal("consume failed: timeout", batch.Alerts[0].Message)
+	assert.Equal(3, batch.Alerts[0].Count)
+	assert.Regexp(regexp.MustCompile(`^alert_test\.go:\d+$`), batch.Alerts[0].Caller)
+	assert.Equal([]gtklog.Field{gtklog.F("ctx_user", "u1")}, batch.Alerts[0].Fields)
+	assert.Equal([]gtklog.Field{gtklog.F("queue", "orders"), gtklog.F("ctx_user", "u1"), gtklog.F("partition", 2)}, batch.Alerts[1].Fields)
+	assert.Contains(buf.String(), "send failed queue=orders ctx_user=u1 partition=2\n")
+	// 去重窗口内相同的告警不再发送
+	consumeFailed()
+	assert.NoError(log.Flush())
+	assert.Len(alerter.Batches(), 1)
+	// 恐慌日志立即发送
+	assert.Panics(func() { log.Panic(ctx, "fatal state") })
+	assert.Len(alerter.Batches(), 2)
+	assert.Equal(gtklog.PanicLevel, alerter.Batches()[1].Alerts[0].Level)
+	assert.Equal(5, strings.Count(buf.String(), "[ERROR]"))
+	// 关闭时发送去重窗口内被抑制的告警
+	assert.NoError(log.Close())
+	if assert.Len(alerter.Batches(), 3) && assert.Len(alerter.Batches()[2].Alerts, 1) {
+		assert.Equal("consume failed: timeout", alerter.Batches()[2].Alerts[0].Message)
+		assert.Equal(1, alerter.Batches()[2].Alerts[0].Count)
+	}
+	log.Error(ctx, "after close")
+	assert.NoError(log.Flush())
+	assert.Len(alerter.Batches(), 3)
+}
+
+func TestAlertLoggerSuppressedSummary(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		ctx     = context.Background()
+		alerter = &captureAlerter{}
+	)
+	log := gtklog.NewAlertLogger(&captureLogger{}, alerter, gtklog.AlertConfig{
+		BatchInterval: 10 * time.Millisecond,
+		DedupWindow:   80 * time.Millisecond,
+	})
+	defer log.Close()
+	consumeFailed := func() { log.Error(ctx, "consume failed") }
+	consumeFailed()
+	assert.Eventually(func() bool { return len(alerter.Batches()) == 1 }, time.Second, 5*time.Millisecond)
+	// 去重窗口内被抑制的告警在窗口结束时汇总发送，之后不再出现也不会丢失
+	consumeFailed()
+	consumeFailed()
+	assert.Len(alerter.Batches(), 1)
+	assert.Eventually(func() bool { return len(alerter.Batches()) == 2 }, time.Second, 5*time.Millisecond)
+	if alerts := alerter.Batches()[1].Alerts; assert.Len(alerts, 1) {
+		assert.Equal("consume failed", alerts[0].Message)
+		assert.Equal(2, alerts[0].Count)
+	}
+}
+
+func TestAlertLoggerLimit(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		ctx     = context.Background()
+		alerter = &captureAlerter{err: errors.New("send failed")}
+		errs    []error
+	)
+	capture := &captureLogger{}
+	log := gtklog.NewAlertLogger(capture, alerter, gtklog.AlertConfig{
+		BatchInterval: time.Hour,
+		MaxBatchSize:  2,
+		RateLimit:     1,
+		OnError:       func(err error) { errs = append(errs, err) },
+	})
+	// 未实现结构化接口的日志器，字段追加到日志内容
+	log.ErrorKV(ctx, "e1", "k", "v")
+	log.Error(ctx, "e2")
+	log.Error(ctx, "e3")
+	assert.Equal([]string{"ERROR e1 k=v", "ERROR e2", "ERROR e3"}, capture.lines)
+	assert.Error(log.Flush())
+	assert.Len(alerter.Batches(), 1)
+	assert.Len(alerter.Batches()[0].Alerts, 2)
+	assert.Equal(1, alerter.Batches()[0].Omitted)
+	assert.Len(errs, 1)
+	// 超过发送频率限制，计入下一条消息的 Omitted
+	log.Error(ctx, "e4")
+	assert.NoError(log.Flush())
+	assert.Len(alerter.Batches(), 1)
+	log.Fatal(ctx, "e5")
+	assert.Len(alerter.Batches(), 2)
+	assert.Equal(1, alerter.Batches()[1].Omitted)
+	assert.Equal("ERROR e1 k=v", capture.lines[0])
+	assert.Equal("FATAL e5", capture.lines[len(capture.lines)-1])
+}
+
+func TestAlertLoggerDisabledLevel(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		arg    = &countingStringer{}
+		buf    bytes.Buffer
+	)
+	// 未开启且不告警的级别不格式化参数
+	log := gtklog.NewAlertLogger(gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(&buf)), &captureAlerter{}, gtklog.AlertConfig{})
+	defer log.Close()
+	log.Debug(ctx, arg)
+	log.Debugf(ctx, "debug %s", arg)
+	log.Tracef(ctx, "trace %s", arg)
+	log.DebugKV(ctx, "debug", "arg", arg)
+	assert.Zero(arg.calls.Load())
+	assert.Empty(buf.String())
+	log.Infof(ctx, "info %s", arg)
+	assert.Equal(int32(1), arg.calls.Load())
+}
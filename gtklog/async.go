@@ -0,0 +1,182 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-24 14:32:51
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-24 14:32:51
+ * @Description: 异步缓冲写入
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// DropPolicy 队列已满时的丢弃策略
+type DropPolicy int
+
+const (
+	DropNewest DropPolicy = iota // 丢弃新写入的日志（默认）
+	DropOldest                   // 丢弃队列中最早的日志
+	DropNone                     // 不丢弃，阻塞等待队列有空位
+)
+
+// AsyncWriterConfig 异步写入配置
+type AsyncWriterConfig struct {
+	QueueSize  int               // 队列长度，默认 1024
+	DropPolicy DropPolicy        // 队列已满时的丢弃策略，默认 DropNewest
+	OnDrop     func(data []byte) // 日志被丢弃时的回调，可用于统计或告警，不能阻塞
+}
+
+// asyncItem 队列中的日志
+type asyncItem struct {
+	data     []byte        // 日志内容
+	level    Level         // 日志级别
+	hasLevel bool          // 是否通过 WriteLevel 写入
+	flushed  chan struct{} // 不为空时表示 Flush 标记，处理到时关闭
+}
+
+// AsyncWriter 异步缓冲写入，日志先写入有界队列，由后台协程写入底层 Writer，避免磁盘或网络抖动阻塞业务
+//
+//	底层 Writer 实现 LevelWriter 时，通过 WriteLevel 写入的日志保留级别信息
+type AsyncWriter struct {
+	w       io.Writer
+	config  AsyncWriterConfig
+	queue   chan asyncItem
+	mu      sync.RWMutex // 保护 closed，写入持有读锁，关闭持有写锁
+	closed  bool
+	done    chan struct{}
+	dropped atomic.Uint64
+}
+
+// NewAsyncWriter 创建异步缓冲写入
+func NewAsyncWriter(w io.Writer, config AsyncWriterConfig) (a *AsyncWriter) {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1024
+	}
+	a = &AsyncWriter{
+		w:      w,
+		config: config,
+		queue:  make(chan asyncItem, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+	return
+}
+
+// Write 写入日志，队列已满时按丢弃策略处理，丢弃日志不返回错误
+func (a *AsyncWriter) Write(p []byte) (n int, err error) {
+	return a.enqueue(asyncItem{data: p})
+}
+
+// WriteLevel 写入指定级别的日志，实现 LevelWriter
+func (a *AsyncWriter) WriteLevel(level Level, p []byte) (n int, err error) {
+	return a.enqueue(asyncItem{data: p, level: level, hasLevel: true})
+}
+
+// Flush 等待调用前写入的日志全部写入底层 Writer
+func (a *AsyncWriter) Flush() (err error) {
+	a.mu.RLock()
+	if a.closed {
+		a.mu.RUnlock()
+		return os.ErrClosed
+	}
+	flushed := make(chan struct{})
+	a.queue <- asyncItem{flushed: flushed}
+	a.mu.RUnlock()
+
+	<-flushed
+	return
+}
+
+// Dropped 获取已丢弃的日志数
+func (a *AsyncWriter) Dropped() (count uint64) {
+	return a.dropped.Load()
+}
+
+// Close 写入队列中剩余的日志后关闭，底层 Writer 实现 io.Closer 时一并关闭（os.Stdout 和 os.Stderr 除外）
+func (a *AsyncWriter) Close() (err error) {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+
+	<-a.done
+	if closer, ok := a.w.(io.Closer); ok && !isStdStream(a.w) {
+		err = closer.Close()
+	}
+	return
+}
+
+// enqueue 将日志放入队列
+func (a *AsyncWriter) enqueue(item asyncItem) (n int, err error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return 0, os.ErrClosed
+	}
+	// 调用方可能复用 p，需要拷贝
+	item.data = append([]byte(nil), item.data...)
+	switch a.config.DropPolicy {
+	case DropNone:
+		a.queue <- item
+	case DropOldest:
+		for {
+			select {
+			case a.queue <- item:
+				return len(item.data), nil
+			default:
+			}
+			select {
+			case old := <-a.queue:
+				a.drop(old)
+			default:
+			}
+		}
+	default:
+		select {
+		case a.queue <- item:
+		default:
+			a.drop(item)
+		}
+	}
+	return len(item.data), nil
+}
+
+// drop 丢弃日志，Flush 标记不能丢弃，直接视为已完成
+func (a *AsyncWriter) drop(item asyncItem) {
+	if item.flushed != nil {
+		close(item.flushed)
+		return
+	}
+	a.dropped.Add(1)
+	if a.config.OnDrop != nil {
+		a.config.OnDrop(item.data)
+	}
+}
+
+// run 后台写入日志
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+
+	lw, isLevelWriter := a.w.(LevelWriter)
+	for item := range a.queue {
+		switch {
+		case item.flushed != nil:
+			close(item.flushed)
+		case item.hasLevel && isLevelWriter:
+			lw.WriteLevel(item.level, item.data)
+		default:
+			a.w.Write(item.data)
+		}
+	}
+}
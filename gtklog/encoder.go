@@ -0,0 +1,158 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-24 10:08:26
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-24 10:08:26
+ * @Description: 日志编码器
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Entry 日志条目
+type Entry struct {
+	Time    time.Time // 时间
+	Level   Level     // 日志级别
+	Caller  string    // 调用者，格式为 file:line
+	Message string    // 日志内容
+	Fields  []Field   // 日志字段
+}
+
+// Encoder 日志编码器接口
+type Encoder interface {
+	Encode(buf *bytes.Buffer, entry *Entry) // 将日志条目编码到 buf 中，包含末尾的换行符
+}
+
+// TextEncoder 文本编码器，格式为 2006/01/02 15:04:05 [INFO] [file:line] message key=value
+type TextEncoder struct{}
+
+// Encode 编码日志条目
+func (TextEncoder) Encode(buf *bytes.Buffer, entry *Entry) {
+	buf.WriteString(entry.Time.Format("2006/01/02 15:04:05"))
+	buf.WriteString(" [" + entry.Level.CapitalString() + "] [" + entry.Caller + "] ")
+	buf.WriteString(entry.Message)
+	for _, field := range entry.Fields {
+		buf.WriteByte(' ')
+		writeLogfmtField(buf, field)
+	}
+	buf.WriteByte('\n')
+}
+
+// JSONEncoder JSON 编码器，每条日志一行 JSON，如 {"time":"...","level":"info","caller":"file:line","msg":"message","key":"value"}
+type JSONEncoder struct {
+	TimeLayout string // 时间格式，默认 time.RFC3339Nano
+}
+
+// Encode 编码日志条目
+func (e JSONEncoder) Encode(buf *bytes.Buffer, entry *Entry) {
+	layout := e.TimeLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	buf.WriteString(`{"time":`)
+	writeJSONValue(buf, entry.Time.Format(layout))
+	buf.WriteString(`,"level":`)
+	writeJSONValue(buf, entry.Level.String())
+	if entry.Caller != "" {
+		buf.WriteString(`,"caller":`)
+		writeJSONValue(buf, entry.Caller)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(buf, entry.Message)
+	for _, field := range entry.Fields {
+		buf.WriteByte(',')
+		writeJSONValue(buf, field.Key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, field.Value)
+	}
+	buf.WriteString("}\n")
+}
+
+// LogfmtEncoder logfmt 编码器，如 time=... level=info caller=file:line msg="message" key=value
+type LogfmtEncoder struct {
+	TimeLayout string // 时间格式，默认 time.RFC3339Nano
+}
+
+// Encode 编码日志条目
+func (e LogfmtEncoder) Encode(buf *bytes.Buffer, entry *Entry) {
+	layout := e.TimeLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	writeLogfmtField(buf, Field{Key: "time", Value: entry.Time.Format(layout)})
+	buf.WriteByte(' ')
+	writeLogfmtField(buf, Field{Key: "level", Value: entry.Level.String()})
+	if entry.Caller != "" {
+		buf.WriteByte(' ')
+		writeLogfmtField(buf, Field{Key: "caller", Value: entry.Caller})
+	}
+	buf.WriteByte(' ')
+	writeLogfmtField(buf, Field{Key: "msg", Value: entry.Message})
+	for _, field := range entry.Fields {
+		buf.WriteByte(' ')
+		writeLogfmtField(buf, field)
+	}
+	buf.WriteByte('\n')
+}
+
+// writeJSONValue 写入 JSON 值，无法序列化的值使用 fmt.Sprint 转换为字符串
+func writeJSONValue(buf *bytes.Buffer, value any) {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case fmt.Stringer:
+		if _, ok := v.(json.Marshaler); !ok {
+			value = v.String()
+		}
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(b)
+}
+
+// writeLogfmtField 写入 logfmt 字段，值包含空格、引号、等号或不可见字符时加引号
+func writeLogfmtField(buf *bytes.Buffer, field Field) {
+	buf.WriteString(field.Key)
+	buf.WriteByte('=')
+	var s string
+	switch v := field.Value.(type) {
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	case nil:
+		s = "<nil>"
+	default:
+		s = fmt.Sprint(v)
+	}
+	if needsQuote(s) {
+		buf.WriteString(strconv.Quote(s))
+		return
+	}
+	buf.WriteString(s)
+}
+
+// needsQuote 判断 logfmt 值是否需要加引号
+func needsQuote(s string) (ok bool) {
+	if s == "" {
+		return true
+	}
+	if !utf8.ValidString(s) {
+		return true
+	}
+	return strings.ContainsFunc(s, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == 0x7f
+	})
+}
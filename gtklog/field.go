@@ -0,0 +1,101 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-24 10:08:26
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-24 10:08:26
+ * @Description: 结构化日志字段
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Field 日志字段
+type Field struct {
+	Key   string // 键
+	Value any    // 值
+}
+
+// F 创建日志字段
+func F(key string, value any) (field Field) {
+	return Field{Key: key, Value: value}
+}
+
+// ContextExtractor 上下文字段提取函数，用于从上下文中提取请求ID、用户等字段
+type ContextExtractor func(ctx context.Context) (fields []Field)
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
+// RegisterContextExtractor 注册上下文字段提取函数，每条日志都会调用已注册的函数提取字段
+//
+//	gtkhttp 包会自动注册请求ID、用户和追踪ID的提取函数
+func RegisterContextExtractor(extractor ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+
+	extractors = append(extractors, extractor)
+}
+
+// ContextFields 从上下文中提取字段
+func ContextFields(ctx context.Context) (fields []Field) {
+	if ctx == nil {
+		return
+	}
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+
+	for _, extractor := range extractors {
+		fields = append(fields, extractor(ctx)...)
+	}
+	return
+}
+
+// argsToFields 将参数转换为字段，参数可以是 Field 或者交替出现的键和值，如 ("user", "u1", F("n", 1))
+//
+//	键不是字符串时使用 fmt.Sprint 转换，缺少值的键使用 "!BADKEY" 作为键
+func argsToFields(args []any) (fields []Field) {
+	fields = make([]Field, 0, len(args)/2+1)
+	for i := 0; i < len(args); i++ {
+		switch v := args[i].(type) {
+		case Field:
+			fields = append(fields, v)
+		case []Field:
+			fields = append(fields, v...)
+		default:
+			if i+1 >= len(args) {
+				fields = append(fields, Field{Key: "!BADKEY", Value: v})
+				continue
+			}
+			key, ok := v.(string)
+			if !ok {
+				key = fmt.Sprint(v)
+			}
+			fields = append(fields, Field{Key: key, Value: args[i+1]})
+			i++
+		}
+	}
+	return
+}
+
+// appendFieldsText 将字段以 key=value 的形式追加到日志内容后，用于不支持结构化字段的日志器
+func appendFieldsText(msg string, fields []Field) (text string) {
+	if len(fields) == 0 {
+		return msg
+	}
+	var buf bytes.Buffer
+	buf.WriteString(msg)
+	for _, field := range fields {
+		buf.WriteByte(' ')
+		writeLogfmtField(&buf, field)
+	}
+	return buf.String()
+}
@@ -0,0 +1,224 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-25 09:47:13
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-25 09:47:13
+ * @Description: 运行时可调整的日志级别
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"weak"
+)
+
+// LevelVar 可在运行时调整的日志级别，并发安全
+type LevelVar struct {
+	v atomic.Uint32
+}
+
+// NewLevelVar 创建可调整的日志级别
+func NewLevelVar(level Level) (v *LevelVar) {
+	v = &LevelVar{}
+	v.Set(level)
+	return
+}
+
+// Level 获取日志级别
+func (v *LevelVar) Level() (level Level) {
+	return Level(v.v.Load())
+}
+
+// Set 设置日志级别
+func (v *LevelVar) Set(level Level) {
+	v.v.Store(uint32(level))
+}
+
+// namedLevel 同名日志器的日志级别
+type namedLevel struct {
+	level    Level                    // 当前日志级别
+	explicit bool                     // 是否通过 SetLevel 显式设置
+	vars     []weak.Pointer[LevelVar] // 同名日志器各自的日志级别
+}
+
+var (
+	levelsMu sync.RWMutex
+	levels   = make(map[string]*namedLevel) // 具名日志器的日志级别
+)
+
+// registerNamedLevel 登记具名日志器的日志级别
+//
+//	name 或其父名称已通过 SetLevel 显式设置时使用显式设置的级别，否则保留日志器自身的级别
+func registerNamedLevel(name string, v *LevelVar) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	for parent := name; ; {
+		if nl, ok := levels[parent]; ok && nl.explicit {
+			v.Set(nl.level)
+			break
+		}
+		i := strings.LastIndexByte(parent, '.')
+		if i < 0 {
+			break
+		}
+		parent = parent[:i]
+	}
+	nl, ok := levels[name]
+	if !ok {
+		nl = &namedLevel{}
+		levels[name] = nl
+	}
+	if !nl.explicit {
+		nl.level = v.Level()
+	}
+	nl.vars = append(liveLevelVars(nl.vars), weak.Make(v))
+}
+
+// liveLevelVars 去除已被回收的日志级别
+func liveLevelVars(vars []weak.Pointer[LevelVar]) (live []weak.Pointer[LevelVar]) {
+	live = vars[:0]
+	for _, p := range vars {
+		if p.Value() != nil {
+			live = append(live, p)
+		}
+	}
+	return
+}
+
+// SetLevel 设置具名日志器的日志级别，同时作用于以 name. 为前缀的子日志器，如设置 gtkmq 会同时设置 gtkmq.consumer
+//
+//	同名日志器各自持有日志级别，未调用 SetLevel 时使用创建时父日志器的级别，调用后所有同名日志器都使用设置的级别
+//	日志器尚未创建时，设置的级别在创建时生效
+func SetLevel(name string, level Level) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	if _, ok := levels[name]; !ok {
+		levels[name] = &namedLevel{}
+	}
+	for childName, nl := range levels {
+		if childName == name || strings.HasPrefix(childName, name+".") {
+			nl.level, nl.explicit = level, true
+			nl.vars = liveLevelVars(nl.vars)
+			for _, p := range nl.vars {
+				p.Value().Set(level)
+			}
+		}
+	}
+}
+
+// GetLevel 获取具名日志器的日志级别
+func GetLevel(name string) (level Level, ok bool) {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	nl, ok := levels[name]
+	if !ok {
+		return
+	}
+	return nl.level, true
+}
+
+// Levels 获取所有具名日志器的日志级别
+func Levels() (result map[string]Level) {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	result = make(map[string]Level, len(levels))
+	for name, nl := range levels {
+		result[name] = nl.level
+	}
+	return
+}
+
+// SetLevels 批量设置具名日志器的日志级别，levels 的值为日志级别名称，如 {"gtkkafka": "debug"}，无法解析的级别返回错误且不会生效
+func SetLevels(levels map[string]string) (err error) {
+	parsed := make(map[string]Level, len(levels))
+	for name, s := range levels {
+		var level Level
+		if level, err = ParseLevel(s); err != nil {
+			return fmt.Errorf("logger %q: %w", name, err)
+		}
+		parsed[name] = level
+	}
+	for name, level := range parsed {
+		SetLevel(name, level)
+	}
+	return
+}
+
+// WatchLevelConfig 监听配置变化并更新具名日志器的日志级别，注册时立即应用一次
+//
+//	getLevels 返回日志器名称到日志级别名称的映射，如：
+//	gtklog.WatchLevelConfig(gtkconf.OnConfigChange, func() map[string]string { return gtkconf.GetStringMapString("log.levels") })
+//	注意 gtkconf.OnConfigChange 只保留最后一次设置的处理程序
+func WatchLevelConfig[E any](onConfigChange func(run func(e E)), getLevels func() (levels map[string]string)) (err error) {
+	onConfigChange(func(e E) {
+		SetLevels(getLevels())
+	})
+	return SetLevels(getLevels())
+}
+
+// levelRequest 设置日志级别的请求
+type levelRequest struct {
+	Name  string `json:"name"`  // 日志器名称
+	Level string `json:"level"` // 日志级别名称
+}
+
+// levelResponse 日志级别接口的响应
+type levelResponse struct {
+	Levels map[string]string `json:"levels,omitempty"` // 日志器名称到日志级别名称的映射
+	Error  string            `json:"error,omitempty"`  // 错误信息
+}
+
+// LevelHandler 查看和设置具名日志器日志级别的 http.Handler，如 http.Handle("/debug/log/level", gtklog.LevelHandler())
+//
+//	GET 返回所有具名日志器的日志级别，如 {"levels":{"gtkmq":"info"}}
+//	PUT/POST 设置日志级别，参数可以是 JSON 请求体 {"name":"gtkkafka","level":"debug"} 或查询参数 ?name=gtkkafka&level=debug
+func LevelHandler() (h http.Handler) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			req := levelRequest{Name: r.URL.Query().Get("name"), Level: r.URL.Query().Get("level")}
+			if req.Level == "" {
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					writeLevelResponse(w, http.StatusBadRequest, levelResponse{Error: "invalid request body: " + err.Error()})
+					return
+				}
+			}
+			if req.Name == "" {
+				writeLevelResponse(w, http.StatusBadRequest, levelResponse{Error: "name is required"})
+				return
+			}
+			if err := SetLevels(map[string]string{req.Name: req.Level}); err != nil {
+				writeLevelResponse(w, http.StatusBadRequest, levelResponse{Error: err.Error()})
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			writeLevelResponse(w, http.StatusMethodNotAllowed, levelResponse{Error: "method not allowed"})
+			return
+		}
+		resp := levelResponse{Levels: make(map[string]string)}
+		for name, level := range Levels() {
+			resp.Levels[name] = level.String()
+		}
+		writeLevelResponse(w, http.StatusOK, resp)
+	})
+}
+
+// writeLevelResponse 输出日志级别接口的响应
+func writeLevelResponse(w http.ResponseWriter, statusCode int, resp levelResponse) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(resp)
+}
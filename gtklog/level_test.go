@@ -0,0 +1,195 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-25 09:47:13
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-25 09:47:13
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/gtklog"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNamedLogger(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		buf    bytes.Buffer
+	)
+	gtklog.SetLevel("mqtest", gtklog.InfoLevel) // 级别全局共用，重复运行时先重置
+	root := gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(&buf))
+	mq := gtklog.NamedLogger(root, "mqtest")
+	consumer := gtklog.NamedLogger(mq, "consumer")
+	kafka := root.Named("kafkatest")
+	// 首次创建时使用父日志器的级别
+	level, ok := gtklog.GetLevel("mqtest.consumer")
+	assert.True(ok)
+	assert.Equal(gtklog.InfoLevel, level)
+	consumer.Debug(ctx, "hidden")
+	assert.Empty(buf.String())
+	// 运行时调整级别，同时作用于子日志器，不影响其他日志器
+	gtklog.SetLevel("mqtest", gtklog.DebugLevel)
+	consumer.Debug(ctx, "consumer debug")
+	mq.Debug(ctx, "mq debug")
+	kafka.Debug(ctx, "hidden")
+	root.Debug(ctx, "hidden")
+	assert.Equal(2, strings.Count(buf.String(), "\n"))
+	assert.Contains(buf.String(), "[DEBUG] [level_test.go:")
+	assert.Contains(buf.String(), "consumer debug logger=mqtest.consumer\n")
+	assert.Contains(buf.String(), "mq debug logger=mqtest\n")
+	// 同名日志器共用级别
+	buf.Reset()
+	root.Named("mqtest").Debug(ctx, "shared")
+	assert.Contains(buf.String(), "shared logger=mqtest\n")
+	// 根日志器调整级别
+	root.SetLevel(gtklog.ErrorLevel)
+	assert.Equal(gtklog.ErrorLevel, root.GetLevel())
+	buf.Reset()
+	root.Warn(ctx, "hidden")
+	assert.Empty(buf.String())
+	// 未实现 Named 的日志器原样返回
+	capture := &captureLogger{}
+	assert.Same(capture, gtklog.NamedLogger(capture, "x"))
+}
+
+func TestNamedLoggerOwnLevel(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		buf    bytes.Buffer
+		name   = fmt.Sprintf("owntest%d", time.Now().UnixNano()) // 显式设置的级别全局保留，重复运行时使用新的名称
+	)
+	// 先创建 Trace 级别的同名日志器（如组件内置的默认日志器），再设置 Info 级别的日志器，后者保留自身的级别
+	builtin := gtklog.NewDefaultLogger(gtklog.TraceLevel, gtklog.WithOutput(&buf)).Named(name)
+	custom := gtklog.NamedLogger(gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(&buf)), name)
+	custom.Debugf(ctx, "hidden %d", 1)
+	custom.Info(ctx, "custom info")
+	builtin.Debug(ctx, "builtin debug")
+	assert.NotContains(buf.String(), "hidden")
+	assert.Contains(buf.String(), "custom info logger="+name+"\n")
+	assert.Contains(buf.String(), "builtin debug logger="+name+"\n")
+	// 显式设置后作用于所有同名日志器，之后创建的同名日志器也使用设置的级别
+	gtklog.SetLevel(name, gtklog.WarnLevel)
+	buf.Reset()
+	builtin.Info(ctx, "hidden")
+	custom.Info(ctx, "hidden")
+	gtklog.NewDefaultLogger(gtklog.TraceLevel, gtklog.WithOutput(&buf)).Named(name).Info(ctx, "hidden")
+	custom.Warn(ctx, "custom warn")
+	assert.NotContains(buf.String(), "hidden")
+	assert.Contains(buf.String(), "custom warn")
+}
+
+func TestLevelHandler(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		handler = gtklog.LevelHandler()
+	)
+	gtklog.SetLevel("handlertest", gtklog.InfoLevel)
+	gtklog.NewDefaultLogger(gtklog.InfoLevel).Named("handlertest")
+	// 查看
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(http.StatusOK, rec.Code)
+	var resp struct {
+		Levels map[string]string `json:"levels"`
+		Error  string            `json:"error"`
+	}
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal("info", resp.Levels["handlertest"])
+	// 通过请求体设置
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"name":"handlertest","level":"debug"}`)))
+	assert.Equal(http.StatusOK, rec.Code)
+	level, _ := gtklog.GetLevel("handlertest")
+	assert.Equal(gtklog.DebugLevel, level)
+	// 通过查询参数设置
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/?name=handlertest&level=trace", nil))
+	assert.Equal(http.StatusOK, rec.Code)
+	level, _ = gtklog.GetLevel("handlertest")
+	assert.Equal(gtklog.TraceLevel, level)
+	// 错误请求
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/?name=handlertest&level=verbose", nil))
+	assert.Equal(http.StatusBadRequest, rec.Code)
+	assert.NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Contains(resp.Error, "unknown log level")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/", nil))
+	assert.Equal(http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestWatchLevelConfig(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		onChange func(e string)
+		conf     = map[string]string{"watchtest": "warn"}
+	)
+	err := gtklog.WatchLevelConfig(func(run func(e string)) { onChange = run }, func() map[string]string { return conf })
+	assert.NoError(err)
+	level, _ := gtklog.GetLevel("watchtest")
+	assert.Equal(gtklog.WarnLevel, level)
+	// 预先设置的级别在日志器创建时生效
+	logger := gtklog.NewDefaultLogger(gtklog.InfoLevel).Named("watchtest")
+	assert.False(logger.(*gtklog.DefaultLogger).Enabled(gtklog.InfoLevel))
+	// 配置变化
+	conf = map[string]string{"watchtest": "debug"}
+	onChange("write")
+	assert.True(logger.(*gtklog.DefaultLogger).Enabled(gtklog.DebugLevel))
+	// 无法解析的级别不生效
+	conf = map[string]string{"watchtest": "bad"}
+	onChange("write")
+	level, _ = gtklog.GetLevel("watchtest")
+	assert.Equal(gtklog.DebugLevel, level)
+}
+
+func TestRateSampler(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		buf    bytes.Buffer
+	)
+	sampler := gtklog.NewRateSampler(gtklog.SamplerConfig{Interval: time.Hour, First: 3, Thereafter: 5})
+	log := gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(&buf), gtklog.WithSampler(sampler))
+	for range 20 {
+		log.With("attempt", 1).Error(ctx, "consume failed")
+	}
+	log.Info(ctx, "other message")
+	// 前 3 条全部输出，之后每 5 条输出 1 条
+	assert.Equal(3+3, strings.Count(buf.String(), "consume failed"))
+	assert.Equal(1, strings.Count(buf.String(), "other message"))
+	assert.Equal(uint64(14), sampler.Dropped())
+	// 新周期重新计数
+	sampler = gtklog.NewRateSampler(gtklog.SamplerConfig{Interval: 20 * time.Millisecond, First: 1})
+	buf.Reset()
+	log = gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(&buf), gtklog.WithSampler(sampler))
+	boom := func() { log.Error(ctx, "boom") }
+	boom()
+	boom()
+	time.Sleep(30 * time.Millisecond)
+	boom()
+	assert.Equal(2, strings.Count(buf.String(), "boom"))
+	// 同一行代码输出的日志参数不同也视为相同日志
+	sampler = gtklog.NewRateSampler(gtklog.SamplerConfig{Interval: time.Hour, First: 2})
+	buf.Reset()
+	log = gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(&buf), gtklog.WithSampler(sampler))
+	for i := range 5 {
+		log.Errorf(ctx, "consume failed, attempt: %d, offset: %d", i, 100+i)
+	}
+	log.Errorf(ctx, "consume failed, attempt: %d, offset: %d", 9, 109)
+	assert.Equal(3, strings.Count(buf.String(), "consume failed"))
+	assert.Equal(uint64(3), sampler.Dropped())
+}
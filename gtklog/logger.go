@@ -10,12 +10,17 @@
 package gtklog
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
 // ILogger 日志接口
@@ -49,148 +54,336 @@ const (
 	TraceLevel
 )
 
-// DefaultLogger 默认日志实现
+// IStructuredLogger 结构化日志接口，在 ILogger 的基础上支持附加字段和键值对日志
+type IStructuredLogger interface {
+	ILogger
+	With(args ...any) (logger IStructuredLogger)          // 创建附加了字段的子日志器，参数可以是 Field 或交替出现的键和值
+	DebugKV(ctx context.Context, msg string, args ...any) // 调试日志，args 为键值对
+	InfoKV(ctx context.Context, msg string, args ...any)  // 信息日志，args 为键值对
+	WarnKV(ctx context.Context, msg string, args ...any)  // 警告日志，args 为键值对
+	ErrorKV(ctx context.Context, msg string, args ...any) // 错误日志，args 为键值对
+}
+
+// levelNames 日志级别名称
+var levelNames = []string{"panic", "fatal", "error", "warn", "info", "debug", "trace"}
+
+// String 日志级别名称，如 info
+func (level Level) String() (s string) {
+	if int(level) < len(levelNames) {
+		return levelNames[level]
+	}
+	return fmt.Sprintf("level(%d)", uint32(level))
+}
+
+// CapitalString 大写的日志级别名称，如 INFO
+func (level Level) CapitalString() (s string) {
+	return strings.ToUpper(level.String())
+}
+
+// ParseLevel 解析日志级别名称，不区分大小写，warning 等同于 warn
+func ParseLevel(s string) (level Level, err error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "warning" {
+		name = "warn"
+	}
+	if i := slices.Index(levelNames, name); i >= 0 {
+		return Level(i), nil
+	}
+	return 0, fmt.Errorf("unknown log level: %q", s)
+}
+
+// LoggerOption 日志器选项
+type LoggerOption func(l *DefaultLogger)
+
+// WithOutput 设置日志输出，默认 os.Stdout，可以是 RotatingFile、AsyncWriter、LevelTee 等
+func WithOutput(w io.Writer) (opt LoggerOption) {
+	return func(l *DefaultLogger) {
+		l.out = w
+	}
+}
+
+// WithEncoder 设置日志编码器，默认 TextEncoder，可选 JSONEncoder、LogfmtEncoder
+func WithEncoder(encoder Encoder) (opt LoggerOption) {
+	return func(l *DefaultLogger) {
+		l.encoder = encoder
+	}
+}
+
+// WithFields 设置日志器的固定字段，参数可以是 Field 或交替出现的键和值
+func WithFields(args ...any) (opt LoggerOption) {
+	return func(l *DefaultLogger) {
+		l.fields = append(l.fields, argsToFields(args)...)
+	}
+}
+
+// WithSampler 设置日志采样器，如 NewRateSampler 限制重复日志的输出条数，子日志器共用采样器
+func WithSampler(sampler Sampler) (opt LoggerOption) {
+	return func(l *DefaultLogger) {
+		l.sampler = sampler
+	}
+}
+
+// WithExitFunc 设置致命日志输出后的退出函数，默认 os.Exit，可用于测试或在退出前执行清理
+func WithExitFunc(exit func(code int)) (opt LoggerOption) {
+	return func(l *DefaultLogger) {
+		l.exit = exit
+	}
+}
+
+// DefaultLogger 默认日志实现，同时实现了 IStructuredLogger
+//
+//	默认以文本格式输出，格式为 2006/01/02 15:04:05 [INFO] [file:line] message key=value
+//	上下文中的字段（如请求ID）通过 RegisterContextExtractor 注册的函数自动提取
 type DefaultLogger struct {
-	logger *log.Logger
-	level  Level
+	out     io.Writer      // 日志输出
+	mu      *sync.Mutex    // 输出锁，子日志器共用
+	encoder Encoder        // 日志编码器
+	level   *LevelVar      // 日志级别
+	name    string         // 日志器名称，以点号连接，如 app.gtkmq
+	sampler Sampler        // 日志采样器
+	fields  []Field        // 固定字段
+	exit    func(code int) // 致命日志输出后的退出函数
 }
 
 // NewDefaultLogger 创建默认日志器
-func NewDefaultLogger(level Level) (l *DefaultLogger) {
-	return &DefaultLogger{
-		logger: log.New(os.Stdout, "", log.LstdFlags),
-		level:  level,
+func NewDefaultLogger(level Level, opts ...LoggerOption) (l *DefaultLogger) {
+	l = &DefaultLogger{
+		out:     os.Stdout,
+		mu:      &sync.Mutex{},
+		encoder: TextEncoder{},
+		level:   NewLevelVar(level),
+		exit:    os.Exit,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return
+}
+
+// Enabled 是否输出指定级别的日志
+func (l *DefaultLogger) Enabled(level Level) (ok bool) {
+	return level <= l.level.Level()
+}
+
+// SetLevel 设置日志级别，在运行时调用立即生效，作用于共用该级别的所有日志器
+func (l *DefaultLogger) SetLevel(level Level) {
+	l.level.Set(level)
+}
+
+// GetLevel 获取日志级别
+func (l *DefaultLogger) GetLevel() (level Level) {
+	return l.level.Level()
+}
+
+// Named 创建具名子日志器，名称以点号连接父日志器的名称，日志中附加 logger 字段
+//
+//	子日志器持有独立的级别，创建时使用父日志器的当前级别，已通过 SetLevel 设置过同名级别时使用设置的级别
+//	具名日志器的级别登记到全局，可以通过 SetLevel、LevelHandler、WatchLevelConfig 在运行时统一调整同名日志器
+func (l *DefaultLogger) Named(name string) (logger IStructuredLogger) {
+	if name == "" {
+		return l
+	}
+	child := *l
+	if l.name != "" {
+		child.name = l.name + "." + name
+	} else {
+		child.name = name
+	}
+	child.level = NewLevelVar(l.level.Level())
+	registerNamedLevel(child.name, child.level)
+	return &child
+}
+
+// NamedLogger 创建具名子日志器，logger 不支持 Named 时原样返回，如 gtklog.NamedLogger(logger, "gtkmq")
+func NamedLogger(logger ILogger, name string) (named ILogger) {
+	if nl, ok := logger.(interface {
+		Named(name string) IStructuredLogger
+	}); ok {
+		return nl.Named(name)
 	}
+	return logger
+}
+
+// With 创建附加了字段的子日志器，子日志器与父日志器共用输出
+func (l *DefaultLogger) With(args ...any) (logger IStructuredLogger) {
+	child := *l
+	child.fields = append(slices.Clip(l.fields), argsToFields(args)...)
+	return &child
 }
 
 // Debug 调试日志
 func (l *DefaultLogger) Debug(ctx context.Context, args ...any) {
-	if DebugLevel > l.level {
-		return
+	if l.Enabled(DebugLevel) {
+		l.log(ctx, DebugLevel, fmt.Sprint(args...), nil)
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[DEBUG] [%s] %s", caller, fmt.Sprint(args...))
 }
 
 // Debugf 调试日志
 func (l *DefaultLogger) Debugf(ctx context.Context, format string, args ...any) {
-	if DebugLevel > l.level {
-		return
+	if l.Enabled(DebugLevel) {
+		l.log(ctx, DebugLevel, fmt.Sprintf(format, args...), nil)
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[DEBUG] [%s] %s", caller, fmt.Sprintf(format, args...))
 }
 
 // Info 信息日志
 func (l *DefaultLogger) Info(ctx context.Context, args ...any) {
-	if InfoLevel > l.level {
-		return
+	if l.Enabled(InfoLevel) {
+		l.log(ctx, InfoLevel, fmt.Sprint(args...), nil)
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[INFO] [%s] %s", caller, fmt.Sprint(args...))
 }
 
 // Infof 信息日志
 func (l *DefaultLogger) Infof(ctx context.Context, format string, args ...any) {
-	if InfoLevel > l.level {
-		return
+	if l.Enabled(InfoLevel) {
+		l.log(ctx, InfoLevel, fmt.Sprintf(format, args...), nil)
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[INFO] [%s] %s", caller, fmt.Sprintf(format, args...))
 }
 
 // Warn 警告日志
 func (l *DefaultLogger) Warn(ctx context.Context, args ...any) {
-	if WarnLevel > l.level {
-		return
+	if l.Enabled(WarnLevel) {
+		l.log(ctx, WarnLevel, fmt.Sprint(args...), nil)
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[WARN] [%s] %s", caller, fmt.Sprint(args...))
 }
 
 // Warnf 警告日志
 func (l *DefaultLogger) Warnf(ctx context.Context, format string, args ...any) {
-	if WarnLevel > l.level {
-		return
+	if l.Enabled(WarnLevel) {
+		l.log(ctx, WarnLevel, fmt.Sprintf(format, args...), nil)
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[WARN] [%s] %s", caller, fmt.Sprintf(format, args...))
 }
 
 // Error 错误日志
 func (l *DefaultLogger) Error(ctx context.Context, args ...any) {
-	if ErrorLevel > l.level {
-		return
+	if l.Enabled(ErrorLevel) {
+		l.log(ctx, ErrorLevel, fmt.Sprint(args...), nil)
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[ERROR] [%s] %s", caller, fmt.Sprint(args...))
 }
 
 // Errorf 错误日志
 func (l *DefaultLogger) Errorf(ctx context.Context, format string, args ...any) {
-	if ErrorLevel > l.level {
-		return
+	if l.Enabled(ErrorLevel) {
+		l.log(ctx, ErrorLevel, fmt.Sprintf(format, args...), nil)
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[ERROR] [%s] %s", caller, fmt.Sprintf(format, args...))
 }
 
 // Trace 跟踪日志
 func (l *DefaultLogger) Trace(ctx context.Context, args ...any) {
-	if TraceLevel > l.level {
-		return
+	if l.Enabled(TraceLevel) {
+		l.log(ctx, TraceLevel, fmt.Sprint(args...), nil)
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[TRACE] [%s] %s", caller, fmt.Sprint(args...))
 }
 
 // Tracef 跟踪日志
 func (l *DefaultLogger) Tracef(ctx context.Context, format string, args ...any) {
-	if TraceLevel > l.level {
-		return
+	if l.Enabled(TraceLevel) {
+		l.log(ctx, TraceLevel, fmt.Sprintf(format, args...), nil)
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[TRACE] [%s] %s", caller, fmt.Sprintf(format, args...))
 }
 
-// Fatal 致命日志
+// Fatal 致命日志，输出并刷新日志输出后调用 os.Exit(1)
 func (l *DefaultLogger) Fatal(ctx context.Context, args ...any) {
-	if FatalLevel > l.level {
-		return
+	if l.log(ctx, FatalLevel, fmt.Sprint(args...), nil) {
+		l.fatalExit()
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[FATAL] [%s] %s", caller, fmt.Sprint(args...))
-	os.Exit(1)
 }
 
-// Fatalf 致命日志
+// Fatalf 致命日志，输出并刷新日志输出后调用 os.Exit(1)
 func (l *DefaultLogger) Fatalf(ctx context.Context, format string, args ...any) {
-	if FatalLevel > l.level {
-		return
+	if l.log(ctx, FatalLevel, fmt.Sprintf(format, args...), nil) {
+		l.fatalExit()
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[FATAL] [%s] %s", caller, fmt.Sprintf(format, args...))
-	os.Exit(1)
 }
 
-// Panic 恐慌日志
+// Panic 恐慌日志，输出后 panic
 func (l *DefaultLogger) Panic(ctx context.Context, args ...any) {
-	if PanicLevel > l.level {
-		return
+	msg := fmt.Sprint(args...)
+	if l.log(ctx, PanicLevel, msg, nil) {
+		panic(msg)
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[PANIC] [%s] %s", caller, fmt.Sprint(args...))
-	panic(fmt.Sprint(args...))
 }
 
-// Panicf 恐慌日志
+// Panicf 恐慌日志，输出后 panic
 func (l *DefaultLogger) Panicf(ctx context.Context, format string, args ...any) {
-	if PanicLevel > l.level {
+	msg := fmt.Sprintf(format, args...)
+	if l.log(ctx, PanicLevel, msg, nil) {
+		panic(msg)
+	}
+}
+
+// DebugKV 调试日志，args 为键值对，如 DebugKV(ctx, "message", "key", value)
+func (l *DefaultLogger) DebugKV(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, DebugLevel, msg, args)
+}
+
+// InfoKV 信息日志，args 为键值对，如 InfoKV(ctx, "message", "key", value)
+func (l *DefaultLogger) InfoKV(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, InfoLevel, msg, args)
+}
+
+// WarnKV 警告日志，args 为键值对，如 WarnKV(ctx, "message", "key", value)
+func (l *DefaultLogger) WarnKV(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, WarnLevel, msg, args)
+}
+
+// ErrorKV 错误日志，args 为键值对，如 ErrorKV(ctx, "message", "key", value)
+func (l *DefaultLogger) ErrorKV(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, ErrorLevel, msg, args)
+}
+
+// log 输出日志，返回是否已输出
+func (l *DefaultLogger) log(ctx context.Context, level Level, msg string, args []any) (ok bool) {
+	if !l.Enabled(level) {
+		return false
+	}
+	return l.logAt(ctx, level, fileInfo(3), msg, args) // 跳过本函数、日志方法和调用者
+}
+
+// logAt 使用指定的调用者输出日志，供包装日志器保留原始调用者，返回是否已输出
+func (l *DefaultLogger) logAt(ctx context.Context, level Level, caller, msg string, args []any) (ok bool) {
+	if !l.Enabled(level) {
+		return false
+	}
+	l.write(ctx, &Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Caller:  caller,
+		Message: msg,
+		Fields:  argsToFields(args),
+	})
+	return true
+}
+
+// write 编码并输出日志条目，依次附加日志器名称、固定字段、上下文字段和日志字段
+func (l *DefaultLogger) write(ctx context.Context, entry *Entry) {
+	if l.sampler != nil && entry.Level > FatalLevel && !l.sampler.Sample(entry) {
+		return
+	}
+	fields := make([]Field, 0, len(l.fields)+len(entry.Fields)+3)
+	if l.name != "" {
+		fields = append(fields, Field{Key: "logger", Value: l.name})
+	}
+	fields = append(fields, l.fields...)
+	fields = append(fields, ContextFields(ctx)...)
+	entry.Fields = append(fields, entry.Fields...)
+
+	var buf bytes.Buffer
+	l.encoder.Encode(&buf, entry)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lw, ok := l.out.(LevelWriter); ok {
+		lw.WriteLevel(entry.Level, buf.Bytes())
 		return
 	}
-	caller := fileInfo(2) // 跳过本函数和调用者
-	l.logger.Printf("[PANIC] [%s] %s", caller, fmt.Sprintf(format, args...))
-	panic(fmt.Sprint(args...))
+	l.out.Write(buf.Bytes())
+}
+
+// fatalExit 刷新日志输出后退出，避免 AsyncWriter 等缓冲输出中的日志丢失
+func (l *DefaultLogger) fatalExit() {
+	if f, ok := l.out.(flusher); ok {
+		f.Flush()
+	}
+	l.exit(1)
 }
 
 // fileInfo 获取调用者的文件名和行号
@@ -10,8 +10,13 @@
 package gtklog_test
 
 import (
+	"bytes"
 	"context"
 	"github.com/liusuxian/go-toolkit/gtklog"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"strings"
+	"sync/atomic"
 	"testing"
 )
 
@@ -31,3 +36,36 @@ func TestDefaultLogger(t *testing.T) {
 	log.Trace(ctx, "hello world")
 	log.Tracef(ctx, "hello world %s", "world")
 }
+
+// countingStringer 记录被格式化次数的参数
+type countingStringer struct {
+	calls atomic.Int32
+}
+
+func (s *countingStringer) String() (str string) {
+	s.calls.Add(1)
+	return "arg"
+}
+
+func TestDisabledLevelSkipsFormatting(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.Background()
+		arg    = &countingStringer{}
+		buf    bytes.Buffer
+	)
+	// 未开启的级别不格式化参数
+	for _, log := range []gtklog.ILogger{
+		gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(&buf)),
+		gtklog.NewSlogLogger(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})),
+	} {
+		log.Debug(ctx, arg)
+		log.Debugf(ctx, "debug %s", arg)
+		log.Trace(ctx, arg)
+		log.Tracef(ctx, "trace %s", arg)
+		assert.Zero(arg.calls.Load())
+		log.Infof(ctx, "info %s", arg)
+		assert.Equal(int32(1), arg.calls.Swap(0))
+	}
+	assert.Equal(2, strings.Count(buf.String(), "info arg"))
+}
@@ -0,0 +1,318 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-24 14:32:51
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-24 14:32:51
+ * @Description: 按大小和时间切割的日志文件
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat 历史文件名中的时间格式
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateConfig 日志文件切割配置
+type RotateConfig struct {
+	Filename   string        // 日志文件路径，如 logs/app.log，目录不存在时自动创建
+	MaxSize    int64         // 单个文件的最大字节数，超过后切割，默认 100MB，小于 0 时不按大小切割
+	Interval   time.Duration // 按时间切割的间隔，如 24 * time.Hour 表示每天切割一次，为 0 时不按时间切割
+	MaxBackups int           // 保留的历史文件数，为 0 时不限制
+	MaxAge     time.Duration // 历史文件的保留时长，为 0 时不限制
+	Compress   bool          // 是否使用 gzip 压缩历史文件
+	LocalTime  bool          // 是否使用本地时间，影响历史文件名和按时间切割的对齐方式，默认使用 UTC
+}
+
+// RotatingFile 按大小和时间切割的日志文件，实现 io.WriteCloser，如 gtklog.WithOutput(file)
+//
+//	历史文件命名为 app-2006-01-02T15-04-05.000.log，压缩后追加 .gz 后缀
+//	压缩和清理历史文件在后台协程中进行，不阻塞写入
+type RotatingFile struct {
+	config     RotateConfig
+	mu         sync.Mutex
+	file       *os.File      // 当前文件
+	size       int64         // 当前文件大小
+	nextRotate time.Time     // 下次按时间切割的时间
+	closed     bool          // 是否已关闭
+	millCh     chan struct{} // 通知后台协程压缩和清理历史文件
+	wg         sync.WaitGroup
+}
+
+// NewRotatingFile 创建按大小和时间切割的日志文件
+func NewRotatingFile(config RotateConfig) (f *RotatingFile, err error) {
+	if config.Filename == "" {
+		return nil, errors.New("gtklog: rotate filename is empty")
+	}
+	if config.MaxSize == 0 {
+		config.MaxSize = 100 * 1024 * 1024
+	}
+	f = &RotatingFile{
+		config: config,
+		millCh: make(chan struct{}, 1),
+	}
+	if err = f.openFile(); err != nil {
+		return nil, err
+	}
+	f.wg.Add(1)
+	go f.millRun()
+	// 启动时清理一次历史文件
+	f.millCh <- struct{}{}
+	return
+}
+
+// Write 写入日志，需要时先切割文件
+func (f *RotatingFile) Write(p []byte) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	// 上次切割时打开新文件失败，重新打开
+	if f.file == nil {
+		if err = f.openFile(); err != nil {
+			return
+		}
+	}
+	if f.shouldRotate(int64(len(p))) {
+		// 切割失败但文件仍可写入时继续写入，下次写入时重试切割
+		if err = f.rotate(); err != nil && f.file == nil {
+			return
+		}
+	}
+	n, err = f.file.Write(p)
+	f.size += int64(n)
+	return
+}
+
+// Rotate 立即切割文件
+func (f *RotatingFile) Rotate() (err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Close 关闭文件，等待后台的压缩和清理完成
+func (f *RotatingFile) Close() (err error) {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+	f.closed = true
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	close(f.millCh)
+	f.mu.Unlock()
+
+	f.wg.Wait()
+	return
+}
+
+// now 获取当前时间
+func (f *RotatingFile) now() (t time.Time) {
+	if f.config.LocalTime {
+		return time.Now()
+	}
+	return time.Now().UTC()
+}
+
+// shouldRotate 是否需要切割文件
+func (f *RotatingFile) shouldRotate(writeLen int64) (ok bool) {
+	if f.config.MaxSize > 0 && f.size > 0 && f.size+writeLen > f.config.MaxSize {
+		return true
+	}
+	return f.config.Interval > 0 && !f.now().Before(f.nextRotate)
+}
+
+// openFile 打开日志文件，文件已存在时追加写入
+func (f *RotatingFile) openFile() (err error) {
+	if err = os.MkdirAll(filepath.Dir(f.config.Filename), 0755); err != nil {
+		return
+	}
+	var file *os.File
+	if file, err = os.OpenFile(f.config.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return
+	}
+	var info os.FileInfo
+	if info, err = file.Stat(); err != nil {
+		file.Close()
+		return
+	}
+	f.file, f.size = file, info.Size()
+	if f.config.Interval > 0 {
+		// 按时间间隔对齐，如每天切割时在零点切割
+		now := f.now()
+		_, offset := now.Zone()
+		shift := time.Duration(offset) * time.Second
+		f.nextRotate = now.Add(shift).Truncate(f.config.Interval).Add(f.config.Interval).Add(-shift)
+	}
+	return
+}
+
+// rotate 切割文件，将当前文件重命名为历史文件后创建新文件
+//
+//	重命名失败时重新打开原文件继续写入，打开新文件失败时 f.file 为空，下次写入时重新打开
+func (f *RotatingFile) rotate() (err error) {
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+		if err != nil {
+			return
+		}
+	}
+	if err = os.Rename(f.config.Filename, f.backupName(f.now())); err != nil && !errors.Is(err, os.ErrNotExist) {
+		if openErr := f.openFile(); openErr != nil {
+			err = errors.Join(err, openErr)
+		}
+		return
+	}
+	if err = f.openFile(); err != nil {
+		return
+	}
+	select {
+	case f.millCh <- struct{}{}:
+	default:
+	}
+	return
+}
+
+// backupName 获取历史文件名，同名文件已存在时时间后移 1 毫秒
+func (f *RotatingFile) backupName(t time.Time) (name string) {
+	dir, prefix, ext := f.nameParts()
+	for {
+		name = filepath.Join(dir, prefix+t.Format(backupTimeFormat)+ext)
+		if !fileExists(name) && !fileExists(name+".gz") {
+			return
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// nameParts 获取日志文件所在目录、历史文件名前缀和扩展名
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(f.config.Filename)
+	base := filepath.Base(f.config.Filename)
+	ext = filepath.Ext(base)
+	prefix = strings.TrimSuffix(base, ext) + "-"
+	return
+}
+
+// backupFile 历史文件
+type backupFile struct {
+	path string    // 文件路径
+	time time.Time // 切割时间
+}
+
+// millRun 后台压缩和清理历史文件
+func (f *RotatingFile) millRun() {
+	defer f.wg.Done()
+
+	for range f.millCh {
+		f.millRunOnce()
+	}
+}
+
+// millRunOnce 按保留数量和保留时长删除历史文件，并压缩未压缩的历史文件
+func (f *RotatingFile) millRunOnce() {
+	backups := f.listBackups()
+	var cutoff time.Time
+	if f.config.MaxAge > 0 {
+		cutoff = f.now().Add(-f.config.MaxAge)
+	}
+	for i, backup := range backups {
+		if (f.config.MaxBackups > 0 && i >= f.config.MaxBackups) || (!cutoff.IsZero() && backup.time.Before(cutoff)) {
+			os.Remove(backup.path)
+			continue
+		}
+		if f.config.Compress && !strings.HasSuffix(backup.path, ".gz") {
+			compressFile(backup.path)
+		}
+	}
+}
+
+// listBackups 列出历史文件，按切割时间从新到旧排列
+func (f *RotatingFile) listBackups() (backups []backupFile) {
+	dir, prefix, ext := f.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(name, prefix)
+		ts = strings.TrimSuffix(ts, ".gz")
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		var t time.Time
+		if f.config.LocalTime {
+			t, err = time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(ts, ext), time.Local)
+		} else {
+			t, err = time.Parse(backupTimeFormat, strings.TrimSuffix(ts, ext))
+		}
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), time: t})
+	}
+	slices.SortFunc(backups, func(a, b backupFile) int {
+		return b.time.Compare(a.time)
+	})
+	return
+}
+
+// compressFile 使用 gzip 压缩文件，压缩成功后删除原文件
+func compressFile(src string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+
+	dst := src + ".gz"
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return
+	}
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err == nil {
+		err = gz.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return
+	}
+	in.Close()
+	return os.Remove(src)
+}
+
+// fileExists 文件是否存在
+func fileExists(name string) (ok bool) {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,99 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-25 09:47:13
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-25 09:47:13
+ * @Description: 重复日志采样
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+)
+
+// sampleBuckets 采样计数器的数量，不同日志按哈希分配到计数器，哈希冲突的日志共用计数
+const sampleBuckets = 4096
+
+// Sampler 日志采样器接口，致命和恐慌日志不参与采样
+type Sampler interface {
+	Sample(entry *Entry) (ok bool) // 是否输出该日志
+}
+
+// SamplerConfig 重复日志采样配置
+type SamplerConfig struct {
+	Interval   time.Duration // 采样周期，默认 1 秒
+	First      int           // 每个周期内相同日志（级别和调用者相同）先输出的条数，默认 10
+	Thereafter int           // 超过 First 条后每 Thereafter 条输出 1 条，为 0 时丢弃剩余的日志
+}
+
+// sampleCounter 采样计数器
+type sampleCounter struct {
+	resetAt atomic.Int64  // 计数重置时间（纳秒）
+	count   atomic.Uint64 // 当前周期内的计数
+}
+
+// RateSampler 重复日志采样器，限制每个周期内相同日志的输出条数，避免循环中的重复错误刷屏
+type RateSampler struct {
+	config   SamplerConfig
+	counters [sampleBuckets]sampleCounter
+	dropped  atomic.Uint64
+}
+
+// NewRateSampler 创建重复日志采样器
+func NewRateSampler(config SamplerConfig) (s *RateSampler) {
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.First <= 0 {
+		config.First = 10
+	}
+	return &RateSampler{config: config}
+}
+
+// Sample 是否输出该日志
+//
+//	相同日志按级别和调用者判断，同一行代码输出的日志即使参数（如重试次数、偏移量）不同也视为相同日志，没有调用者时按日志内容判断
+func (s *RateSampler) Sample(entry *Entry) (ok bool) {
+	h := fnv.New32a()
+	h.Write([]byte{byte(entry.Level)})
+	if entry.Caller != "" {
+		h.Write([]byte(entry.Caller))
+	} else {
+		h.Write([]byte(entry.Message))
+	}
+	counter := &s.counters[h.Sum32()%sampleBuckets]
+
+	n := counter.incr(entry.Time, s.config.Interval)
+	if n <= uint64(s.config.First) {
+		return true
+	}
+	if s.config.Thereafter > 0 && (n-uint64(s.config.First))%uint64(s.config.Thereafter) == 0 {
+		return true
+	}
+	s.dropped.Add(1)
+	return false
+}
+
+// Dropped 获取被丢弃的日志数
+func (s *RateSampler) Dropped() (count uint64) {
+	return s.dropped.Load()
+}
+
+// incr 计数加 1，超过重置时间时从 1 重新计数
+func (c *sampleCounter) incr(t time.Time, interval time.Duration) (n uint64) {
+	now := t.UnixNano()
+	resetAt := c.resetAt.Load()
+	if resetAt > now {
+		return c.count.Add(1)
+	}
+	c.count.Store(1)
+	if !c.resetAt.CompareAndSwap(resetAt, now+interval.Nanoseconds()) {
+		// 其他协程已重置
+		return c.count.Add(1)
+	}
+	return 1
+}
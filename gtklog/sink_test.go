@@ -0,0 +1,261 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-24 14:32:51
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-24 14:32:51
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"github.com/liusuxian/go-toolkit/gtklog"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		dir      = t.TempDir()
+		filename = filepath.Join(dir, "logs", "app.log")
+	)
+	file, err := gtklog.NewRotatingFile(gtklog.RotateConfig{
+		Filename:   filename,
+		MaxSize:    20,
+		MaxBackups: 2,
+		Compress:   true,
+	})
+	if !assert.NoError(err) {
+		return
+	}
+	for _, line := range []string{"line-0000001\n", "line-0000002\n", "line-0000003\n", "line-0000004\n"} {
+		_, err = file.Write([]byte(line))
+		assert.NoError(err)
+	}
+	assert.NoError(file.Close())
+	_, err = file.Write([]byte("closed"))
+	assert.ErrorIs(err, os.ErrClosed)
+	// 当前文件只保留最后一行，历史文件保留 2 个且已压缩
+	content, err := os.ReadFile(filename)
+	assert.NoError(err)
+	assert.Equal("line-0000004\n", string(content))
+	backups, err := filepath.Glob(filepath.Join(dir, "logs", "app-*.log.gz"))
+	assert.NoError(err)
+	assert.Len(backups, 2)
+	var lines []string
+	for _, backup := range backups {
+		f, err := os.Open(backup)
+		assert.NoError(err)
+		gz, err := gzip.NewReader(f)
+		assert.NoError(err)
+		data, err := io.ReadAll(gz)
+		assert.NoError(err)
+		f.Close()
+		lines = append(lines, string(data))
+	}
+	assert.ElementsMatch([]string{"line-0000002\n", "line-0000003\n"}, lines)
+	// 按时间切割
+	timeFile, err := gtklog.NewRotatingFile(gtklog.RotateConfig{Filename: filepath.Join(dir, "time.log"), Interval: 50 * time.Millisecond})
+	if !assert.NoError(err) {
+		return
+	}
+	timeFile.Write([]byte("before\n"))
+	time.Sleep(60 * time.Millisecond)
+	timeFile.Write([]byte("after\n"))
+	assert.NoError(timeFile.Close())
+	backups, _ = filepath.Glob(filepath.Join(dir, "time-*.log"))
+	assert.Len(backups, 1)
+	content, _ = os.ReadFile(filepath.Join(dir, "time.log"))
+	assert.Equal("after\n", string(content))
+}
+
+func TestRotatingFileRecover(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		dir      = filepath.Join(t.TempDir(), "logs")
+		filename = filepath.Join(dir, "app.log")
+	)
+	file, err := gtklog.NewRotatingFile(gtklog.RotateConfig{Filename: filename, MaxSize: 10})
+	if !assert.NoError(err) {
+		return
+	}
+	defer file.Close()
+	_, err = file.Write([]byte("line-0001\n"))
+	assert.NoError(err)
+	// 日志目录被替换为普通文件，切割时重命名和打开文件均失败
+	assert.NoError(os.Rename(dir, dir+".bak"))
+	assert.NoError(os.WriteFile(dir, nil, 0644))
+	_, err = file.Write([]byte("line-0002\n"))
+	assert.Error(err)
+	_, err = file.Write([]byte("line-0003\n"))
+	assert.Error(err)
+	// 目录恢复后继续写入
+	assert.NoError(os.Remove(dir))
+	assert.NoError(os.Rename(dir+".bak", dir))
+	_, err = file.Write([]byte("line-0004\n"))
+	assert.NoError(err)
+	_, err = file.Write([]byte("line-0005\n"))
+	assert.NoError(err)
+	assert.NoError(file.Close())
+	content, err := os.ReadFile(filename)
+	assert.NoError(err)
+	assert.Equal("line-0005\n", string(content))
+	// 切割失败前写入的日志没有丢失
+	backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	assert.Len(backups, 2)
+}
+
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (n int, err error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() (s string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		w       = &blockingWriter{release: make(chan struct{})}
+		dropped []string
+	)
+	async := gtklog.NewAsyncWriter(w, gtklog.AsyncWriterConfig{
+		QueueSize:  2,
+		DropPolicy: gtklog.DropOldest,
+		OnDrop:     func(data []byte) { dropped = append(dropped, string(data)) },
+	})
+	// 第一条被后台协程取出并阻塞在写入，队列中保留最新的 2 条
+	async.Write([]byte("1"))
+	assert.Eventually(func() bool {
+		async.Write([]byte("probe"))
+		return async.Dropped() > 0
+	}, time.Second, time.Millisecond)
+	async.Write([]byte("2"))
+	async.Write([]byte("3"))
+	close(w.release)
+	assert.NoError(async.Flush())
+	assert.True(strings.HasPrefix(w.String(), "1"))
+	assert.True(strings.HasSuffix(w.String(), "23"))
+	assert.Equal(int(async.Dropped()), len(dropped))
+	assert.NoError(async.Close())
+	_, err := async.Write([]byte("4"))
+	assert.ErrorIs(err, os.ErrClosed)
+}
+
+func TestLevelTee(t *testing.T) {
+	var (
+		assert           = assert.New(t)
+		ctx              = context.Background()
+		all, errs, debug bytes.Buffer
+	)
+	tee := gtklog.NewLevelTee().
+		Route(&all).
+		RouteAtLeast(&errs, gtklog.ErrorLevel).
+		Route(&debug, gtklog.DebugLevel)
+	async := gtklog.NewAsyncWriter(tee, gtklog.AsyncWriterConfig{DropPolicy: gtklog.DropNone})
+	log := gtklog.NewDefaultLogger(gtklog.DebugLevel, gtklog.WithOutput(async))
+	log.Debug(ctx, "debug message")
+	log.Info(ctx, "info message")
+	log.Errorf(ctx, "error message")
+	assert.NoError(async.Close())
+	assert.Equal(3, strings.Count(all.String(), "\n"))
+	assert.Equal(1, strings.Count(errs.String(), "\n"))
+	assert.Contains(errs.String(), "[ERROR]")
+	assert.Equal(1, strings.Count(debug.String(), "\n"))
+	assert.Contains(debug.String(), "[DEBUG]")
+	// 没有级别信息的日志分发到所有输出
+	tee.Write([]byte("raw\n"))
+	assert.Contains(errs.String(), "raw\n")
+	assert.NoError(tee.Close())
+}
+
+// slowWriter 每次写入都等待一段时间的 Writer
+type slowWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (n int, err error) {
+	time.Sleep(10 * time.Millisecond)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) String() (s string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestFatalFlush(t *testing.T) {
+	var (
+		assert  = assert.New(t)
+		ctx     = context.Background()
+		w       = &slowWriter{}
+		async   = gtklog.NewAsyncWriter(w, gtklog.AsyncWriterConfig{DropPolicy: gtklog.DropNone})
+		code    = -1
+		written string
+	)
+	defer async.Close()
+	// 退出前刷新输出，LevelTee 将刷新传递给 AsyncWriter，队列中的日志和致命日志都不会丢失
+	log := gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(gtklog.NewLevelTee().Route(async)), gtklog.WithExitFunc(func(c int) {
+		code, written = c, w.String()
+	}))
+	log.Info(ctx, "queued 1")
+	log.Info(ctx, "queued 2")
+	log.Fatalf(ctx, "fatal %s", "message")
+	assert.Equal(1, code)
+	assert.Contains(written, "queued 1")
+	assert.Contains(written, "queued 2")
+	assert.Contains(written, "[FATAL]")
+	assert.Contains(written, "fatal message")
+}
+
+func TestCloseSkipsStdStreams(t *testing.T) {
+	assert := assert.New(t)
+	// 替换标准输出，避免测试失败时关闭真正的标准输出
+	r, w, err := os.Pipe()
+	if !assert.NoError(err) {
+		return
+	}
+	defer r.Close()
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	var file bytes.Buffer
+	tee := gtklog.NewLevelTee().Route(os.Stdout).Route(os.Stderr).RouteAtLeast(&file, gtklog.ErrorLevel)
+	async := gtklog.NewAsyncWriter(tee, gtklog.AsyncWriterConfig{})
+	assert.NoError(async.Close())
+	assert.NoError(gtklog.NewAsyncWriter(os.Stdout, gtklog.AsyncWriterConfig{}).Close())
+	// 关闭日志输出后标准输出仍然可以写入
+	_, err = w.Write([]byte("still open\n"))
+	assert.NoError(err)
+	assert.NoError(w.Close())
+	data, err := io.ReadAll(r)
+	assert.NoError(err)
+	assert.Equal("still open\n", string(data))
+}
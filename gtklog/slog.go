@@ -0,0 +1,349 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-24 10:08:26
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-24 10:08:26
+ * @Description: 与标准库 log/slog 的双向桥接
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+const (
+	SlogLevelTrace slog.Level = slog.LevelDebug - 4 // 跟踪日志对应的 slog 级别
+	SlogLevelFatal slog.Level = slog.LevelError + 4 // 致命日志对应的 slog 级别
+	SlogLevelPanic slog.Level = slog.LevelError + 8 // 恐慌日志对应的 slog 级别
+)
+
+// LevelToSlog 将日志级别转换为 slog 级别
+func LevelToSlog(level Level) (slogLevel slog.Level) {
+	switch level {
+	case PanicLevel:
+		return SlogLevelPanic
+	case FatalLevel:
+		return SlogLevelFatal
+	case ErrorLevel:
+		return slog.LevelError
+	case WarnLevel:
+		return slog.LevelWarn
+	case InfoLevel:
+		return slog.LevelInfo
+	case DebugLevel:
+		return slog.LevelDebug
+	default:
+		return SlogLevelTrace
+	}
+}
+
+// LevelFromSlog 将 slog 级别转换为日志级别，高于 slog.LevelError 的级别均转换为 ErrorLevel，避免通过 slog 触发退出或 panic
+func LevelFromSlog(slogLevel slog.Level) (level Level) {
+	switch {
+	case slogLevel < slog.LevelDebug:
+		return TraceLevel
+	case slogLevel < slog.LevelInfo:
+		return DebugLevel
+	case slogLevel < slog.LevelWarn:
+		return InfoLevel
+	case slogLevel < slog.LevelError:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
+
+// slogHandler 将 slog 日志转发到 ILogger 的 slog.Handler
+type slogHandler struct {
+	logger ILogger // 日志器
+	fields []Field // WithAttrs 附加的字段
+	prefix string  // WithGroup 产生的字段前缀，如 "group."
+}
+
+// NewSlogHandler 创建将日志转发到 logger 的 slog.Handler，如 slog.New(gtklog.NewSlogHandler(logger))
+//
+//	分组属性展开为以点号连接的键，如 group.key
+//	logger 未实现 IStructuredLogger 时，属性以 key=value 的形式追加到日志内容中
+func NewSlogHandler(logger ILogger) (h slog.Handler) {
+	return &slogHandler{logger: logger}
+}
+
+// Enabled 是否输出指定级别的日志
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) (ok bool) {
+	if l, ok := h.logger.(interface{ Enabled(level Level) bool }); ok {
+		return l.Enabled(LevelFromSlog(level))
+	}
+	return true
+}
+
+// Handle 处理日志记录
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) (err error) {
+	fields := make([]Field, 0, len(h.fields)+r.NumAttrs())
+	fields = append(fields, h.fields...)
+	r.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, attr)
+		return true
+	})
+	level := LevelFromSlog(r.Level)
+	// 默认日志器直接输出，使用 slog 记录的调用者
+	if l, ok := h.logger.(*DefaultLogger); ok {
+		if !l.Enabled(level) {
+			return
+		}
+		l.write(ctx, &Entry{
+			Time:    r.Time,
+			Level:   level,
+			Caller:  pcFileInfo(r.PC),
+			Message: r.Message,
+			Fields:  fields,
+		})
+		return
+	}
+	logger, msg := h.logger, r.Message
+	if sl, ok := logger.(IStructuredLogger); ok {
+		if len(fields) > 0 {
+			logger = sl.With(fields)
+		}
+	} else {
+		msg = appendFieldsText(msg, fields)
+	}
+	switch level {
+	case TraceLevel:
+		logger.Trace(ctx, msg)
+	case DebugLevel:
+		logger.Debug(ctx, msg)
+	case InfoLevel:
+		logger.Info(ctx, msg)
+	case WarnLevel:
+		logger.Warn(ctx, msg)
+	default:
+		logger.Error(ctx, msg)
+	}
+	return
+}
+
+// WithAttrs 创建附加了属性的 Handler
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) (handler slog.Handler) {
+	child := *h
+	child.fields = make([]Field, 0, len(h.fields)+len(attrs))
+	child.fields = append(child.fields, h.fields...)
+	for _, attr := range attrs {
+		child.fields = appendAttr(child.fields, h.prefix, attr)
+	}
+	return &child
+}
+
+// WithGroup 创建附加了分组的 Handler
+func (h *slogHandler) WithGroup(name string) (handler slog.Handler) {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.prefix = h.prefix + name + "."
+	return &child
+}
+
+// appendAttr 将 slog 属性转换为字段，分组属性展开为以点号连接的键
+func appendAttr(fields []Field, prefix string, attr slog.Attr) (result []Field) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, a := range attr.Value.Group() {
+			fields = appendAttr(fields, prefix, a)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + attr.Key, Value: attr.Value.Any()})
+}
+
+// pcFileInfo 获取程序计数器对应的文件名和行号
+func pcFileInfo(pc uintptr) (caller string) {
+	if pc == 0 {
+		return "<???>:1"
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+}
+
+// SlogLogger 将日志转发到 slog.Handler 的日志器，实现 IStructuredLogger
+//
+//	跟踪、致命、恐慌日志分别使用 SlogLevelTrace、SlogLevelFatal、SlogLevelPanic 级别
+//	上下文中的字段（如请求ID）通过 RegisterContextExtractor 注册的函数自动提取
+type SlogLogger struct {
+	handler slog.Handler // slog 处理器
+}
+
+// NewSlogLogger 创建将日志转发到 handler 的日志器，如 gtklog.NewSlogLogger(slog.Default().Handler())
+func NewSlogLogger(handler slog.Handler) (l *SlogLogger) {
+	return &SlogLogger{handler: handler}
+}
+
+// Handler 获取 slog 处理器
+func (l *SlogLogger) Handler() (handler slog.Handler) {
+	return l.handler
+}
+
+// Enabled 是否输出指定级别的日志
+func (l *SlogLogger) Enabled(level Level) (ok bool) {
+	return l.handler.Enabled(context.Background(), LevelToSlog(level))
+}
+
+// With 创建附加了字段的子日志器
+func (l *SlogLogger) With(args ...any) (logger IStructuredLogger) {
+	fields := argsToFields(args)
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		attrs = append(attrs, slog.Any(field.Key, field.Value))
+	}
+	return &SlogLogger{handler: l.handler.WithAttrs(attrs)}
+}
+
+// Debug 调试日志
+func (l *SlogLogger) Debug(ctx context.Context, args ...any) {
+	if l.Enabled(DebugLevel) {
+		l.log(ctx, DebugLevel, fmt.Sprint(args...), nil)
+	}
+}
+
+// Debugf 调试日志
+func (l *SlogLogger) Debugf(ctx context.Context, format string, args ...any) {
+	if l.Enabled(DebugLevel) {
+		l.log(ctx, DebugLevel, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Info 信息日志
+func (l *SlogLogger) Info(ctx context.Context, args ...any) {
+	if l.Enabled(InfoLevel) {
+		l.log(ctx, InfoLevel, fmt.Sprint(args...), nil)
+	}
+}
+
+// Infof 信息日志
+func (l *SlogLogger) Infof(ctx context.Context, format string, args ...any) {
+	if l.Enabled(InfoLevel) {
+		l.log(ctx, InfoLevel, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Warn 警告日志
+func (l *SlogLogger) Warn(ctx context.Context, args ...any) {
+	if l.Enabled(WarnLevel) {
+		l.log(ctx, WarnLevel, fmt.Sprint(args...), nil)
+	}
+}
+
+// Warnf 警告日志
+func (l *SlogLogger) Warnf(ctx context.Context, format string, args ...any) {
+	if l.Enabled(WarnLevel) {
+		l.log(ctx, WarnLevel, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Error 错误日志
+func (l *SlogLogger) Error(ctx context.Context, args ...any) {
+	if l.Enabled(ErrorLevel) {
+		l.log(ctx, ErrorLevel, fmt.Sprint(args...), nil)
+	}
+}
+
+// Errorf 错误日志
+func (l *SlogLogger) Errorf(ctx context.Context, format string, args ...any) {
+	if l.Enabled(ErrorLevel) {
+		l.log(ctx, ErrorLevel, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Trace 跟踪日志
+func (l *SlogLogger) Trace(ctx context.Context, args ...any) {
+	if l.Enabled(TraceLevel) {
+		l.log(ctx, TraceLevel, fmt.Sprint(args...), nil)
+	}
+}
+
+// Tracef 跟踪日志
+func (l *SlogLogger) Tracef(ctx context.Context, format string, args ...any) {
+	if l.Enabled(TraceLevel) {
+		l.log(ctx, TraceLevel, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// Fatal 致命日志，输出后调用 os.Exit(1)
+func (l *SlogLogger) Fatal(ctx context.Context, args ...any) {
+	l.log(ctx, FatalLevel, fmt.Sprint(args...), nil)
+	os.Exit(1)
+}
+
+// Fatalf 致命日志，输出后调用 os.Exit(1)
+func (l *SlogLogger) Fatalf(ctx context.Context, format string, args ...any) {
+	l.log(ctx, FatalLevel, fmt.Sprintf(format, args...), nil)
+	os.Exit(1)
+}
+
+// Panic 恐慌日志，输出后 panic
+func (l *SlogLogger) Panic(ctx context.Context, args ...any) {
+	msg := fmt.Sprint(args...)
+	l.log(ctx, PanicLevel, msg, nil)
+	panic(msg)
+}
+
+// Panicf 恐慌日志，输出后 panic
+func (l *SlogLogger) Panicf(ctx context.Context, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	l.log(ctx, PanicLevel, msg, nil)
+	panic(msg)
+}
+
+// DebugKV 调试日志，args 为键值对
+func (l *SlogLogger) DebugKV(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, DebugLevel, msg, args)
+}
+
+// InfoKV 信息日志，args 为键值对
+func (l *SlogLogger) InfoKV(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, InfoLevel, msg, args)
+}
+
+// WarnKV 警告日志，args 为键值对
+func (l *SlogLogger) WarnKV(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, WarnLevel, msg, args)
+}
+
+// ErrorKV 错误日志，args 为键值对
+func (l *SlogLogger) ErrorKV(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, ErrorLevel, msg, args)
+}
+
+// log 输出日志
+func (l *SlogLogger) log(ctx context.Context, level Level, msg string, args []any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	slogLevel := LevelToSlog(level)
+	if !l.handler.Enabled(ctx, slogLevel) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // 跳过 runtime.Callers、本函数和日志方法
+	r := slog.NewRecord(time.Now(), slogLevel, msg, pcs[0])
+	for _, field := range ContextFields(ctx) {
+		r.AddAttrs(slog.Any(field.Key, field.Value))
+	}
+	for _, field := range argsToFields(args) {
+		r.AddAttrs(slog.Any(field.Key, field.Value))
+	}
+	l.handler.Handle(ctx, r)
+}
@@ -0,0 +1,157 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-24 10:08:26
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-24 10:08:26
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/liusuxian/go-toolkit/gtklog"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type ctxUserKey struct{}
+
+func init() {
+	gtklog.RegisterContextExtractor(func(ctx context.Context) (fields []gtklog.Field) {
+		if user, ok := ctx.Value(ctxUserKey{}).(string); ok {
+			fields = append(fields, gtklog.F("ctx_user", user))
+		}
+		return
+	})
+}
+
+func TestStructuredLogger(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.WithValue(context.Background(), ctxUserKey{}, "u1")
+		buf    bytes.Buffer
+	)
+	// 文本格式兼容原有输出
+	log := gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(&buf))
+	log.Infof(context.Background(), "hello %s", "world")
+	log.Debug(context.Background(), "hidden")
+	assert.Regexp(regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} \[INFO\] \[structured_test\.go:\d+\] hello world\n$`), buf.String())
+	// 键值对与子日志器
+	buf.Reset()
+	log.With("service", "chat").InfoKV(ctx, "request done", "cost", 12, gtklog.F("msg text", "a b"), "dangling")
+	line := buf.String()
+	assert.Contains(line, "[INFO] [structured_test.go:")
+	assert.True(strings.HasSuffix(line, `] request done service=chat ctx_user=u1 cost=12 msg text="a b" !BADKEY=dangling`+"\n"), line)
+	// JSON 格式
+	buf.Reset()
+	jsonLog := gtklog.NewDefaultLogger(gtklog.DebugLevel, gtklog.WithOutput(&buf), gtklog.WithEncoder(gtklog.JSONEncoder{}), gtklog.WithFields("app", "demo"))
+	jsonLog.ErrorKV(ctx, "failed", "err", errors.New("boom"), "n", 1)
+	var m map[string]any
+	assert.NoError(json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal("error", m["level"])
+	assert.Equal("failed", m["msg"])
+	assert.Equal("demo", m["app"])
+	assert.Equal("u1", m["ctx_user"])
+	assert.Equal("boom", m["err"])
+	assert.Equal(float64(1), m["n"])
+	assert.Contains(m["caller"], "structured_test.go:")
+	// logfmt 格式
+	buf.Reset()
+	logfmtLog := gtklog.NewDefaultLogger(gtklog.DebugLevel, gtklog.WithOutput(&buf), gtklog.WithEncoder(gtklog.LogfmtEncoder{}))
+	logfmtLog.WarnKV(context.Background(), "slow query", "sql", `select "a"`, "empty", "")
+	assert.Regexp(regexp.MustCompile(`^time=\S+ level=warn caller=structured_test\.go:\d+ msg="slow query" sql="select \\"a\\"" empty=""\n$`), buf.String())
+	// 日志级别
+	level, err := gtklog.ParseLevel("WARNING")
+	assert.NoError(err)
+	assert.Equal(gtklog.WarnLevel, level)
+	assert.Equal("WARN", level.CapitalString())
+	_, err = gtklog.ParseLevel("verbose")
+	assert.Error(err)
+	assert.Panics(func() { log.Panicf(ctx, "oops %d", 1) })
+}
+
+func TestSlogBridge(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		ctx    = context.WithValue(context.Background(), ctxUserKey{}, "u1")
+		buf    bytes.Buffer
+	)
+	// slog -> ILogger
+	log := gtklog.NewDefaultLogger(gtklog.InfoLevel, gtklog.WithOutput(&buf), gtklog.WithEncoder(gtklog.LogfmtEncoder{}))
+	logger := slog.New(gtklog.NewSlogHandler(log)).With("app", "demo").WithGroup("req")
+	logger.DebugContext(ctx, "hidden")
+	logger.InfoContext(ctx, "handled", "status", 200, slog.Group("user", "id", 7))
+	line := buf.String()
+	assert.Contains(line, "level=info caller=structured_test.go:")
+	assert.Contains(line, "msg=handled ctx_user=u1 app=demo req.status=200 req.user.id=7\n")
+	// slog -> 未实现结构化接口的 ILogger
+	capture := &captureLogger{}
+	slog.New(gtklog.NewSlogHandler(capture)).Warn("retry", "attempt", 2)
+	assert.Equal([]string{"WARN retry attempt=2"}, capture.lines)
+	// ILogger -> slog
+	buf.Reset()
+	slogLog := gtklog.NewSlogLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true, Level: gtklog.SlogLevelTrace}))
+	slogLog.With("app", "demo").InfoKV(ctx, "done", "cost", 3)
+	var m map[string]any
+	assert.NoError(json.Unmarshal(buf.Bytes(), &m))
+	assert.Equal("INFO", m["level"])
+	assert.Equal("done", m["msg"])
+	assert.Equal("demo", m["app"])
+	assert.Equal("u1", m["ctx_user"])
+	assert.Equal(float64(3), m["cost"])
+	source, _ := m["source"].(map[string]any)
+	assert.Contains(source["file"], "structured_test.go")
+	buf.Reset()
+	slogLog.Tracef(ctx, "trace %d", 1)
+	assert.Contains(buf.String(), `"level":"DEBUG-4"`)
+	assert.True(slogLog.Enabled(gtklog.TraceLevel))
+}
+
+type captureLogger struct {
+	lines []string
+}
+
+func (l *captureLogger) record(level string, args ...any) {
+	var sb strings.Builder
+	for _, arg := range args {
+		sb.WriteString(arg.(string))
+	}
+	l.lines = append(l.lines, level+" "+sb.String())
+}
+
+func (l *captureLogger) Debug(ctx context.Context, args ...any) { l.record("DEBUG", args...) }
+func (l *captureLogger) Debugf(ctx context.Context, format string, args ...any) {
+	l.record("DEBUG", format)
+}
+func (l *captureLogger) Info(ctx context.Context, args ...any) { l.record("INFO", args...) }
+func (l *captureLogger) Infof(ctx context.Context, format string, args ...any) {
+	l.record("INFO", format)
+}
+func (l *captureLogger) Warn(ctx context.Context, args ...any) { l.record("WARN", args...) }
+func (l *captureLogger) Warnf(ctx context.Context, format string, args ...any) {
+	l.record("WARN", format)
+}
+func (l *captureLogger) Error(ctx context.Context, args ...any) { l.record("ERROR", args...) }
+func (l *captureLogger) Errorf(ctx context.Context, format string, args ...any) {
+	l.record("ERROR", format)
+}
+func (l *captureLogger) Trace(ctx context.Context, args ...any) { l.record("TRACE", args...) }
+func (l *captureLogger) Tracef(ctx context.Context, format string, args ...any) {
+	l.record("TRACE", format)
+}
+func (l *captureLogger) Fatal(ctx context.Context, args ...any) { l.record("FATAL", args...) }
+func (l *captureLogger) Fatalf(ctx context.Context, format string, args ...any) {
+	l.record("FATAL", format)
+}
+func (l *captureLogger) Panic(ctx context.Context, args ...any) { l.record("PANIC", args...) }
+func (l *captureLogger) Panicf(ctx context.Context, format string, args ...any) {
+	l.record("PANIC", format)
+}
@@ -0,0 +1,134 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-24 14:32:51
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-24 14:32:51
+ * @Description: 按日志级别分发到多个输出
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtklog
+
+import (
+	"errors"
+	"io"
+	"os"
+	"slices"
+)
+
+// LevelWriter 支持日志级别的 Writer，DefaultLogger 的输出实现该接口时通过 WriteLevel 写入
+type LevelWriter interface {
+	io.Writer
+	WriteLevel(level Level, p []byte) (n int, err error) // 写入指定级别的日志
+}
+
+// flusher 支持刷新的输出，如 AsyncWriter、LevelTee
+type flusher interface {
+	Flush() (err error)
+}
+
+// levelRoute 输出路由
+type levelRoute struct {
+	w     io.Writer             // 输出
+	match func(Level) (ok bool) // 是否匹配日志级别
+}
+
+// LevelTee 按日志级别将日志分发到多个输出，实现 LevelWriter 和 io.Closer
+//
+//	如全部日志输出到控制台，错误日志同时输出到文件：
+//	tee := gtklog.NewLevelTee().Route(os.Stdout).RouteAtLeast(errorFile, gtklog.ErrorLevel)
+//	路由需要在开始写入前配置完成
+type LevelTee struct {
+	routes []levelRoute
+}
+
+// NewLevelTee 创建按日志级别分发的输出
+func NewLevelTee() (t *LevelTee) {
+	return &LevelTee{}
+}
+
+// Route 添加输出，接收指定级别的日志，未指定级别时接收全部日志
+func (t *LevelTee) Route(w io.Writer, levels ...Level) (tee *LevelTee) {
+	match := func(Level) bool { return true }
+	if len(levels) > 0 {
+		levels = slices.Clone(levels)
+		match = func(level Level) bool { return slices.Contains(levels, level) }
+	}
+	t.routes = append(t.routes, levelRoute{w: w, match: match})
+	return t
+}
+
+// RouteAtLeast 添加输出，接收不低于指定级别的日志，如 ErrorLevel 接收错误、致命、恐慌日志
+func (t *LevelTee) RouteAtLeast(w io.Writer, level Level) (tee *LevelTee) {
+	t.routes = append(t.routes, levelRoute{w: w, match: func(l Level) bool { return l <= level }})
+	return t
+}
+
+// Write 写入日志，没有级别信息的日志分发到所有输出
+func (t *LevelTee) Write(p []byte) (n int, err error) {
+	var errs []error
+	for _, route := range t.routes {
+		if _, e := route.w.Write(p); e != nil {
+			errs = append(errs, e)
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
+
+// WriteLevel 写入指定级别的日志，分发到匹配的输出，输出实现 LevelWriter 时保留级别信息
+func (t *LevelTee) WriteLevel(level Level, p []byte) (n int, err error) {
+	var errs []error
+	for _, route := range t.routes {
+		if !route.match(level) {
+			continue
+		}
+		var e error
+		if lw, ok := route.w.(LevelWriter); ok {
+			_, e = lw.WriteLevel(level, p)
+		} else {
+			_, e = route.w.Write(p)
+		}
+		if e != nil {
+			errs = append(errs, e)
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
+
+// Flush 刷新所有支持 Flush 的输出，如 AsyncWriter
+func (t *LevelTee) Flush() (err error) {
+	var errs []error
+	for _, route := range t.routes {
+		if f, ok := route.w.(flusher); ok {
+			if e := f.Flush(); e != nil {
+				errs = append(errs, e)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 关闭所有实现了 io.Closer 的输出，同一个输出只关闭一次，os.Stdout 和 os.Stderr 不会被关闭
+func (t *LevelTee) Close() (err error) {
+	var (
+		errs   []error
+		closed []io.Closer
+	)
+	for _, route := range t.routes {
+		closer, ok := route.w.(io.Closer)
+		if !ok || isStdStream(route.w) || slices.Contains(closed, closer) {
+			continue
+		}
+		closed = append(closed, closer)
+		if e := closer.Close(); e != nil {
+			errs = append(errs, e)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isStdStream 是否是标准输出或标准错误，它们属于进程而不属于日志输出，关闭日志输出时不能关闭
+func isStdStream(w io.Writer) (ok bool) {
+	f, isFile := w.(*os.File)
+	return isFile && (f == os.Stdout || f == os.Stderr)
+}
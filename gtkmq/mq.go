@@ -11,7 +11,13 @@ package gtkmq
 
 import (
 	"context"
+	"encoding/json"
+	"github.com/liusuxian/go-toolkit/gtkconv"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
 	"github.com/liusuxian/go-toolkit/gtkretry"
+	"github.com/liusuxian/go-toolkit/gtktrace"
+	"github.com/liusuxian/go-toolkit/internal/mqstats"
+	"maps"
 	"time"
 )
 
@@ -50,10 +56,11 @@ type MQConfig struct {
 
 // ProducerMessage 生产者消息
 type ProducerMessage struct {
-	Key       string        `json:"key,omitempty"`        // 键
-	Data      any           `json:"data"`                 // 数据
-	DelayTime time.Duration `json:"delay_time,omitempty"` // 延迟时长（>0时生效）
-	dataBytes []byte        // 数据字节数组
+	Key       string            `json:"key,omitempty"`        // 键
+	Data      any               `json:"data"`                 // 数据
+	DelayTime time.Duration     `json:"delay_time,omitempty"` // 延迟时长（>0时生效）
+	Headers   map[string]string `json:"headers,omitempty"`    // 消息头，开启链路追踪时会自动注入 traceparent
+	dataBytes []byte            // 数据字节数组
 }
 
 // MQPartition 消息队列分区
@@ -66,11 +73,106 @@ type MQPartition struct {
 
 // MQMessage 消息队列消息
 type MQMessage struct {
-	MQPartition MQPartition `json:"mq_partition"`  // 消息队列分区
-	Key         []byte      `json:"key,omitempty"` // 键
-	Value       []byte      `json:"value"`         // 值
-	Timestamp   time.Time   `json:"timestamp"`     // 发送消息的时间戳
-	ExpireTime  time.Time   `json:"expire_time"`   // 消息过期时间
+	MQPartition MQPartition       `json:"mq_partition"`      // 消息队列分区
+	Key         []byte            `json:"key,omitempty"`     // 键
+	Value       []byte            `json:"value"`             // 值
+	Timestamp   time.Time         `json:"timestamp"`         // 发送消息的时间戳
+	ExpireTime  time.Time         `json:"expire_time"`       // 消息过期时间
+	Headers     map[string]string `json:"headers,omitempty"` // 消息头
+	ctx         context.Context   // 消费消息时的上下文，包含消费者跨度
+}
+
+// MQStats 消息队列统计数据，可以通过 NewStatsCollector 注册到 gtkhttp.MetricsRegistry 中输出
+type MQStats struct {
+	SentMessages          uint64 `json:"sent_messages"`           // 发送成功的消息数
+	SendFailedMessages    uint64 `json:"send_failed_messages"`    // 发送失败的消息数
+	ConsumedMessages      uint64 `json:"consumed_messages"`       // 消费成功的消息数
+	ConsumeFailedMessages uint64 `json:"consume_failed_messages"` // 消费失败（重试结束后仍失败）的消息数
+}
+
+// StatsProvider 提供消息队列统计数据的客户端，如 *RedisMQClient
+type StatsProvider interface {
+	Stats() (stats MQStats)
+}
+
+// NewStatsCollector 创建消息队列统计数据的指标采集器，可以注册到 gtkhttp.MetricsRegistry 中输出
+//
+//	输出 gtkmq_sent_messages_total、gtkmq_send_failed_messages_total、gtkmq_consumed_messages_total、gtkmq_consume_failed_messages_total 计数器
+//	labels 为附加到每个指标的标签，注册多个客户端时用于区分，如 {"client": "order"}，可以为空
+func NewStatsCollector(client StatsProvider, labels map[string]string) (collector gtkhttp.PrometheusCollector) {
+	return gtkhttp.CollectorFunc(func() (families []*gtkhttp.MetricFamily) {
+		stats := client.Stats()
+		return mqstats.Families("gtkmq", labels, mqstats.Snapshot{
+			Sent:          stats.SentMessages,
+			SendFailed:    stats.SendFailedMessages,
+			Consumed:      stats.ConsumedMessages,
+			ConsumeFailed: stats.ConsumeFailedMessages,
+		})
+	})
+}
+
+// Context 获取消息的上下文，消费时包含消费者跨度，业务代码可以使用它创建子跨度或继续传递追踪信息
+func (m *MQMessage) Context() (ctx context.Context) {
+	if m.ctx != nil {
+		return m.ctx
+	}
+	return gtktrace.Extract(context.Background(), gtktrace.MapCarrier(m.Headers))
+}
+
+// startProducerSpan 创建生产者跨度，并将追踪信息注入消息头
+//
+//	注入时使用新的消息头，不会修改 producerMessage 原来的消息头，producerMessage 需要是调用方消息的副本
+func startProducerSpan(ctx context.Context, tracer gtktrace.Tracer, system, queue string, producerMessage *ProducerMessage) (newCtx context.Context, span gtktrace.Span) {
+	newCtx, span = tracer.Start(ctx, queue+" send",
+		gtktrace.WithSpanKind(gtktrace.SpanKindProducer),
+		gtktrace.WithAttributes(
+			gtktrace.Attr("messaging.system", system),
+			gtktrace.Attr("messaging.destination.name", queue),
+			gtktrace.Attr("messaging.operation", "send"),
+		))
+	if !gtktrace.SpanContextFromContext(newCtx).IsValid() {
+		return
+	}
+	headers := make(map[string]string, len(producerMessage.Headers)+2)
+	maps.Copy(headers, producerMessage.Headers)
+	gtktrace.Inject(newCtx, gtktrace.MapCarrier(headers))
+	producerMessage.Headers = headers
+	return
+}
+
+// startConsumerSpan 创建消费者跨度，单条消息时以消息头中的追踪信息作为父跨度，批量消息时关联每条消息的追踪信息
+func startConsumerSpan(ctx context.Context, tracer gtktrace.Tracer, system, queue string, messages []*MQMessage) (newCtx context.Context, span gtktrace.Span) {
+	opts := []gtktrace.StartOption{
+		gtktrace.WithSpanKind(gtktrace.SpanKindConsumer),
+		gtktrace.WithAttributes(
+			gtktrace.Attr("messaging.system", system),
+			gtktrace.Attr("messaging.destination.name", queue),
+			gtktrace.Attr("messaging.operation", "process"),
+			gtktrace.Attr("messaging.batch.message_count", len(messages)),
+		),
+	}
+	if len(messages) == 1 {
+		ctx = gtktrace.Extract(ctx, gtktrace.MapCarrier(messages[0].Headers))
+	} else {
+		for _, message := range messages {
+			opts = append(opts, gtktrace.WithLinks(gtktrace.SpanContextFromContext(message.Context())))
+		}
+	}
+	newCtx, span = tracer.Start(ctx, queue+" process", opts...)
+	for _, message := range messages {
+		message.ctx = newCtx
+	}
+	return
+}
+
+// parseMessageHeaders 解析 stream 消息中的消息头字段，消息字段为 key value timestamp expire_time [headers]
+func parseMessageHeaders(dataSlice []any) (headers map[string]string) {
+	for i := 8; i+1 < len(dataSlice); i += 2 {
+		if gtkconv.ToString(dataSlice[i]) == "headers" {
+			json.Unmarshal(gtkconv.ToBytes(dataSlice[i+1]), &headers)
+		}
+	}
+	return
 }
 
 // MQClient 消息队列客户端接口
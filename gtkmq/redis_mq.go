@@ -20,6 +20,8 @@ import (
 	"github.com/liusuxian/go-toolkit/gtklog"
 	"github.com/liusuxian/go-toolkit/gtkredis"
 	"github.com/liusuxian/go-toolkit/gtkretry"
+	"github.com/liusuxian/go-toolkit/gtktrace"
+	"github.com/liusuxian/go-toolkit/internal/mqstats"
 	"hash/fnv"
 	"math"
 	"runtime"
@@ -57,6 +59,8 @@ type redisMQClient struct {
 	producerMap map[string]bool
 	consumerMap map[string]bool
 	logger      gtklog.ILogger          // 日志接口
+	tracer      gtktrace.Tracer         // 追踪器
+	stats       mqstats.Counter         // 统计计数器
 	janitor     *janitor                // 清理器
 	delaySender map[string]*delaySender // 延迟发送器
 }
@@ -126,7 +130,13 @@ var internalScriptMap = map[string]string{
 	end
 	-- 发送消息到目标分区
 	local targetPartitionQueue = KEYS[1] .. "@" .. targetPartition
-	redis.call("XADD", targetPartitionQueue, "*", "key", ARGV[3], "value", ARGV[4], "timestamp", ARGV[5], "expire_time", ARGV[6])
+	local fields = {"key", ARGV[3], "value", ARGV[4], "timestamp", ARGV[5], "expire_time", ARGV[6]}
+	-- 消息头（可选）
+	if ARGV[7] and ARGV[7] ~= "" then
+		table.insert(fields, "headers")
+		table.insert(fields, ARGV[7])
+	end
+	redis.call("XADD", targetPartitionQueue, "*", unpack(fields))
 	return targetPartition
 	`,
 
@@ -182,7 +192,13 @@ var internalScriptMap = map[string]string{
 		end
 		-- 发送消息到目标分区
 		local targetPartitionQueue = KEYS[2] .. "@" .. targetPartition
-		local streamId = redis.call("XADD", targetPartitionQueue, "*", "key", msg.key or "", "value", cjson.encode(msg.data), "timestamp", ARGV[1], "expire_time", ARGV[4])
+		local fields = {"key", msg.key or "", "value", cjson.encode(msg.data), "timestamp", ARGV[1], "expire_time", ARGV[4]}
+		-- 消息头（可选）
+		if type(msg.headers) == "table" and next(msg.headers) ~= nil then
+			table.insert(fields, "headers")
+			table.insert(fields, cjson.encode(msg.headers))
+		end
+		local streamId = redis.call("XADD", targetPartitionQueue, "*", unpack(fields))
 		if streamId then
 			-- Stream 添加成功，从 ZSET 删除
 			redis.call('ZREM', KEYS[1], msgJson)
@@ -200,11 +216,12 @@ const (
 
 // delayMessage 延迟消息
 type delayMessage struct {
-	UUID      string    `json:"uuid"`          // 消息唯一标识
-	Queue     string    `json:"queue"`         // 队列名称
-	Key       string    `json:"key,omitempty"` // 键
-	Data      any       `json:"data"`          // 数据
-	Timestamp time.Time `json:"timestamp"`     // 发送消息的时间戳
+	UUID      string            `json:"uuid"`              // 消息唯一标识
+	Queue     string            `json:"queue"`             // 队列名称
+	Key       string            `json:"key,omitempty"`     // 键
+	Data      any               `json:"data"`              // 数据
+	Headers   map[string]string `json:"headers,omitempty"` // 消息头
+	Timestamp time.Time         `json:"timestamp"`         // 发送消息的时间戳
 }
 
 // NewRedisMQClient 创建 Redis 消息队列客户端
@@ -223,9 +240,33 @@ func NewRedisMQClient(ctx context.Context, redisConfig *gtkredis.ClientConfig, m
 	return MQ, nil
 }
 
-// SetLogger 设置日志对象
+// SetLogger 设置日志对象，logger 支持 Named 时使用名为 gtkmq 的子日志器，可以单独调整日志级别
 func (mq *redisMQClient) SetLogger(logger gtklog.ILogger) {
-	mq.logger = logger
+	mq.logger = gtklog.NamedLogger(logger, "gtkmq")
+}
+
+// SetTracer 设置追踪器，未设置时使用 gtktrace.GetTracer()
+func (mq *redisMQClient) SetTracer(tracer gtktrace.Tracer) {
+	mq.tracer = tracer
+}
+
+// Stats 获取统计数据
+func (mq *redisMQClient) Stats() (stats MQStats) {
+	s := mq.stats.Snapshot()
+	return MQStats{
+		SentMessages:          s.Sent,
+		SendFailedMessages:    s.SendFailed,
+		ConsumedMessages:      s.Consumed,
+		ConsumeFailedMessages: s.ConsumeFailed,
+	}
+}
+
+// getTracer 获取追踪器
+func (mq *redisMQClient) getTracer() (tracer gtktrace.Tracer) {
+	if mq.tracer != nil {
+		return mq.tracer
+	}
+	return gtktrace.GetTracer()
 }
 
 // PrintClientConfig 打印消息队列客户端配置
@@ -327,6 +368,16 @@ func (mq *redisMQClient) SendMessage(ctx context.Context, queue string, producer
 	if !isStart {
 		return
 	}
+	// 拷贝消息，调用方可能复用消息或在多个协程中并发发送同一条消息
+	message := *producerMessage
+	producerMessage = &message
+	// 创建生产者跨度，并将追踪信息注入消息头
+	var span gtktrace.Span
+	ctx, span = startProducerSpan(ctx, mq.getTracer(), "redis", queue, producerMessage)
+	defer func() {
+		mq.stats.RecordSend(err)
+		gtktrace.EndSpan(span, err)
+	}()
 	if mqConfig.EnableDelayQueue && producerMessage.DelayTime > 0 {
 		// 发送延迟消息
 		return mq.sendDelayMessage(ctx, queue, producerMessage)
@@ -404,6 +455,7 @@ func (mq *redisMQClient) GetExpiredMessages(ctx context.Context, queue string, i
 					Value:      gtkconv.ToBytes(dataSlice[3]),
 					Timestamp:  time.UnixMilli(gtkconv.ToInt64(dataSlice[5])),
 					ExpireTime: time.Unix(gtkconv.ToInt64(dataSlice[7]), 0),
+					Headers:    parseMessageHeaders(dataSlice),
 				}
 				mqMessageList = append(mqMessageList, mqMessage)
 			}
@@ -580,6 +632,13 @@ func (mq *redisMQClient) sendMessage(ctx context.Context, queue string, mqConfig
 	if globalProducerName != "" {
 		producerName = mq.getGlobalProducerName(globalProducerName)
 	}
+	// 消息头
+	var headers []byte
+	if len(producerMessage.Headers) > 0 {
+		if headers, err = json.Marshal(producerMessage.Headers); err != nil {
+			return
+		}
+	}
 	// 发送消息
 	var now time.Time
 	if err = gtkretry.NewRetry(gtkretry.RetryConfig{
@@ -596,6 +655,7 @@ func (mq *redisMQClient) sendMessage(ctx context.Context, queue string, mqConfig
 			producerMessage.dataBytes,
 			now.UnixMilli(),
 			now.Add(mq.config.ExpiredTime).Unix(),
+			headers,
 		}
 		// 执行 lua 脚本
 		var value any
@@ -628,10 +688,11 @@ func (mq *redisMQClient) sendDelayMessage(ctx context.Context, queue string, pro
 	}
 	// 构造延迟消息
 	delayMsg := &delayMessage{
-		UUID:  uuid.New().String(),
-		Queue: queue,
-		Key:   producerMessage.Key,
-		Data:  producerMessage.Data,
+		UUID:    uuid.New().String(),
+		Queue:   queue,
+		Key:     producerMessage.Key,
+		Data:    producerMessage.Data,
+		Headers: producerMessage.Headers,
 	}
 	// 将消息添加到延迟队列
 	if err = gtkretry.NewRetry(gtkretry.RetryConfig{
@@ -764,6 +825,7 @@ func (mq *redisMQClient) handelSubscribe(ctx context.Context, queue string, isBa
 									Value:      gtkconv.ToBytes(dataSlice[3]),
 									Timestamp:  time.UnixMilli(gtkconv.ToInt64(dataSlice[5])),
 									ExpireTime: time.Unix(gtkconv.ToInt64(dataSlice[7]), 0),
+									Headers:    parseMessageHeaders(dataSlice),
 								}
 								mqMessageList = append(mqMessageList, mqMessage)
 							}
@@ -868,13 +930,23 @@ func (mq *redisMQClient) handelData(ctx context.Context, mqConfig *MQConfig, par
 			partitionConsumerName, partitionQueueName, attempt, partition, offset, key, content, timestamp, err)
 		return true
 	}
+	// 创建消费者跨度，消息的上下文中包含该跨度
+	var (
+		handleErr error
+		span      gtktrace.Span
+	)
+	ctx, span = startConsumerSpan(ctx, mq.getTracer(), "redis", lastMessage.MQPartition.Queue, messages)
+	defer func() {
+		mq.stats.RecordConsume(len(messages), handleErr)
+		gtktrace.EndSpan(span, handleErr)
+	}()
 	// 创建重试实例，并且立即执行重试
-	if err := gtkretry.NewRetry(retryConfig).Do(ctx, func(ctx context.Context) error {
+	if handleErr = gtkretry.NewRetry(retryConfig).Do(ctx, func(ctx context.Context) error {
 		// 执行业务函数
 		return fn(messages)
-	}); err != nil {
+	}); handleErr != nil {
 		mq.logger.Errorf(ctx, "handelData finished, partition-consumer: %s, partition-queue: %s, partition: %d, offset: %v, key: %s, content: %s, timestamp: %v, error: %+v",
-			partitionConsumerName, partitionQueueName, partition, offset, key, content, timestamp, err)
+			partitionConsumerName, partitionQueueName, partition, offset, key, content, timestamp, handleErr)
 		// 检查是否是因为 context 被取消（退出信号）
 		if ctx.Err() != nil {
 			return
@@ -1070,7 +1142,7 @@ func newRedisMQClient(ctx context.Context, redisConfig *gtkredis.ClientConfig, m
 		config:      mqConfig,
 		producerMap: make(map[string]bool),
 		consumerMap: make(map[string]bool),
-		logger:      gtklog.NewDefaultLogger(gtklog.TraceLevel),
+		logger:      gtklog.NamedLogger(gtklog.NewDefaultLogger(gtklog.TraceLevel), "gtkmq"),
 		delaySender: make(map[string]*delaySender),
 	}
 	// 发送消息失败后允许重试的次数，默认 2147483647
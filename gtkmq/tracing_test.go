@@ -0,0 +1,125 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 11:28:47
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 11:28:47
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkmq_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"github.com/liusuxian/go-toolkit/gtkmq"
+	"github.com/liusuxian/go-toolkit/gtkredis"
+	"github.com/liusuxian/go-toolkit/gtktrace"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRedisMQTracing(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		assert   = assert.New(t)
+		r        = miniredis.RunT(t)
+		exporter = gtktrace.NewInMemoryExporter()
+		tracer   = gtktrace.NewRecordingTracer(exporter)
+	)
+	client, err := gtkmq.NewRedisMQClient(ctx, &gtkredis.ClientConfig{Addr: r.Addr()}, &gtkmq.RedisMQConfig{
+		WaitTimeout: 100 * time.Millisecond,
+		MQConfig: map[string]gtkmq.MQConfig{
+			"trace_queue": {PartitionNum: 1, Mode: gtkmq.ModeBoth},
+		},
+	})
+	if !assert.NoError(err) {
+		return
+	}
+	defer client.Close()
+	client.SetTracer(tracer)
+	assert.NoError(client.NewProducer(ctx, "trace_queue"))
+
+	// 发送消息时注入生产者跨度
+	parentCtx, parent := tracer.Start(ctx, "parent")
+	err = client.SendMessage(parentCtx, "trace_queue", &gtkmq.ProducerMessage{Data: map[string]any{"a": 1}})
+	assert.NoError(err)
+	parent.End()
+	assert.Equal(gtkmq.MQStats{SentMessages: 1}, client.Stats())
+
+	// 消息头随消息写入 stream
+	spans := exporter.Spans()
+	if assert.Len(spans, 2) {
+		producer := spans[0]
+		assert.Equal("trace_queue send", producer.Name)
+		assert.Equal(gtktrace.SpanKindProducer, producer.Kind)
+		assert.Equal(parent.SpanContext(), producer.Parent)
+		assert.Equal("redis", producer.Attributes["messaging.system"])
+
+		entries, err := r.Stream("local_trace_queue@0")
+		if assert.NoError(err) && assert.Len(entries, 1) {
+			values := entries[0].Values
+			if assert.Len(values, 10) && assert.Equal("headers", values[8]) {
+				// 消费端从消息头中解析出生产者跨度
+				message := &gtkmq.MQMessage{Headers: map[string]string{}}
+				assert.NoError(json.Unmarshal([]byte(values[9]), &message.Headers))
+				assert.Equal(producer.SpanContext.SpanID, gtktrace.SpanContextFromContext(message.Context()).SpanID)
+			}
+		}
+	}
+
+	// 不修改调用方的消息头，复用消息时每次发送都注入新的追踪信息
+	reused := &gtkmq.ProducerMessage{Data: 1, Headers: map[string]string{"k": "v"}}
+	for range 2 {
+		assert.NoError(client.SendMessage(ctx, "trace_queue", reused))
+	}
+	assert.Equal(map[string]string{"k": "v"}, reused.Headers)
+	entries, err := r.Stream("local_trace_queue@0")
+	if assert.NoError(err) && assert.Len(entries, 3) {
+		var traceparents []string
+		for _, entry := range entries[1:] {
+			headers := map[string]string{}
+			assert.NoError(json.Unmarshal([]byte(entry.Values[9]), &headers))
+			assert.Equal("v", headers["k"])
+			traceparents = append(traceparents, headers["traceparent"])
+		}
+		assert.NotEmpty(traceparents[0])
+		assert.NotEqual(traceparents[0], traceparents[1])
+	}
+}
+
+func TestStatsCollector(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		assert   = assert.New(t)
+		r        = miniredis.RunT(t)
+		registry = gtkhttp.NewMetricsRegistry()
+	)
+	client, err := gtkmq.NewRedisMQClient(ctx, &gtkredis.ClientConfig{Addr: r.Addr()}, &gtkmq.RedisMQConfig{
+		WaitTimeout: 100 * time.Millisecond,
+		MQConfig: map[string]gtkmq.MQConfig{
+			"stats_queue": {PartitionNum: 1, Mode: gtkmq.ModeProducer},
+		},
+	})
+	if !assert.NoError(err) {
+		return
+	}
+	defer client.Close()
+	assert.NoError(client.NewProducer(ctx, "stats_queue"))
+	assert.NoError(client.SendMessage(ctx, "stats_queue", &gtkmq.ProducerMessage{Data: "a"}))
+	assert.Error(client.SendMessage(ctx, "stats_queue", &gtkmq.ProducerMessage{Data: func() {}}))
+
+	registry.Register(gtkmq.NewStatsCollector(client, map[string]string{"client": "order"}))
+	var buf bytes.Buffer
+	_, err = registry.WriteTo(&buf)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "# TYPE gtkmq_sent_messages_total counter\n")
+	assert.Contains(buf.String(), `gtkmq_sent_messages_total{client="order"} 1`+"\n")
+	assert.Contains(buf.String(), `gtkmq_send_failed_messages_total{client="order"} 1`+"\n")
+	assert.Contains(buf.String(), `gtkmq_consumed_messages_total{client="order"} 0`+"\n")
+	assert.Contains(buf.String(), `gtkmq_consume_failed_messages_total{client="order"} 0`+"\n")
+}
@@ -0,0 +1,267 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 10:12:36
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 10:12:36
+ * @Description: redis 发布订阅与键空间通知
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkredis
+
+import (
+	"context"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/gtkconv"
+	"github.com/liusuxian/go-toolkit/internal/utils"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 键空间通知频道前缀
+const (
+	keyspacePrefix = "__keyspace@" // 键空间通知，频道为 __keyspace@<db>__:<key>，消息为事件名
+	keyeventPrefix = "__keyevent@" // 键事件通知，频道为 __keyevent@<db>__:<event>，消息为键名
+)
+
+// Message 订阅消息
+type Message struct {
+	Channel string // 消息来源频道
+	Pattern string // 匹配的模式，仅通过 PSubscribe 订阅时有值
+	Payload string // 消息内容
+}
+
+// KeyspaceEvent 键空间事件
+type KeyspaceEvent struct {
+	DB    int    // 数据库索引
+	Event string // 事件名，如 expired、del、set
+	Key   string // 发生事件的键
+}
+
+// SubscribeOption 订阅选项
+type SubscribeOption func(s *subscribeOptions)
+
+// subscribeOptions 订阅选项
+type subscribeOptions struct {
+	channelSize         int           // 消息通道缓冲大小，默认 100
+	healthCheckInterval time.Duration // 健康检查间隔，默认 3s，连接异常时会自动重连并重新订阅
+	sendTimeout         time.Duration // 消息投递到通道的超时时间，默认 60s，超时的消息将被丢弃
+}
+
+// Subscription 订阅
+type Subscription struct {
+	pubsub    *redis.PubSub  // redis 订阅
+	ch        chan *Message  // 消息通道
+	done      chan struct{}  // 关闭信号
+	closeOnce sync.Once      // 确保只关闭一次
+	wg        sync.WaitGroup // 等待消息转发协程退出
+}
+
+// WithChannelSize 设置消息通道缓冲大小
+func WithChannelSize(size int) SubscribeOption {
+	return func(s *subscribeOptions) {
+		s.channelSize = size
+	}
+}
+
+// WithHealthCheckInterval 设置健康检查间隔
+func WithHealthCheckInterval(d time.Duration) SubscribeOption {
+	return func(s *subscribeOptions) {
+		s.healthCheckInterval = d
+	}
+}
+
+// WithSendTimeout 设置消息投递到通道的超时时间
+func WithSendTimeout(d time.Duration) SubscribeOption {
+	return func(s *subscribeOptions) {
+		s.sendTimeout = d
+	}
+}
+
+// Publish 发布消息，返回接收到消息的订阅者数量
+func (rc *RedisClient) Publish(ctx context.Context, channel string, message any) (receivers int64, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// 处理`redis`命令参数
+	args := []any{message}
+	if err = utils.DoRedisArgs(0, args...); err != nil {
+		return
+	}
+	receivers, err = rc.client.Publish(ctx, channel, args[0]).Result()
+	return
+}
+
+// Subscribe 订阅频道，连接断开后会自动重连并重新订阅
+func (rc *RedisClient) Subscribe(ctx context.Context, channels []string, opts ...SubscribeOption) (sub *Subscription, err error) {
+	if len(channels) == 0 {
+		err = fmt.Errorf("subscribe channels is empty")
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return rc.newSubscription(ctx, rc.client.Subscribe(ctx, channels...), opts...)
+}
+
+// PSubscribe 按模式订阅频道，连接断开后会自动重连并重新订阅
+func (rc *RedisClient) PSubscribe(ctx context.Context, patterns []string, opts ...SubscribeOption) (sub *Subscription, err error) {
+	if len(patterns) == 0 {
+		err = fmt.Errorf("psubscribe patterns is empty")
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return rc.newSubscription(ctx, rc.client.PSubscribe(ctx, patterns...), opts...)
+}
+
+// EnableKeyspaceNotifications 开启键空间通知，flags 参考 redis 配置项 notify-keyspace-events，如 "Ex" 表示开启键过期事件
+//
+//	部分云厂商的 redis 禁用了 CONFIG 命令，此时需要在控制台中开启
+func (rc *RedisClient) EnableKeyspaceNotifications(ctx context.Context, flags string) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return rc.client.ConfigSet(ctx, "notify-keyspace-events", flags).Err()
+}
+
+// SubscribeKeyEvents 订阅当前数据库的键事件通知，如 expired、del、set，events 为空时订阅全部事件
+//
+//	需要 redis 已开启对应的键事件通知（notify-keyspace-events 包含 E），消息可通过 Message.KeyspaceEvent 解析
+func (rc *RedisClient) SubscribeKeyEvents(ctx context.Context, events []string, opts ...SubscribeOption) (sub *Subscription, err error) {
+	if len(events) == 0 {
+		events = []string{"*"}
+	}
+	patterns := make([]string, 0, len(events))
+	for _, event := range events {
+		patterns = append(patterns, fmt.Sprintf("%s%d__:%s", keyeventPrefix, rc.db, event))
+	}
+	return rc.PSubscribe(ctx, patterns, opts...)
+}
+
+// SubscribeExpired 订阅当前数据库的键过期事件
+//
+//	需要 redis 已开启键过期事件通知（notify-keyspace-events 包含 Ex）
+func (rc *RedisClient) SubscribeExpired(ctx context.Context, opts ...SubscribeOption) (sub *Subscription, err error) {
+	return rc.SubscribeKeyEvents(ctx, []string{"expired"}, opts...)
+}
+
+// Channel 获取消息通道，订阅关闭后通道会被关闭
+func (s *Subscription) Channel() (ch <-chan *Message) {
+	return s.ch
+}
+
+// Subscribe 追加订阅频道
+func (s *Subscription) Subscribe(ctx context.Context, channels ...string) (err error) {
+	return s.pubsub.Subscribe(ctx, channels...)
+}
+
+// PSubscribe 追加按模式订阅频道
+func (s *Subscription) PSubscribe(ctx context.Context, patterns ...string) (err error) {
+	return s.pubsub.PSubscribe(ctx, patterns...)
+}
+
+// Unsubscribe 取消订阅频道，channels 为空时取消订阅全部频道
+func (s *Subscription) Unsubscribe(ctx context.Context, channels ...string) (err error) {
+	return s.pubsub.Unsubscribe(ctx, channels...)
+}
+
+// PUnsubscribe 取消按模式订阅频道，patterns 为空时取消订阅全部模式
+func (s *Subscription) PUnsubscribe(ctx context.Context, patterns ...string) (err error) {
+	return s.pubsub.PUnsubscribe(ctx, patterns...)
+}
+
+// Close 关闭订阅
+func (s *Subscription) Close() (err error) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.pubsub.Close()
+		s.wg.Wait()
+	})
+	return
+}
+
+// Scan 将消息内容解析到 val 中
+func (m *Message) Scan(val any) (err error) {
+	return gtkconv.ToStructE(m.Payload, val)
+}
+
+// KeyspaceEvent 将键空间通知或键事件通知消息解析为键空间事件
+func (m *Message) KeyspaceEvent() (event *KeyspaceEvent, ok bool) {
+	var (
+		name       string
+		isKeyspace bool
+	)
+	switch {
+	case strings.HasPrefix(m.Channel, keyspacePrefix):
+		name, isKeyspace = strings.TrimPrefix(m.Channel, keyspacePrefix), true
+	case strings.HasPrefix(m.Channel, keyeventPrefix):
+		name = strings.TrimPrefix(m.Channel, keyeventPrefix)
+	default:
+		return
+	}
+	// 频道格式为 <db>__:<key|event>
+	db, suffix, found := strings.Cut(name, "__:")
+	if !found {
+		return
+	}
+	dbIndex, err := gtkconv.ToIntE(db)
+	if err != nil {
+		return
+	}
+	event = &KeyspaceEvent{DB: dbIndex}
+	if isKeyspace {
+		event.Key, event.Event = suffix, m.Payload
+	} else {
+		event.Key, event.Event = m.Payload, suffix
+	}
+	return event, true
+}
+
+// newSubscription 创建订阅
+func (rc *RedisClient) newSubscription(ctx context.Context, pubsub *redis.PubSub, opts ...SubscribeOption) (sub *Subscription, err error) {
+	options := &subscribeOptions{
+		channelSize:         100,
+		healthCheckInterval: 3 * time.Second,
+		sendTimeout:         time.Minute,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	// 等待订阅确认，确保返回时订阅已生效
+	if _, err = pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return
+	}
+	sub = &Subscription{
+		pubsub: pubsub,
+		ch:     make(chan *Message, options.channelSize),
+		done:   make(chan struct{}),
+	}
+	// go-redis 的消息通道在连接异常时会自动重连并重新订阅全部频道
+	msgCh := pubsub.Channel(
+		redis.WithChannelSize(options.channelSize),
+		redis.WithChannelHealthCheckInterval(options.healthCheckInterval),
+		redis.WithChannelSendTimeout(options.sendTimeout),
+	)
+	sub.wg.Add(1)
+	go func() {
+		defer sub.wg.Done()
+		defer close(sub.ch)
+		for msg := range msgCh {
+			select {
+			case sub.ch <- &Message{
+				Channel: msg.Channel,
+				Pattern: msg.Pattern,
+				Payload: msg.Payload,
+			}:
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+	return
+}
@@ -0,0 +1,120 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 10:40:18
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 10:40:18
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkredis_test
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/liusuxian/go-toolkit/gtkredis"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRedisPubSub(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		r      = miniredis.RunT(t)
+		assert = assert.New(t)
+	)
+	client, err := gtkredis.NewClient(ctx, &gtkredis.ClientConfig{
+		Addr:     r.Addr(),
+		Username: "default",
+		Password: "",
+		DB:       1,
+	})
+	assert.NoError(err)
+	defer client.Close()
+
+	_, err = client.Subscribe(ctx, nil)
+	assert.Error(err)
+
+	sub, err := client.Subscribe(ctx, []string{"test:pubsub"})
+	if !assert.NoError(err) {
+		return
+	}
+	defer sub.Close()
+
+	receivers, err := client.Publish(ctx, "test:pubsub", &A{A: 1, B: 1.2, C: "hello"})
+	assert.NoError(err)
+	assert.Equal(int64(1), receivers)
+	select {
+	case msg := <-sub.Channel():
+		assert.Equal("test:pubsub", msg.Channel)
+		assert.Empty(msg.Pattern)
+		val := &A{}
+		assert.NoError(msg.Scan(val))
+		assert.Equal(&A{A: 1, B: 1.2, C: "hello"}, val)
+	case <-time.After(time.Second):
+		assert.Fail("receive message timeout")
+	}
+
+	psub, err := client.PSubscribe(ctx, []string{"test:pattern:*"})
+	if !assert.NoError(err) {
+		return
+	}
+	receivers, err = client.Publish(ctx, "test:pattern:a", "hello")
+	assert.NoError(err)
+	assert.Equal(int64(1), receivers)
+	select {
+	case msg := <-psub.Channel():
+		assert.Equal("test:pattern:a", msg.Channel)
+		assert.Equal("test:pattern:*", msg.Pattern)
+		assert.Equal("hello", msg.Payload)
+	case <-time.After(time.Second):
+		assert.Fail("receive message timeout")
+	}
+	// 关闭后通道应被关闭
+	assert.NoError(psub.Close())
+	assert.NoError(psub.Close())
+	_, ok := <-psub.Channel()
+	assert.False(ok)
+}
+
+func TestRedisKeyspaceEvent(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		r      = miniredis.RunT(t)
+		assert = assert.New(t)
+	)
+	client, err := gtkredis.NewClient(ctx, &gtkredis.ClientConfig{
+		Addr:     r.Addr(),
+		Username: "default",
+		Password: "",
+		DB:       1,
+	})
+	assert.NoError(err)
+	defer client.Close()
+
+	sub, err := client.SubscribeExpired(ctx)
+	if !assert.NoError(err) {
+		return
+	}
+	defer sub.Close()
+	// miniredis 不会产生键空间通知，这里手动模拟一条过期事件
+	_, err = client.Publish(ctx, "__keyevent@1__:expired", "test:expired")
+	assert.NoError(err)
+	select {
+	case msg := <-sub.Channel():
+		event, ok := msg.KeyspaceEvent()
+		if assert.True(ok) {
+			assert.Equal(&gtkredis.KeyspaceEvent{DB: 1, Event: "expired", Key: "test:expired"}, event)
+		}
+	case <-time.After(time.Second):
+		assert.Fail("receive message timeout")
+	}
+
+	event, ok := (&gtkredis.Message{Channel: "__keyspace@0__:user:1", Payload: "del"}).KeyspaceEvent()
+	if assert.True(ok) {
+		assert.Equal(&gtkredis.KeyspaceEvent{DB: 0, Event: "del", Key: "user:1"}, event)
+	}
+	_, ok = (&gtkredis.Message{Channel: "test", Payload: "del"}).KeyspaceEvent()
+	assert.False(ok)
+}
@@ -46,12 +46,15 @@ type ClientConfig struct {
 	DisableIdentity bool          `json:"disable_identity"`   // 用于在连接时禁用 CLIENT SETINFO 命令，默认 false
 	IdentitySuffix  string        `json:"identity_suffix"`    // 添加客户端名称后缀
 	UnstableResp3   bool          `json:"unstable_resp_3"`    // 为 Redis Search 模块启用 RESP3 的不稳定模式，默认 false
+	WatchMaxRetries int           `json:"watch_max_retries"`  // Watch 事务因被监视的键发生变更而失败时的最大尝试次数，默认 3
 }
 
 // RedisClient redis 客户端结构
 type RedisClient struct {
-	client        *redis.Client // redis 客户端
-	luaEvalShaMap map[string]string
+	client       *redis.Client   // redis 客户端
+	db           int             // 数据库索引
+	watchRetries int             // Watch 事务的最大尝试次数
+	scripts      *scriptRegistry // lua 脚本注册表
 }
 
 // PipelineResult 管道返回值
@@ -113,7 +116,12 @@ func NewClient(ctx context.Context, cfg *ClientConfig) (client *RedisClient, err
 			IdentitySuffix:  cfg.IdentitySuffix,
 			UnstableResp3:   cfg.UnstableResp3,
 		}),
-		luaEvalShaMap: make(map[string]string),
+		db:           cfg.DB,
+		watchRetries: cfg.WatchMaxRetries,
+		scripts:      newScriptRegistry(),
+	}
+	if client.watchRetries <= 0 {
+		client.watchRetries = defaultWatchMaxRetries
 	}
 	for k, v := range internalScriptMap {
 		if err = client.ScriptLoad(ctx, k, v); err != nil {
@@ -166,23 +174,21 @@ func (rc *RedisClient) Pipeline(ctx context.Context, cmdArgsList ...[]any) (resu
 		return
 	}
 	// 处理返回结果
-	results = make([]*PipelineResult, 0, len(resList))
-	for _, v := range resList {
-		results = append(results, &PipelineResult{
-			Val: v.(*redis.Cmd).Val(),
-			Err: v.Err(),
-		})
-	}
+	results = toPipelineResults(resList)
 	return
 }
 
-// ScriptLoad 加载 lua 脚本
+// ScriptLoad 加载 lua 脚本，脚本在加载时由 redis 编译校验
 func (rc *RedisClient) ScriptLoad(ctx context.Context, name, script string) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	var evalsha string
 	if evalsha, err = rc.client.ScriptLoad(ctx, script).Result(); err != nil {
+		err = fmt.Errorf("[%s] script load error: %w", name, err)
 		return
 	}
-	rc.luaEvalShaMap[name] = evalsha
+	rc.scripts.set(name, &luaScript{src: script, sha: evalsha})
 	return
 }
 
@@ -193,13 +199,7 @@ func (rc *RedisClient) ScriptLoadByPath(ctx context.Context, scriptPath string)
 		err = fmt.Errorf("[%s] script not found", scriptPath)
 		return
 	}
-	var evalsha string
-	if evalsha, err = rc.client.ScriptLoad(ctx, script).Result(); err != nil {
-		return
-	}
-	name := utils.Name(scriptPath)
-	rc.luaEvalShaMap[name] = evalsha
-	return
+	return rc.ScriptLoad(ctx, utils.Name(scriptPath), script)
 }
 
 // Eval 执行 lua 脚本
@@ -213,18 +213,16 @@ func (rc *RedisClient) Eval(ctx context.Context, script string, keys []string, a
 	return
 }
 
-// EvalSha 执行 lua 脚本
+// EvalSha 执行已加载的 lua 脚本，脚本在 redis 中不存在时（NOSCRIPT）会自动重新加载
 func (rc *RedisClient) EvalSha(ctx context.Context, name string, keys []string, args ...any) (value any, err error) {
-	evalsha, ok := rc.luaEvalShaMap[name]
-	if !ok {
-		err = fmt.Errorf("[%s] Script Not Found", name)
-		return
+	if ctx == nil {
+		ctx = context.Background()
 	}
 	// 处理`redis`命令参数
 	if err = utils.DoRedisArgs(0, args...); err != nil {
 		return
 	}
-	value, err = rc.client.EvalSha(ctx, evalsha, keys, args...).Result()
+	value, err = rc.evalSha(ctx, name, keys, args...)
 	err = noErrNil(err)
 	return
 }
@@ -283,6 +281,18 @@ func (rc *RedisClient) Close() (err error) {
 	return rc.client.Close()
 }
 
+// toPipelineResults 将管道命令的执行结果转换为管道返回值
+func toPipelineResults(resList []redis.Cmder) (results []*PipelineResult) {
+	results = make([]*PipelineResult, 0, len(resList))
+	for _, v := range resList {
+		results = append(results, &PipelineResult{
+			Val: v.(*redis.Cmd).Val(),
+			Err: v.Err(),
+		})
+	}
+	return
+}
+
 // noErrNil 处理 redis.Nil 错误
 func noErrNil(err error) error {
 	if err == redis.Nil {
@@ -0,0 +1,136 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 13:48:25
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 13:48:25
+ * @Description: lua 脚本注册表，redis 故障切换或 SCRIPT FLUSH 后自动重新加载脚本
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkredis
+
+import (
+	"context"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/internal/utils"
+	"github.com/redis/go-redis/v9"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// luaScript lua 脚本
+type luaScript struct {
+	src string // 脚本内容
+	sha string // 脚本的 SHA1 校验和
+}
+
+// scriptRegistry lua 脚本注册表，并发安全
+type scriptRegistry struct {
+	mu      sync.RWMutex          // 读写锁
+	scripts map[string]*luaScript // 脚本名称 -> 脚本
+}
+
+// newScriptRegistry 创建 lua 脚本注册表
+func newScriptRegistry() (r *scriptRegistry) {
+	return &scriptRegistry{
+		scripts: make(map[string]*luaScript),
+	}
+}
+
+// get 获取脚本
+func (r *scriptRegistry) get(name string) (script *luaScript, ok bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	script, ok = r.scripts[name]
+	return
+}
+
+// set 设置脚本
+func (r *scriptRegistry) set(name string, script *luaScript) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scripts[name] = script
+}
+
+// names 获取全部脚本名称
+func (r *scriptRegistry) names() (names []string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names = make([]string, 0, len(r.scripts))
+	for name := range r.scripts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return
+}
+
+// ScriptLoadFS 加载文件系统 fsys 中 dir 目录下的全部 .lua 脚本（不递归子目录），脚本名称为不含扩展名的文件名，通常配合 embed.FS 使用
+//
+//	脚本在加载时由 redis 编译校验，任一脚本编译失败都会返回错误
+func (rc *RedisClient) ScriptLoadFS(ctx context.Context, fsys fs.FS, dir string) (err error) {
+	var entries []fs.DirEntry
+	if entries, err = fs.ReadDir(fsys, dir); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(utils.ExtName(entry.Name()), "lua") {
+			continue
+		}
+		var (
+			scriptPath = path.Join(dir, entry.Name())
+			data       []byte
+		)
+		if data, err = fs.ReadFile(fsys, scriptPath); err != nil {
+			return
+		}
+		if err = rc.ScriptLoad(ctx, utils.Name(entry.Name()), string(data)); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ScriptReload 重新加载全部已注册的 lua 脚本
+func (rc *RedisClient) ScriptReload(ctx context.Context) (err error) {
+	for _, name := range rc.scripts.names() {
+		script, ok := rc.scripts.get(name)
+		if !ok {
+			continue
+		}
+		if err = rc.ScriptLoad(ctx, name, script.src); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ScriptNames 获取全部已注册的 lua 脚本名称
+func (rc *RedisClient) ScriptNames() (names []string) {
+	return rc.scripts.names()
+}
+
+// evalSha 执行 lua 脚本，脚本在 redis 中不存在时（NOSCRIPT）自动重新加载并重试一次
+func (rc *RedisClient) evalSha(ctx context.Context, name string, keys []string, args ...any) (value any, err error) {
+	script, ok := rc.scripts.get(name)
+	if !ok {
+		err = fmt.Errorf("[%s] Script Not Found", name)
+		return
+	}
+	value, err = rc.client.EvalSha(ctx, script.sha, keys, args...).Result()
+	if err == nil || !redis.HasErrorPrefix(err, "NOSCRIPT") {
+		return
+	}
+	// redis 故障切换或执行了 SCRIPT FLUSH，重新加载脚本
+	if err = rc.ScriptLoad(ctx, name, script.src); err != nil {
+		return
+	}
+	if script, ok = rc.scripts.get(name); !ok {
+		err = fmt.Errorf("[%s] Script Not Found", name)
+		return
+	}
+	value, err = rc.client.EvalSha(ctx, script.sha, keys, args...).Result()
+	return
+}
@@ -0,0 +1,100 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 14:20:51
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 14:20:51
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkredis_test
+
+import (
+	"context"
+	"embed"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/liusuxian/go-toolkit/gtkconv"
+	"github.com/liusuxian/go-toolkit/gtkredis"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+//go:embed lua_script/*.lua
+var luaScriptFS embed.FS
+
+func TestRedisScriptLoadFS(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		r      = miniredis.RunT(t)
+		assert = assert.New(t)
+	)
+	client, err := gtkredis.NewClient(ctx, &gtkredis.ClientConfig{
+		Addr:     r.Addr(),
+		Username: "default",
+		Password: "",
+		DB:       1,
+	})
+	assert.NoError(err)
+	defer client.Close()
+
+	err = client.ScriptLoadFS(ctx, luaScriptFS, "lua_script")
+	assert.NoError(err)
+	assert.Subset(client.ScriptNames(), []string{"test", "test_get", "test_set"})
+	// 编译失败的脚本加载时返回错误
+	err = client.ScriptLoadFS(ctx, fstest.MapFS{
+		"scripts/bad.lua":   &fstest.MapFile{Data: []byte("return redis.call('GET', KEYS[1]")},
+		"scripts/readme.md": &fstest.MapFile{Data: []byte("# scripts")},
+	}, "scripts")
+	assert.Error(err)
+	assert.NotContains(client.ScriptNames(), "bad")
+	err = client.ScriptLoadFS(ctx, luaScriptFS, "not_exist")
+	assert.Error(err)
+}
+
+func TestRedisScriptNoScriptRecovery(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		r      = miniredis.RunT(t)
+		assert = assert.New(t)
+	)
+	client, err := gtkredis.NewClient(ctx, &gtkredis.ClientConfig{
+		Addr:     r.Addr(),
+		Username: "default",
+		Password: "",
+		DB:       1,
+	})
+	assert.NoError(err)
+	defer client.Close()
+
+	err = client.ScriptLoadFS(ctx, luaScriptFS, "lua_script")
+	assert.NoError(err)
+	// 模拟 redis 故障切换后脚本缓存丢失
+	_, err = client.Do(ctx, "SCRIPT", "FLUSH")
+	assert.NoError(err)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			val, e := client.EvalSha(ctx, "test", []string{"lua_key"}, 1)
+			if assert.NoError(e) {
+				assert.Equal(1, gtkconv.ToInt(val))
+			}
+		})
+	}
+	wg.Wait()
+	// 内置脚本同样可以自动恢复
+	ok, err := client.CompareAndDelete(ctx, "lua_key", 1)
+	assert.NoError(err)
+	assert.True(ok)
+
+	_, err = client.Do(ctx, "SCRIPT", "FLUSH")
+	assert.NoError(err)
+	assert.NoError(client.ScriptReload(ctx))
+	index, err := client.Polling(ctx, "polling_key", 5)
+	assert.NoError(err)
+	assert.Equal(0, index)
+
+	_, err = client.EvalSha(ctx, "not_exist", nil)
+	assert.Error(err)
+}
@@ -0,0 +1,128 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 11:05:42
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 11:05:42
+ * @Description: redis 事务（MULTI/EXEC）与基于 WATCH 的乐观锁
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultWatchMaxRetries = 3 // Watch 事务默认的最大尝试次数
+)
+
+// ErrTxFailed 事务执行失败，被监视的键在事务提交前发生了变更
+var ErrTxFailed = redis.TxFailedErr
+
+// Tx 事务，仅在 Watch 的回调函数中有效
+type Tx struct {
+	tx          *redis.Tx // redis 事务
+	cmdArgsList [][]any   // 待提交的命令列表
+}
+
+// TxPipeline 执行 redis 事务管道命令，所有命令包裹在 MULTI/EXEC 中原子执行
+func (rc *RedisClient) TxPipeline(ctx context.Context, cmdArgsList ...[]any) (results []*PipelineResult, err error) {
+	if len(cmdArgsList) == 0 {
+		err = fmt.Errorf("tx pipeline cmd args list is empty")
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return execTxPipeline(ctx, rc.client.TxPipeline(), cmdArgsList)
+}
+
+// Watch 监视 keys 并执行乐观锁事务
+//
+//	fn 中通过 tx.Do 读取数据（立即执行），通过 tx.Queue 追加需要在 MULTI/EXEC 中提交的命令
+//	若被监视的键在提交前发生变更，会重新执行 fn，超过最大尝试次数后返回 ErrTxFailed
+//	fn 未追加任何命令时不提交事务，results 为空
+func (rc *RedisClient) Watch(ctx context.Context, fn func(tx *Tx) error, keys ...string) (results []*PipelineResult, err error) {
+	if fn == nil {
+		err = fmt.Errorf("watch fn is nil")
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for range rc.watchRetries {
+		err = rc.client.Watch(ctx, func(rtx *redis.Tx) (e error) {
+			tx := &Tx{tx: rtx}
+			if e = fn(tx); e != nil {
+				return
+			}
+			if len(tx.cmdArgsList) == 0 {
+				return
+			}
+			results, e = execTxPipeline(ctx, rtx.TxPipeline(), tx.cmdArgsList)
+			return
+		}, keys...)
+		if !errors.Is(err, ErrTxFailed) {
+			return
+		}
+		results = nil
+	}
+	return
+}
+
+// Do 在被监视的连接上立即执行 redis 命令，通常用于读取被监视的键
+func (t *Tx) Do(ctx context.Context, cmd string, args ...any) (value any, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// 处理`redis`命令参数
+	if err = utils.DoRedisArgs(0, args...); err != nil {
+		return
+	}
+	// 执行`redis`命令
+	cmdArgs := make([]any, 0, len(args)+1)
+	cmdArgs = append(cmdArgs, cmd)
+	cmdArgs = append(cmdArgs, args...)
+	value, err = t.tx.Do(ctx, cmdArgs...).Result()
+	err = noErrNil(err)
+	return
+}
+
+// Queue 追加需要在事务中提交的命令
+func (t *Tx) Queue(cmdArgs ...any) (err error) {
+	if len(cmdArgs) == 0 {
+		err = fmt.Errorf("tx cmd args is empty")
+		return
+	}
+	// 处理`redis`命令参数
+	if err = utils.DoRedisArgs(1, cmdArgs...); err != nil {
+		return
+	}
+	t.cmdArgsList = append(t.cmdArgsList, cmdArgs)
+	return
+}
+
+// execTxPipeline 执行事务管道命令
+func execTxPipeline(ctx context.Context, p redis.Pipeliner, cmdArgsList [][]any) (results []*PipelineResult, err error) {
+	// 处理`redis`命令参数
+	for _, cmdArgs := range cmdArgsList {
+		if err = utils.DoRedisArgs(1, cmdArgs...); err != nil {
+			return
+		}
+		// 执行`redis`命令
+		p.Do(ctx, cmdArgs...)
+	}
+	var resList []redis.Cmder
+	resList, err = p.Exec(ctx)
+	if err = noErrNil(err); err != nil {
+		return
+	}
+	// 处理返回结果
+	results = toPipelineResults(resList)
+	return
+}
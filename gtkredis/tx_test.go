@@ -0,0 +1,123 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-19 11:32:07
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-19 11:32:07
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkredis_test
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/liusuxian/go-toolkit/gtkconv"
+	"github.com/liusuxian/go-toolkit/gtkredis"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRedisTxPipeline(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		r      = miniredis.RunT(t)
+		assert = assert.New(t)
+	)
+	client, err := gtkredis.NewClient(ctx, &gtkredis.ClientConfig{
+		Addr:     r.Addr(),
+		Username: "default",
+		Password: "",
+		DB:       1,
+	})
+	assert.NoError(err)
+	defer client.Close()
+
+	_, err = client.TxPipeline(ctx)
+	assert.Error(err)
+
+	var results []*gtkredis.PipelineResult
+	results, err = client.TxPipeline(ctx, []any{"SET", "tx_a", 1}, []any{"INCRBY", "tx_a", 2}, []any{"GET", "tx_a"})
+	if assert.NoError(err) && assert.Len(results, 3) {
+		assert.Equal("OK", results[0].Val)
+		assert.Equal(int64(3), results[1].Val)
+		assert.Equal("3", results[2].Val)
+	}
+}
+
+func TestRedisWatch(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		r      = miniredis.RunT(t)
+		assert = assert.New(t)
+	)
+	client, err := gtkredis.NewClient(ctx, &gtkredis.ClientConfig{
+		Addr:            r.Addr(),
+		Username:        "default",
+		Password:        "",
+		DB:              1,
+		WatchMaxRetries: 2,
+	})
+	assert.NoError(err)
+	defer client.Close()
+
+	_, err = client.Do(ctx, "SET", "balance", 100)
+	assert.NoError(err)
+	// 扣减余额
+	deduct := func(amount int) func(tx *gtkredis.Tx) error {
+		return func(tx *gtkredis.Tx) (e error) {
+			var val any
+			if val, e = tx.Do(ctx, "GET", "balance"); e != nil {
+				return
+			}
+			balance := gtkconv.ToInt(val)
+			if balance < amount {
+				return nil
+			}
+			return tx.Queue("SET", "balance", balance-amount)
+		}
+	}
+	var results []*gtkredis.PipelineResult
+	results, err = client.Watch(ctx, deduct(30), "balance")
+	if assert.NoError(err) && assert.Len(results, 1) {
+		assert.Equal("OK", results[0].Val)
+	}
+	val, err := client.Do(ctx, "GET", "balance")
+	assert.NoError(err)
+	assert.Equal(70, gtkconv.ToInt(val))
+	// 余额不足时不提交事务
+	results, err = client.Watch(ctx, deduct(100), "balance")
+	assert.NoError(err)
+	assert.Empty(results)
+	// 第一次尝试时被监视的键发生变更，重试后成功
+	var attempts int
+	results, err = client.Watch(ctx, func(tx *gtkredis.Tx) (e error) {
+		attempts++
+		if e = deduct(10)(tx); e != nil {
+			return
+		}
+		if attempts == 1 {
+			_, e = client.Do(ctx, "SET", "balance", 50)
+		}
+		return
+	}, "balance")
+	if assert.NoError(err) && assert.Len(results, 1) {
+		assert.Equal(2, attempts)
+	}
+	val, err = client.Do(ctx, "GET", "balance")
+	assert.NoError(err)
+	assert.Equal(40, gtkconv.ToInt(val))
+	// 每次尝试时被监视的键都发生变更，超过最大尝试次数后失败
+	attempts = 0
+	results, err = client.Watch(ctx, func(tx *gtkredis.Tx) (e error) {
+		attempts++
+		if e = deduct(10)(tx); e != nil {
+			return
+		}
+		_, e = client.Do(ctx, "INCR", "balance")
+		return
+	}, "balance")
+	assert.ErrorIs(err, gtkredis.ErrTxFailed)
+	assert.Nil(results)
+	assert.Equal(2, attempts)
+}
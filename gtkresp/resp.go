@@ -19,7 +19,8 @@ import (
 )
 
 const (
-	CodeSuccess int = 0
+	CodeSuccess       int = 0                     // 成功
+	CodeInvalidParams int = http.StatusBadRequest // 请求参数错误
 )
 
 // Response 通用响应数据
@@ -0,0 +1,259 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-21 09:32:14
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-21 09:32:14
+ * @Description: SSE 流式响应写入器
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkresp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSSEWriterClosed = errors.New("sse writer is closed")            // SSE 写入器已关闭
+	ErrSSEInvalidField = errors.New("sse field contains a line break") // SSE 事件的 ID 或类型包含换行符
+)
+
+// SSEEvent SSE 事件
+type SSEEvent struct {
+	ID    string        // 事件ID，不能包含换行符
+	Event string        // 事件类型，为空时客户端视为 message，不能包含换行符
+	Retry time.Duration // 客户端重连间隔，为 0 时不发送
+	Data  any           // 事件数据，string 和 []byte 原样发送，其他类型序列化为 JSON，包含换行符时拆分为多个 data 行
+}
+
+// SSEOption SSE 写入器选项
+type SSEOption func(sw *SSEWriter)
+
+// WithSSEHeartbeat 设置心跳间隔，定时发送注释行以保持连接，默认不发送
+func WithSSEHeartbeat(interval time.Duration) (opt SSEOption) {
+	return func(sw *SSEWriter) {
+		sw.heartbeat = interval
+	}
+}
+
+// WithSSEHeader 设置额外的响应头
+func WithSSEHeader(key, value string) (opt SSEOption) {
+	return func(sw *SSEWriter) {
+		sw.w.Header().Set(key, value)
+	}
+}
+
+// SSEWriter SSE 流式响应写入器，并发安全
+type SSEWriter struct {
+	w         http.ResponseWriter
+	rc        *http.ResponseController
+	ctx       context.Context
+	heartbeat time.Duration
+	mu        sync.Mutex
+	closed    bool
+	done      chan struct{}
+}
+
+// NewSSEWriter 新建 SSE 写入器，设置 SSE 响应头并立即发送
+//
+//	客户端断开连接（r.Context() 结束）后写入会返回 context 错误，使用完毕后需要调用 Close
+func NewSSEWriter(w http.ResponseWriter, r *http.Request, opts ...SSEOption) (sw *SSEWriter, err error) {
+	sw = &SSEWriter{
+		w:    w,
+		rc:   http.NewResponseController(w),
+		ctx:  r.Context(),
+		done: make(chan struct{}),
+	}
+	// 设置`SSE`的响应头信息
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	for _, opt := range opts {
+		opt(sw)
+	}
+	w.WriteHeader(http.StatusOK)
+	if err = sw.rc.Flush(); err != nil {
+		return
+	}
+	// 心跳
+	if sw.heartbeat > 0 {
+		go sw.runHeartbeat()
+	}
+	return
+}
+
+// Context 获取请求的上下文，客户端断开连接时结束
+func (sw *SSEWriter) Context() (ctx context.Context) {
+	return sw.ctx
+}
+
+// Send 发送成功响应数据，数据格式为 Response
+func (sw *SSEWriter) Send(data any) (err error) {
+	return sw.SendEvent(SSEEvent{Data: Succ(data)})
+}
+
+// SendFail 发送失败响应数据，数据格式为 Response
+func (sw *SSEWriter) SendFail(code int, msg string, data ...any) (err error) {
+	return sw.SendEvent(SSEEvent{Data: Fail(code, msg, data...)})
+}
+
+// SendDone 发送结束标记 data: [DONE]
+func (sw *SSEWriter) SendDone() (err error) {
+	return sw.SendEvent(SSEEvent{Data: "[DONE]"})
+}
+
+// SendEvent 发送事件，ID 或 Event 包含 CR、LF 时返回 ErrSSEInvalidField，避免注入额外的字段或事件
+func (sw *SSEWriter) SendEvent(event SSEEvent) (err error) {
+	for _, field := range [][2]string{{"id", event.ID}, {"event", event.Event}} {
+		if strings.ContainsAny(field[1], "\r\n") {
+			return fmt.Errorf("%w: %s", ErrSSEInvalidField, field[0])
+		}
+	}
+	var buf bytes.Buffer
+	if event.ID != "" {
+		writeSSEField(&buf, "id", []byte(event.ID))
+	}
+	if event.Event != "" {
+		writeSSEField(&buf, "event", []byte(event.Event))
+	}
+	if event.Retry > 0 {
+		writeSSEField(&buf, "retry", []byte(strconv.FormatInt(event.Retry.Milliseconds(), 10)))
+	}
+	if event.Data != nil {
+		var data []byte
+		switch v := event.Data.(type) {
+		case []byte:
+			data = v
+		case string:
+			data = []byte(v)
+		default:
+			if data, err = json.Marshal(v); err != nil {
+				return
+			}
+		}
+		for line := range bytes.Lines(data) {
+			writeSSEField(&buf, "data", bytes.TrimRight(line, "\r\n"))
+		}
+		if len(data) == 0 {
+			writeSSEField(&buf, "data", nil)
+		}
+	}
+	buf.WriteByte('\n')
+	return sw.write(buf.Bytes())
+}
+
+// Comment 发送注释行，客户端会忽略注释行，通常用于保持连接
+func (sw *SSEWriter) Comment(text string) (err error) {
+	var buf bytes.Buffer
+	for line := range bytes.Lines([]byte(text)) {
+		buf.WriteString(": ")
+		buf.Write(bytes.TrimRight(line, "\r\n"))
+		buf.WriteByte('\n')
+	}
+	if text == "" {
+		buf.WriteString(":\n")
+	}
+	buf.WriteByte('\n')
+	return sw.write(buf.Bytes())
+}
+
+// Close 关闭写入器，停止心跳，不会关闭底层连接
+func (sw *SSEWriter) Close() (err error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if !sw.closed {
+		sw.closed = true
+		close(sw.done)
+	}
+	return
+}
+
+// write 写入数据并立即发送
+func (sw *SSEWriter) write(b []byte) (err error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.closed {
+		return ErrSSEWriterClosed
+	}
+	if err = sw.ctx.Err(); err != nil {
+		return
+	}
+	if _, err = sw.w.Write(b); err != nil {
+		return
+	}
+	return sw.rc.Flush()
+}
+
+// runHeartbeat 定时发送心跳
+func (sw *SSEWriter) runHeartbeat() {
+	ticker := time.NewTicker(sw.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sw.ctx.Done():
+			return
+		case <-sw.done:
+			return
+		case <-ticker.C:
+			if err := sw.Comment("ping"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeSSEField 写入 SSE 字段
+func writeSSEField(buf *bytes.Buffer, field string, value []byte) {
+	buf.WriteString(field)
+	buf.WriteString(": ")
+	buf.Write(value)
+	buf.WriteByte('\n')
+}
+
+// StreamSource 流式数据源，gtkhttp.StreamReader[T] 实现了该接口
+type StreamSource[T any] interface {
+	Recv() (response T, isFinished bool, err error) // 接收数据
+}
+
+// RelayStream 将流式数据源中的数据逐条以 Response 格式转发到 SSE 写入器，直到数据源结束、出错或客户端断开连接
+//
+//	数据源出错时会发送失败响应并返回该错误；数据源实现了 io.Closer 时，转发结束后会将其关闭
+func RelayStream[T any](sw *SSEWriter, src StreamSource[T]) (err error) {
+	return RelayStreamFunc(sw, src, func(sw *SSEWriter, data T) (err error) {
+		return sw.Send(data)
+	})
+}
+
+// RelayStreamFunc 将流式数据源中的数据逐条通过 send 转发到 SSE 写入器，可用于转换数据或发送原始事件
+func RelayStreamFunc[T any](sw *SSEWriter, src StreamSource[T], send func(sw *SSEWriter, data T) (err error)) (err error) {
+	if closer, ok := src.(io.Closer); ok {
+		defer closer.Close()
+	}
+	for {
+		if err = sw.ctx.Err(); err != nil {
+			return
+		}
+		data, isFinished, e := src.Recv()
+		if e != nil {
+			sw.SendFail(http.StatusBadGateway, e.Error())
+			return e
+		}
+		if isFinished {
+			return
+		}
+		if err = send(sw, data); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,235 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-26 10:14:52
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-26 10:14:52
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkresp_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"github.com/liusuxian/go-toolkit/gtkresp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testSource 测试用的流式数据源，依次返回 items，之后返回 err，err 为空时返回结束
+type testSource struct {
+	items  []string
+	err    error
+	block  chan struct{} // 不为空时 items 返回完毕后阻塞，直到关闭
+	closed atomic.Bool
+}
+
+func (s *testSource) Recv() (response string, isFinished bool, err error) {
+	if len(s.items) > 0 {
+		response, s.items = s.items[0], s.items[1:]
+		return
+	}
+	if s.block != nil {
+		<-s.block
+		return "late", false, nil
+	}
+	if s.err != nil {
+		return "", false, s.err
+	}
+	return "", true, nil
+}
+
+func (s *testSource) Close() (err error) {
+	s.closed.Store(true)
+	return
+}
+
+// readSSEEvent 读取一个以空行结束的事件
+func readSSEEvent(reader *bufio.Reader) (event string, err error) {
+	var sb strings.Builder
+	for {
+		var line string
+		if line, err = reader.ReadString('\n'); err != nil {
+			return
+		}
+		if line == "\n" {
+			return sb.String(), nil
+		}
+		sb.WriteString(line)
+	}
+}
+
+func TestSSEWriter(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		rec    = httptest.NewRecorder()
+		req    = httptest.NewRequest(http.MethodGet, "/sse", nil)
+	)
+	sw, err := gtkresp.NewSSEWriter(rec, req, gtkresp.WithSSEHeader("X-Stream", "1"))
+	if !assert.NoError(err) {
+		return
+	}
+	// 响应头立即发送
+	assert.True(rec.Flushed)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal("text/event-stream; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal("no-cache", rec.Header().Get("Cache-Control"))
+	assert.Equal("1", rec.Header().Get("X-Stream"))
+
+	assert.NoError(sw.SendEvent(gtkresp.SSEEvent{ID: "7", Event: "delta", Retry: 1500 * time.Millisecond, Data: "line1\nline2\r\nline3"}))
+	assert.NoError(sw.SendEvent(gtkresp.SSEEvent{Data: []byte{}}))
+	assert.NoError(sw.SendEvent(gtkresp.SSEEvent{Event: "ping"}))
+	assert.NoError(sw.Send(map[string]any{"text": "hi"}))
+	assert.NoError(sw.SendFail(500, "failed"))
+	assert.NoError(sw.Comment("keep\nalive"))
+	assert.NoError(sw.SendDone())
+	assert.Equal("id: 7\nevent: delta\nretry: 1500\ndata: line1\ndata: line2\ndata: line3\n\n"+
+		"data: \n\n"+
+		"event: ping\n\n"+
+		"data: {\"code\":0,\"message\":\"\",\"data\":{\"text\":\"hi\"}}\n\n"+
+		"data: {\"code\":500,\"message\":\"failed\",\"data\":null}\n\n"+
+		": keep\n: alive\n\n"+
+		"data: [DONE]\n\n", rec.Body.String())
+
+	// ID 和事件类型不能包含换行符，避免注入额外的字段或事件
+	rec.Body.Reset()
+	assert.ErrorIs(sw.SendEvent(gtkresp.SSEEvent{ID: "1\ndata: injected", Data: "x"}), gtkresp.ErrSSEInvalidField)
+	assert.ErrorIs(sw.SendEvent(gtkresp.SSEEvent{Event: "delta\r\n\r\ndata: injected", Data: "x"}), gtkresp.ErrSSEInvalidField)
+	assert.Empty(rec.Body.String())
+
+	// 关闭后不能写入
+	assert.NoError(sw.Close())
+	assert.NoError(sw.Close())
+	assert.ErrorIs(sw.SendDone(), gtkresp.ErrSSEWriterClosed)
+	// 数据无法序列化
+	sw, _ = gtkresp.NewSSEWriter(httptest.NewRecorder(), req)
+	assert.Error(sw.SendEvent(gtkresp.SSEEvent{Data: make(chan int)}))
+}
+
+func TestSSEWriterFlush(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		next   = make(chan struct{})
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw, err := gtkresp.NewSSEWriter(w, r, gtkresp.WithSSEHeartbeat(20*time.Millisecond))
+			if err != nil {
+				return
+			}
+			defer sw.Close()
+			sw.SendEvent(gtkresp.SSEEvent{Data: "first"})
+			// 客户端收到第一个事件后才发送第二个事件，每个事件都立即发送而不是等待响应结束
+			<-next
+			sw.SendEvent(gtkresp.SSEEvent{Data: "second"})
+		}))
+	)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if !assert.NoError(err) {
+		return
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	event, err := readSSEEvent(reader)
+	assert.NoError(err)
+	assert.Equal("data: first\n", event)
+	// 等待期间发送心跳
+	event, err = readSSEEvent(reader)
+	assert.NoError(err)
+	assert.Equal(": ping\n", event)
+	close(next)
+	for {
+		if event, err = readSSEEvent(reader); err != nil || event != ": ping\n" {
+			break
+		}
+	}
+	assert.NoError(err)
+	assert.Equal("data: second\n", event)
+}
+
+func TestRelayStream(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		req      = httptest.NewRequest(http.MethodGet, "/sse", nil)
+		errUp    = errors.New("upstream broken")
+		finished = &testSource{items: []string{"a", "b"}}
+		failed   = &testSource{items: []string{"a"}, err: errUp}
+	)
+	// 数据源结束
+	rec := httptest.NewRecorder()
+	sw, _ := gtkresp.NewSSEWriter(rec, req)
+	assert.NoError(gtkresp.RelayStream(sw, finished))
+	assert.True(finished.closed.Load())
+	assert.Equal("data: {\"code\":0,\"message\":\"\",\"data\":\"a\"}\n\n"+
+		"data: {\"code\":0,\"message\":\"\",\"data\":\"b\"}\n\n", rec.Body.String())
+	// 数据源出错时发送失败响应并返回错误
+	rec = httptest.NewRecorder()
+	sw, _ = gtkresp.NewSSEWriter(rec, req)
+	assert.ErrorIs(gtkresp.RelayStream(sw, failed), errUp)
+	assert.True(failed.closed.Load())
+	assert.Equal("data: {\"code\":0,\"message\":\"\",\"data\":\"a\"}\n\n"+
+		"data: {\"code\":502,\"message\":\"upstream broken\",\"data\":null}\n\n", rec.Body.String())
+	// 自定义转发
+	rec = httptest.NewRecorder()
+	sw, _ = gtkresp.NewSSEWriter(rec, req)
+	assert.NoError(gtkresp.RelayStreamFunc(sw, &testSource{items: []string{"x"}}, func(sw *gtkresp.SSEWriter, data string) (err error) {
+		return sw.SendEvent(gtkresp.SSEEvent{Event: "raw", Data: data})
+	}))
+	assert.Equal("event: raw\ndata: x\n\n", rec.Body.String())
+	// 转发出错时停止转发
+	rec = httptest.NewRecorder()
+	sw, _ = gtkresp.NewSSEWriter(rec, req)
+	sw.Close()
+	assert.ErrorIs(gtkresp.RelayStream(sw, &testSource{items: []string{"x", "y"}}), gtkresp.ErrSSEWriterClosed)
+}
+
+func TestRelayStreamClientDisconnect(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		src      = &testSource{items: []string{"a"}, block: make(chan struct{})}
+		relayErr = make(chan error, 1)
+		server   = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw, err := gtkresp.NewSSEWriter(w, r)
+			if err != nil {
+				relayErr <- err
+				return
+			}
+			defer sw.Close()
+			// 服务端感知到连接断开后数据源才返回下一条数据
+			go func() {
+				<-r.Context().Done()
+				close(src.block)
+			}()
+			relayErr <- gtkresp.RelayStream(sw, src)
+		}))
+	)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(err) {
+		cancel()
+		return
+	}
+	event, err := readSSEEvent(bufio.NewReader(resp.Body))
+	assert.NoError(err)
+	assert.Equal("data: {\"code\":0,\"message\":\"\",\"data\":\"a\"}\n", event)
+	// 客户端断开连接后，数据源返回的下一条数据不再转发，转发结束并关闭数据源
+	cancel()
+	resp.Body.Close()
+	select {
+	case err = <-relayErr:
+		assert.ErrorIs(err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		assert.Fail("relay did not stop after client disconnect")
+	}
+	assert.True(src.closed.Load())
+}
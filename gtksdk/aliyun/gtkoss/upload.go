@@ -11,10 +11,13 @@ package gtkoss
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/liusuxian/go-toolkit/gtkhttp"
 	"github.com/liusuxian/go-toolkit/internal/utils"
+	"io"
 	"io/fs"
 	"mime/multipart"
 	"net/http"
@@ -529,3 +532,42 @@ func (s *AliyunOSS) doUploadFromFile(fileName, dirPath string, fileStat fs.FileI
 	}
 	return
 }
+
+// OSSUploadSink 阿里云OSS流式上传写入目标，实现了 gtkhttp.UploadSink，可用于 gtkhttp.UploadFileService.StreamUpload
+type OSSUploadSink struct {
+	oss  *AliyunOSS
+	opts []Option
+}
+
+var _ gtkhttp.UploadSink = (*OSSUploadSink)(nil)
+
+// UploadSink 新建阿里云OSS流式上传写入目标，文件名由 gtkhttp.UploadFileService 生成，不再经过本服务的 uploadFileNameFn
+func (s *AliyunOSS) UploadSink(opts ...Option) (sink *OSSUploadSink) {
+	return &OSSUploadSink{oss: s, opts: opts}
+}
+
+// Save 保存文件
+func (sink *OSSUploadSink) Save(ctx context.Context, dirPath, fileName string, reader io.Reader) (filePath string, err error) {
+	// 构建文件完整路径
+	if dirPath == "" {
+		filePath = fileName
+	} else {
+		filePath = dirPath + "/" + fileName
+	}
+	// 获取存储空间
+	var (
+		client *oss.Client
+		bucket *oss.Bucket
+	)
+	if client, bucket, err = sink.oss.getBucket(sink.opts...); err != nil {
+		return "", err
+	}
+	// 关闭空闲连接
+	defer sink.oss.closeIdleConnections(client)
+	// 上传文件
+	ossOptions := append(slices.Clone(sink.oss.ossOptions), oss.WithContext(ctx))
+	if err = bucket.PutObject(filePath, reader, ossOptions...); err != nil {
+		return "", err
+	}
+	return
+}
@@ -0,0 +1,135 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-25 15:21:38
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-25 15:21:38
+ * @Description: 日志告警
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkrobot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/liusuxian/go-toolkit/gtklog"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxAlertMessageLen   = 1000      // 告警卡片中日志内容的最大长度（字符数）
+	shortAlertMessageLen = 200       // 卡片内容接近上限时，日志内容和字段的最大长度（字符数）
+	maxAlertCardSize     = 16 * 1024 // 告警卡片中告警内容的最大字节数，飞书 webhook 请求体上限约 20KB
+	alertElementOverhead = 64        // 每条告警的卡片元素（分割线、div 等）占用的字节数估计值
+)
+
+// larkMdEscaper 转义 lark_md 中的标签和 Markdown 标记，避免日志内容中的 <at id=all></at> 等被解析
+var larkMdEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"*", "&#42;",
+	"~", "&#126;",
+	"`", "&#96;",
+	"[", "&#91;",
+	"]", "&#93;",
+)
+
+// SendAlerts 以卡片消息发送一批告警，实现 gtklog.Alerter
+//
+//	如：logger := gtklog.NewAlertLogger(logger, gtkrobot.NewFeishuRobot(url, gtkrobot.WithSecret(secret)), gtklog.AlertConfig{Env: "prod"})
+func (fr *FeishuRobot) SendAlerts(ctx context.Context, batch *gtklog.AlertBatch) (err error) {
+	if len(batch.Alerts) == 0 {
+		return
+	}
+	return fr.SendCardMessage(ctx, NewAlertCard(batch, fr.alertMentions...))
+}
+
+// NewAlertCard 根据一批告警创建卡片，包含环境、服务、主机以及每条告警的调用者、请求ID、出现次数和日志字段
+//
+//	日志内容和字段会被转义；卡片内容接近飞书的大小上限时，先缩短日志内容和字段，仍然超出的告警计入未发送的告警数
+func NewAlertCard(batch *gtklog.AlertBatch, mentions ...FeishuMention) (card *FeishuCard) {
+	var (
+		total    int
+		template = CardTemplateOrange
+	)
+	for _, alert := range batch.Alerts {
+		total += alert.Count
+		if alert.Level <= gtklog.FatalLevel {
+			template = CardTemplateRed
+		}
+	}
+	title := "错误告警"
+	if batch.Service != "" {
+		title = batch.Service + " " + title
+	}
+	if batch.Env != "" {
+		title = "[" + batch.Env + "] " + title
+	}
+	card = NewFeishuCard(fmt.Sprintf("%s（%d）", title, total), template)
+
+	var fields []FeishuCardField
+	for _, kv := range [][2]string{{"环境", batch.Env}, {"服务", batch.Service}, {"主机", batch.Host}} {
+		if kv[1] != "" {
+			fields = append(fields, ShortField(kv[0], larkMdEscaper.Replace(kv[1])))
+		}
+	}
+	if len(fields) > 0 {
+		card.AddFields(fields...)
+	}
+	var (
+		size    int
+		omitted = batch.Omitted
+	)
+	for i, alert := range batch.Alerts {
+		content := alertMarkdown(alert, maxAlertMessageLen)
+		if size+jsonSize(content)+alertElementOverhead > maxAlertCardSize {
+			content = alertMarkdown(alert, shortAlertMessageLen)
+		}
+		if size+jsonSize(content)+alertElementOverhead > maxAlertCardSize {
+			for _, rest := range batch.Alerts[i:] {
+				omitted += rest.Count
+			}
+			break
+		}
+		size += jsonSize(content) + alertElementOverhead
+		card.AddDivider().AddMarkdown(content)
+	}
+	if omitted > 0 {
+		card.AddNote(fmt.Sprintf("另有 %d 条告警因数量过多或发送频率限制未发送", omitted))
+	}
+	card.AddMentions(mentions...)
+	return
+}
+
+// alertMarkdown 单条告警的 Markdown 内容，日志内容和字段值最多保留 maxLen 个字符
+func alertMarkdown(alert *gtklog.Alert, maxLen int) (content string) {
+	var sb strings.Builder
+	sb.WriteString("**[" + alert.Level.CapitalString() + "]** " + larkMdEscaper.Replace(alert.Caller))
+	if alert.Count > 1 {
+		sb.WriteString(" × " + strconv.Itoa(alert.Count))
+	}
+	sb.WriteString("\n" + larkMdEscaper.Replace(truncateRunes(alert.Message, maxLen)))
+	for _, field := range alert.Fields {
+		sb.WriteString("\n**" + larkMdEscaper.Replace(field.Key) + "**: " + larkMdEscaper.Replace(truncateRunes(fmt.Sprint(field.Value), maxLen)))
+	}
+	sb.WriteString("\n**time**: " + alert.Time.Format("2006-01-02 15:04:05.000"))
+	return sb.String()
+}
+
+// jsonSize 字符串序列化为 JSON 后的字节数
+func jsonSize(s string) (size int) {
+	data, _ := json.Marshal(s)
+	return len(data)
+}
+
+// truncateRunes 截断字符串，超过 maxLen 个字符时保留前 maxLen 个字符并追加省略号
+func truncateRunes(s string, maxLen int) (result string) {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen]) + "..."
+}
@@ -0,0 +1,142 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-25 15:21:38
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-25 15:21:38
+ * @Description: 飞书卡片消息
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtkrobot
+
+// 卡片标题颜色
+const (
+	CardTemplateBlue   = "blue"   // 蓝色
+	CardTemplateGreen  = "green"  // 绿色
+	CardTemplateOrange = "orange" // 橙色
+	CardTemplateRed    = "red"    // 红色
+	CardTemplateGrey   = "grey"   // 灰色
+)
+
+// FeishuMention @ 的用户
+type FeishuMention struct {
+	UserID string // 用户的 open_id 或 user_id，all 表示所有人
+	Name   string // 用户名称，仅文本消息使用
+}
+
+// MentionAll @ 所有人
+var MentionAll = FeishuMention{UserID: "all", Name: "所有人"}
+
+// textTag 文本消息中的 @ 标签
+func (m FeishuMention) textTag() (tag string) {
+	return `<at user_id="` + m.UserID + `">` + m.Name + `</at>`
+}
+
+// cardTag 卡片消息中的 @ 标签
+func (m FeishuMention) cardTag() (tag string) {
+	return "<at id=" + m.UserID + "></at>"
+}
+
+// FeishuCard 飞书卡片
+type FeishuCard struct {
+	Config   *FeishuCardConfig `json:"config,omitempty" dc:"卡片配置"` // 卡片配置
+	Header   *FeishuCardHeader `json:"header,omitempty" dc:"卡片标题"` // 卡片标题
+	Elements []any             `json:"elements" dc:"卡片内容"`         // 卡片内容元素
+}
+
+// FeishuCardConfig 飞书卡片配置
+type FeishuCardConfig struct {
+	WideScreenMode bool `json:"wide_screen_mode" dc:"是否宽屏"` // 是否宽屏显示
+	EnableForward  bool `json:"enable_forward" dc:"是否允许转发"` // 是否允许转发
+}
+
+// FeishuCardHeader 飞书卡片标题
+type FeishuCardHeader struct {
+	Title    FeishuCardText `json:"title" dc:"标题"`                // 标题
+	Template string         `json:"template,omitempty" dc:"标题颜色"` // 标题颜色，如 CardTemplateRed
+}
+
+// FeishuCardText 飞书卡片文本
+type FeishuCardText struct {
+	Tag     string `json:"tag" dc:"文本类型"`     // 文本类型，plain_text 或 lark_md
+	Content string `json:"content" dc:"文本内容"` // 文本内容
+}
+
+// FeishuCardField 飞书卡片字段，多个短字段并排显示
+type FeishuCardField struct {
+	IsShort bool           `json:"is_short" dc:"是否并排显示"` // 是否并排显示
+	Text    FeishuCardText `json:"text" dc:"字段内容"`       // 字段内容
+}
+
+// feishuCardDiv 飞书卡片内容块
+type feishuCardDiv struct {
+	Tag    string            `json:"tag"`
+	Text   *FeishuCardText   `json:"text,omitempty"`
+	Fields []FeishuCardField `json:"fields,omitempty"`
+}
+
+// feishuCardTag 只有标签的飞书卡片元素，如分割线
+type feishuCardTag struct {
+	Tag string `json:"tag"`
+}
+
+// feishuCardNote 飞书卡片备注
+type feishuCardNote struct {
+	Tag      string           `json:"tag"`
+	Elements []FeishuCardText `json:"elements"`
+}
+
+// NewFeishuCard 新建飞书卡片，template 为标题颜色，如 CardTemplateRed
+func NewFeishuCard(title, template string) (card *FeishuCard) {
+	return &FeishuCard{
+		Config: &FeishuCardConfig{WideScreenMode: true, EnableForward: true},
+		Header: &FeishuCardHeader{
+			Title:    FeishuCardText{Tag: "plain_text", Content: title},
+			Template: template,
+		},
+	}
+}
+
+// AddMarkdown 添加 Markdown 内容块
+func (c *FeishuCard) AddMarkdown(content string) (card *FeishuCard) {
+	c.Elements = append(c.Elements, feishuCardDiv{Tag: "div", Text: &FeishuCardText{Tag: "lark_md", Content: content}})
+	return c
+}
+
+// AddFields 添加字段，如 AddFields(ShortField("环境", "prod"), ShortField("主机", "host-1"))
+func (c *FeishuCard) AddFields(fields ...FeishuCardField) (card *FeishuCard) {
+	c.Elements = append(c.Elements, feishuCardDiv{Tag: "div", Fields: fields})
+	return c
+}
+
+// AddDivider 添加分割线
+func (c *FeishuCard) AddDivider() (card *FeishuCard) {
+	c.Elements = append(c.Elements, feishuCardTag{Tag: "hr"})
+	return c
+}
+
+// AddNote 添加备注
+func (c *FeishuCard) AddNote(content string) (card *FeishuCard) {
+	c.Elements = append(c.Elements, feishuCardNote{Tag: "note", Elements: []FeishuCardText{{Tag: "plain_text", Content: content}}})
+	return c
+}
+
+// AddMentions 添加 @ 用户
+func (c *FeishuCard) AddMentions(mentions ...FeishuMention) (card *FeishuCard) {
+	if len(mentions) == 0 {
+		return c
+	}
+	var content string
+	for i, mention := range mentions {
+		if i > 0 {
+			content += " "
+		}
+		content += mention.cardTag()
+	}
+	return c.AddMarkdown(content)
+}
+
+// ShortField 创建并排显示的字段，标题加粗显示
+func ShortField(title, value string) (field FeishuCardField) {
+	return FeishuCardField{IsShort: true, Text: FeishuCardText{Tag: "lark_md", Content: "**" + title + "**\n" + value}}
+}
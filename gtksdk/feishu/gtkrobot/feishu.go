@@ -11,21 +11,49 @@ package gtkrobot
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
 	"github.com/liusuxian/go-toolkit/gtkhttp"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // FeishuRobot
 type FeishuRobot struct {
-	webHookURL string
-	httpClient *gtkhttp.HTTPClient // http 客户端
+	webHookURL    string
+	secret        string              // 签名密钥
+	alertMentions []FeishuMention     // 告警消息 @ 的用户
+	httpClient    *gtkhttp.HTTPClient // http 客户端
+}
+
+// Option 飞书机器人选项
+type Option func(fr *FeishuRobot)
+
+// WithSecret 设置签名密钥，对应机器人安全设置中的签名校验
+func WithSecret(secret string) (opt Option) {
+	return func(fr *FeishuRobot) {
+		fr.secret = secret
+	}
+}
+
+// WithAlertMentions 设置告警消息 @ 的用户
+func WithAlertMentions(mentions ...FeishuMention) (opt Option) {
+	return func(fr *FeishuRobot) {
+		fr.alertMentions = mentions
+	}
 }
 
 // FeiShuMessage 飞书消息
 type FeiShuMessage struct {
-	MsgType string        `json:"msg_type" dc:"消息类型"` // 消息类型
-	Content FeishuContent `json:"content" dc:"消息内容"`  // 消息内容
+	Timestamp string        `json:"timestamp,omitempty" dc:"签名时间戳"` // 签名时间戳（秒），设置了签名密钥时自动填充
+	Sign      string        `json:"sign,omitempty" dc:"签名"`         // 签名，设置了签名密钥时自动填充
+	MsgType   string        `json:"msg_type" dc:"消息类型"`             // 消息类型
+	Content   FeishuContent `json:"content,omitzero" dc:"消息内容"`     // 消息内容
+	Card      *FeishuCard   `json:"card,omitempty" dc:"卡片内容"`       // 卡片内容，消息类型为 interactive 时有效
 }
 
 // FeishuContent 飞书消息内容
@@ -33,19 +61,33 @@ type FeishuContent struct {
 	Text string `json:"text" dc:"文本内容"` // 文本内容
 }
 
+// feishuResponse 飞书接口响应
+type feishuResponse struct {
+	gtkhttp.HttpHeader
+	Code int    `json:"code"` // 错误码，0 表示成功
+	Msg  string `json:"msg"`  // 错误信息
+}
+
 // NewFeishuRobot 新建飞书机器人
-func NewFeishuRobot(webHookURL string) (fr *FeishuRobot) {
-	return &FeishuRobot{
+func NewFeishuRobot(webHookURL string, opts ...Option) (fr *FeishuRobot) {
+	fr = &FeishuRobot{
 		webHookURL: webHookURL,
 		httpClient: gtkhttp.NewHTTPClient(""),
 	}
+	for _, opt := range opts {
+		opt(fr)
+	}
+	return
 }
 
-// SendTextMessage 发送文本消息
-func (fr *FeishuRobot) SendTextMessage(ctx context.Context, content string) (err error) {
+// SendTextMessage 发送文本消息，mentions 不为空时在消息末尾 @ 指定的用户
+func (fr *FeishuRobot) SendTextMessage(ctx context.Context, content string, mentions ...FeishuMention) (err error) {
 	if strings.Trim(fr.webHookURL, " ") == "" {
 		return
 	}
+	for _, mention := range mentions {
+		content += " " + mention.textTag()
+	}
 	return fr.send(ctx, FeiShuMessage{
 		MsgType: "text",
 		Content: FeishuContent{
@@ -54,17 +96,46 @@ func (fr *FeishuRobot) SendTextMessage(ctx context.Context, content string) (err
 	})
 }
 
-// send 发送
+// SendCardMessage 发送卡片消息
+func (fr *FeishuRobot) SendCardMessage(ctx context.Context, card *FeishuCard) (err error) {
+	if strings.Trim(fr.webHookURL, " ") == "" {
+		return
+	}
+	return fr.send(ctx, FeiShuMessage{
+		MsgType: "interactive",
+		Card:    card,
+	})
+}
+
+// send 发送，设置了签名密钥时自动签名，飞书返回错误码时返回错误
 func (fr *FeishuRobot) send(ctx context.Context, data FeiShuMessage) (err error) {
+	if fr.secret != "" {
+		timestamp := time.Now().Unix()
+		data.Timestamp = strconv.FormatInt(timestamp, 10)
+		data.Sign = genFeishuSign(fr.secret, timestamp)
+	}
 	var (
 		setters = []gtkhttp.RequestOption{
 			gtkhttp.WithBody(data),
 			gtkhttp.WithContentType("application/json; charset=utf-8"),
 		}
-		req *http.Request
+		req  *http.Request
+		resp feishuResponse
 	)
 	if req, err = fr.httpClient.NewRequest(ctx, http.MethodPost, fr.webHookURL, setters...); err != nil {
 		return
 	}
-	return fr.httpClient.SendRequest(req, nil)
+	if err = fr.httpClient.SendRequest(req, &resp); err != nil {
+		return
+	}
+	if resp.Code != 0 {
+		return fmt.Errorf("feishu robot error, code: %d, msg: %s", resp.Code, resp.Msg)
+	}
+	return
+}
+
+// genFeishuSign 生成签名，以 timestamp + "\n" + 密钥作为 HmacSHA256 的密钥计算空字符串的签名，再进行 Base64 编码
+func genFeishuSign(secret string, timestamp int64) (sign string) {
+	h := hmac.New(sha256.New, []byte(strconv.FormatInt(timestamp, 10)+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
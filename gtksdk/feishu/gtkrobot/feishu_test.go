@@ -11,11 +11,21 @@ package gtkrobot_test
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"github.com/joho/godotenv"
 	"github.com/liusuxian/go-toolkit/gtkenv"
+	"github.com/liusuxian/go-toolkit/gtklog"
 	"github.com/liusuxian/go-toolkit/gtksdk/feishu/gtkrobot"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSendTextMessage(t *testing.T) {
@@ -32,3 +42,97 @@ func TestSendTextMessage(t *testing.T) {
 	err = fr.SendTextMessage(ctx, "我是测试日志上报内容，并不是真的服务器报错，请悉知")
 	assert.NoError(err)
 }
+
+func TestSendAlerts(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		ctx      = context.Background()
+		received []map[string]any
+		code     int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"code": code, "msg": "sign match fail"})
+	}))
+	defer server.Close()
+
+	fr := gtkrobot.NewFeishuRobot(server.URL, gtkrobot.WithSecret("secret"), gtkrobot.WithAlertMentions(gtkrobot.MentionAll))
+	// 文本消息 @ 用户，并校验签名
+	err := fr.SendTextMessage(ctx, "hello", gtkrobot.FeishuMention{UserID: "ou_1", Name: "Tom"})
+	assert.NoError(err)
+	body := received[0]
+	assert.Equal("text", body["msg_type"])
+	assert.Equal(`hello <at user_id="ou_1">Tom</at>`, body["content"].(map[string]any)["text"])
+	h := hmac.New(sha256.New, []byte(body["timestamp"].(string)+"\n"+"secret"))
+	assert.Equal(base64.StdEncoding.EncodeToString(h.Sum(nil)), body["sign"])
+	// 告警卡片
+	err = fr.SendAlerts(ctx, &gtklog.AlertBatch{
+		Service: "chat",
+		Env:     "prod",
+		Host:    "host-1",
+		Alerts: []*gtklog.Alert{
+			{Time: time.Now(), Level: gtklog.ErrorLevel, Caller: "mq.go:10", Message: "consume failed", Count: 3, Fields: []gtklog.Field{gtklog.F("request_id", "req-1")}},
+			{Time: time.Now(), Level: gtklog.FatalLevel, Caller: "main.go:5", Message: "exit", Count: 1},
+		},
+		Omitted: 2,
+	})
+	assert.NoError(err)
+	body = received[1]
+	assert.Equal("interactive", body["msg_type"])
+	assert.NotContains(body, "content")
+	card := body["card"].(map[string]any)
+	header := card["header"].(map[string]any)
+	assert.Equal("red", header["template"])
+	assert.Equal("[prod] chat 错误告警（4）", header["title"].(map[string]any)["content"])
+	data, _ := json.Marshal(card["elements"])
+	assert.Contains(string(data), `**[ERROR]** mq.go:10 × 3\nconsume failed\n**request_id**: req-1`)
+	assert.Contains(string(data), "另有 2 条告警")
+	assert.Contains(string(data), `\u003cat id=all\u003e`)
+	// 飞书返回错误码
+	code = 19021
+	err = fr.SendCardMessage(ctx, gtkrobot.NewFeishuCard("title", gtkrobot.CardTemplateBlue).AddMarkdown("**hi**"))
+	assert.ErrorContains(err, "19021")
+}
+
+func TestNewAlertCardLimit(t *testing.T) {
+	var (
+		assert = assert.New(t)
+		long   = strings.Repeat("错", 1000)
+		alerts []*gtklog.Alert
+	)
+	for i := range 20 {
+		alerts = append(alerts, &gtklog.Alert{
+			Time:    time.Now(),
+			Level:   gtklog.ErrorLevel,
+			Caller:  "mq.go:" + strconv.Itoa(i),
+			Message: long,
+			Count:   2,
+			Fields:  []gtklog.Field{gtklog.F("content", long), gtklog.F("offset", long)},
+		})
+	}
+	// 卡片大小不超过飞书的上限，超出的告警计入未发送的告警数
+	card := gtkrobot.NewAlertCard(&gtklog.AlertBatch{Service: "chat", Alerts: alerts, Omitted: 1}, gtkrobot.MentionAll)
+	data, err := json.Marshal(card)
+	assert.NoError(err)
+	assert.Less(len(data), 20*1024)
+	text := string(data)
+	assert.Contains(text, "chat 错误告警（40）")
+	assert.Contains(text, "mq.go:0 × 2")
+	assert.NotContains(text, "mq.go:19 ")
+	sent := strings.Count(text, `"tag":"hr"`)
+	assert.Greater(sent, 0)
+	assert.Contains(text, "另有 "+strconv.Itoa(1+(20-sent)*2)+" 条告警")
+	// 日志内容和字段中的标签和 Markdown 标记被转义
+	card = gtkrobot.NewAlertCard(&gtklog.AlertBatch{Alerts: []*gtklog.Alert{
+		{Level: gtklog.ErrorLevel, Caller: "api.go:1", Message: "user input <at id=all></at> **bold**", Count: 1, Fields: []gtklog.Field{gtklog.F("name", "[link](http://x)")}},
+	}})
+	data, _ = json.Marshal(card)
+	text = string(data)
+	assert.NotContains(text, `\u003cat`)
+	assert.NotContains(text, "**bold**")
+	assert.Contains(text, `\u0026lt;at id=all\u0026gt;`)
+	assert.Contains(text, `**name**: \u0026#91;link\u0026#93;(http://x)`)
+}
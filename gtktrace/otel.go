@@ -0,0 +1,157 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 09:36:18
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 09:36:18
+ * @Description: OpenTelemetry 适配器
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtktrace
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OTelTracer 将 OpenTelemetry 的追踪器适配为 Tracer
+//
+//	跨度由 OTel SDK 创建和导出，上下文中同时保存 OTel 跨度，业务代码也可以直接使用 OTel API 创建子跨度
+type OTelTracer struct {
+	tracer trace.Tracer
+}
+
+// NewOTelTracer 创建 OpenTelemetry 适配器，如 NewOTelTracer(otel.Tracer("my-service"))
+func NewOTelTracer(tracer trace.Tracer) (t *OTelTracer) {
+	return &OTelTracer{tracer: tracer}
+}
+
+// Start 创建跨度
+func (t *OTelTracer) Start(ctx context.Context, name string, opts ...StartOption) (newCtx context.Context, span Span) {
+	config := NewStartConfig(opts...)
+	// 上下文中没有 OTel 跨度时，使用 gtktrace 的跨度上下文（如通过 Extract 解析的远程跨度上下文）作为父跨度
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if sc := SpanContextFromContext(ctx); sc.IsValid() {
+			ctx = trace.ContextWithRemoteSpanContext(ctx, toOTelSpanContext(sc))
+		}
+	}
+	otelOpts := []trace.SpanStartOption{
+		trace.WithSpanKind(toOTelSpanKind(config.Kind)),
+		trace.WithAttributes(toOTelAttributes(config.Attributes)...),
+	}
+	for _, link := range config.Links {
+		otelOpts = append(otelOpts, trace.WithLinks(trace.Link{SpanContext: toOTelSpanContext(link)}))
+	}
+	newCtx, otelSpan := t.tracer.Start(ctx, name, otelOpts...)
+	span = &otelSpanAdapter{span: otelSpan}
+	return ContextWithSpan(newCtx, span), span
+}
+
+// otelSpanAdapter 将 OTel 跨度适配为 Span
+type otelSpanAdapter struct {
+	span trace.Span
+}
+
+// SpanContext 获取跨度上下文
+func (s *otelSpanAdapter) SpanContext() (sc SpanContext) {
+	otelSC := s.span.SpanContext()
+	return SpanContext{
+		TraceID:    TraceID(otelSC.TraceID()),
+		SpanID:     SpanID(otelSC.SpanID()),
+		TraceFlags: byte(otelSC.TraceFlags()),
+		TraceState: otelSC.TraceState().String(),
+		Remote:     otelSC.IsRemote(),
+	}
+}
+
+// SetAttributes 设置属性
+func (s *otelSpanAdapter) SetAttributes(attrs ...Attribute) {
+	s.span.SetAttributes(toOTelAttributes(attrs)...)
+}
+
+// SetStatus 设置状态
+func (s *otelSpanAdapter) SetStatus(code StatusCode, description string) {
+	switch code {
+	case StatusError:
+		s.span.SetStatus(codes.Error, description)
+	case StatusOK:
+		s.span.SetStatus(codes.Ok, description)
+	default:
+		s.span.SetStatus(codes.Unset, description)
+	}
+}
+
+// RecordError 记录错误
+func (s *otelSpanAdapter) RecordError(err error) {
+	s.span.RecordError(err)
+}
+
+// End 结束跨度
+func (s *otelSpanAdapter) End() {
+	s.span.End()
+}
+
+// toOTelSpanContext 转换为 OTel 跨度上下文
+func toOTelSpanContext(sc SpanContext) (otelSC trace.SpanContext) {
+	config := trace.SpanContextConfig{
+		TraceID:    trace.TraceID(sc.TraceID),
+		SpanID:     trace.SpanID(sc.SpanID),
+		TraceFlags: trace.TraceFlags(sc.TraceFlags),
+		Remote:     sc.Remote,
+	}
+	if sc.TraceState != "" {
+		if ts, err := trace.ParseTraceState(sc.TraceState); err == nil {
+			config.TraceState = ts
+		}
+	}
+	return trace.NewSpanContext(config)
+}
+
+// toOTelSpanKind 转换为 OTel 跨度类型
+func toOTelSpanKind(kind SpanKind) (otelKind trace.SpanKind) {
+	switch kind {
+	case SpanKindServer:
+		return trace.SpanKindServer
+	case SpanKindClient:
+		return trace.SpanKindClient
+	case SpanKindProducer:
+		return trace.SpanKindProducer
+	case SpanKindConsumer:
+		return trace.SpanKindConsumer
+	default:
+		return trace.SpanKindInternal
+	}
+}
+
+// toOTelAttributes 转换为 OTel 属性
+func toOTelAttributes(attrs []Attribute) (kvs []attribute.KeyValue) {
+	kvs = make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		switch v := attr.Value.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(attr.Key, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(attr.Key, v))
+		case int:
+			kvs = append(kvs, attribute.Int(attr.Key, v))
+		case int32:
+			kvs = append(kvs, attribute.Int64(attr.Key, int64(v)))
+		case int64:
+			kvs = append(kvs, attribute.Int64(attr.Key, v))
+		case uint32:
+			kvs = append(kvs, attribute.Int64(attr.Key, int64(v)))
+		case float32:
+			kvs = append(kvs, attribute.Float64(attr.Key, float64(v)))
+		case float64:
+			kvs = append(kvs, attribute.Float64(attr.Key, v))
+		case []string:
+			kvs = append(kvs, attribute.StringSlice(attr.Key, v))
+		default:
+			kvs = append(kvs, attribute.String(attr.Key, fmt.Sprint(v)))
+		}
+	}
+	return
+}
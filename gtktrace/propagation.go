@@ -0,0 +1,121 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 09:36:18
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 09:36:18
+ * @Description: W3C Trace Context 传播，traceparent/tracestate
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtktrace
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	TraceparentHeader = "traceparent" // W3C traceparent 头键
+	TracestateHeader  = "tracestate"  // W3C tracestate 头键
+)
+
+// Carrier 追踪信息的载体，如 HTTP 请求头、消息头
+type Carrier interface {
+	Get(key string) (value string) // 获取值
+	Set(key, value string)         // 设置值
+}
+
+// HeaderCarrier 以 http.Header 作为载体
+type HeaderCarrier http.Header
+
+// Get 获取值
+func (c HeaderCarrier) Get(key string) (value string) {
+	return http.Header(c).Get(key)
+}
+
+// Set 设置值
+func (c HeaderCarrier) Set(key, value string) {
+	http.Header(c).Set(key, value)
+}
+
+// MapCarrier 以 map[string]string 作为载体，键不区分大小写
+type MapCarrier map[string]string
+
+// Get 获取值
+func (c MapCarrier) Get(key string) (value string) {
+	if value, ok := c[key]; ok {
+		return value
+	}
+	for k, v := range c {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// Set 设置值
+func (c MapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Inject 将上下文中的跨度上下文写入载体，没有有效的跨度上下文时不写入
+func Inject(ctx context.Context, carrier Carrier) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	carrier.Set(TraceparentHeader, FormatTraceparent(sc))
+	if sc.TraceState != "" {
+		carrier.Set(TracestateHeader, sc.TraceState)
+	}
+}
+
+// Extract 从载体中解析跨度上下文，并作为远程父跨度存入上下文，解析失败时返回原上下文
+func Extract(ctx context.Context, carrier Carrier) (newCtx context.Context) {
+	sc, err := ParseTraceparent(carrier.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	sc.TraceState = carrier.Get(TracestateHeader)
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// FormatTraceparent 格式化 traceparent
+func FormatTraceparent(sc SpanContext) (traceparent string) {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.TraceFlags)
+}
+
+// ParseTraceparent 解析 traceparent，格式为 version-traceid-spanid-flags
+func ParseTraceparent(traceparent string) (sc SpanContext, err error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("invalid traceparent: %q", traceparent)
+	}
+	// 版本 00 只能有 4 个部分，版本 ff 无效
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("invalid traceparent version: %q", traceparent)
+	}
+	var version, flags [1]byte
+	if _, err = hex.Decode(version[:], []byte(parts[0])); err != nil {
+		return sc, fmt.Errorf("invalid traceparent version: %q", traceparent)
+	}
+	if _, err = hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil || strings.ToLower(parts[1]) != parts[1] {
+		return SpanContext{}, fmt.Errorf("invalid trace id: %q", traceparent)
+	}
+	if _, err = hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil || strings.ToLower(parts[2]) != parts[2] {
+		return SpanContext{}, fmt.Errorf("invalid span id: %q", traceparent)
+	}
+	if _, err = hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return SpanContext{}, fmt.Errorf("invalid trace flags: %q", traceparent)
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent: %q", traceparent)
+	}
+	sc.TraceFlags = flags[0]
+	sc.Remote = true
+	return sc, nil
+}
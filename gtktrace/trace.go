@@ -0,0 +1,278 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 09:36:18
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 09:36:18
+ * @Description: 链路追踪接口，与 OpenTelemetry 兼容，可以接入 OTel SDK 或使用空实现
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtktrace
+
+import (
+	"context"
+	"encoding/hex"
+	"sync/atomic"
+)
+
+// TraceID 追踪ID
+type TraceID [16]byte
+
+// IsValid 是否有效（非全零）
+func (t TraceID) IsValid() (ok bool) {
+	return t != TraceID{}
+}
+
+// String 十六进制字符串
+func (t TraceID) String() (str string) {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID 跨度ID
+type SpanID [8]byte
+
+// IsValid 是否有效（非全零）
+func (s SpanID) IsValid() (ok bool) {
+	return s != SpanID{}
+}
+
+// String 十六进制字符串
+func (s SpanID) String() (str string) {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext 跨度上下文，即需要跨进程传递的追踪信息
+type SpanContext struct {
+	TraceID    TraceID // 追踪ID
+	SpanID     SpanID  // 跨度ID
+	TraceFlags byte    // 追踪标志，最低位表示是否采样
+	TraceState string  // 厂商自定义的追踪状态，原样传递
+	Remote     bool    // 是否是从其他进程传递过来的
+}
+
+// IsValid 是否有效
+func (sc SpanContext) IsValid() (ok bool) {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// IsSampled 是否采样
+func (sc SpanContext) IsSampled() (ok bool) {
+	return sc.TraceFlags&FlagsSampled == FlagsSampled
+}
+
+const (
+	FlagsSampled byte = 0x01 // 采样标志
+)
+
+// SpanKind 跨度类型
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = iota // 内部操作
+	SpanKindServer                   // 服务端处理请求
+	SpanKindClient                   // 客户端发出请求
+	SpanKindProducer                 // 生产者发送消息
+	SpanKindConsumer                 // 消费者处理消息
+)
+
+// String 跨度类型名称
+func (k SpanKind) String() (str string) {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	case SpanKindProducer:
+		return "producer"
+	case SpanKindConsumer:
+		return "consumer"
+	default:
+		return "internal"
+	}
+}
+
+// StatusCode 跨度状态码
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota // 未设置
+	StatusError                   // 错误
+	StatusOK                      // 成功
+)
+
+// Attribute 跨度属性，Value 支持 string、bool、整数、浮点数，其他类型会被转换为字符串
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Attr 创建跨度属性
+func Attr(key string, value any) (attr Attribute) {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span 跨度
+type Span interface {
+	SpanContext() (sc SpanContext)                 // 获取跨度上下文
+	SetAttributes(attrs ...Attribute)              // 设置属性
+	SetStatus(code StatusCode, description string) // 设置状态
+	RecordError(err error)                         // 记录错误，不会改变状态
+	End()                                          // 结束跨度，多次调用只有第一次生效
+}
+
+// Tracer 追踪器
+type Tracer interface {
+	// Start 创建跨度，父跨度从 ctx 中获取（本进程的跨度或通过 Extract 解析的远程跨度上下文），返回包含新跨度的上下文
+	Start(ctx context.Context, name string, opts ...StartOption) (newCtx context.Context, span Span)
+}
+
+// StartConfig 创建跨度的配置
+type StartConfig struct {
+	Kind       SpanKind      // 跨度类型
+	Attributes []Attribute   // 属性
+	Links      []SpanContext // 关联的跨度，如批量消费时每条消息的生产者跨度
+}
+
+// StartOption 创建跨度的选项
+type StartOption func(c *StartConfig)
+
+// WithSpanKind 设置跨度类型
+func WithSpanKind(kind SpanKind) (opt StartOption) {
+	return func(c *StartConfig) {
+		c.Kind = kind
+	}
+}
+
+// WithAttributes 设置属性
+func WithAttributes(attrs ...Attribute) (opt StartOption) {
+	return func(c *StartConfig) {
+		c.Attributes = append(c.Attributes, attrs...)
+	}
+}
+
+// WithLinks 设置关联的跨度，无效的跨度上下文会被忽略
+func WithLinks(links ...SpanContext) (opt StartOption) {
+	return func(c *StartConfig) {
+		for _, link := range links {
+			if link.IsValid() {
+				c.Links = append(c.Links, link)
+			}
+		}
+	}
+}
+
+// NewStartConfig 应用创建跨度的选项，供 Tracer 的实现使用
+func NewStartConfig(opts ...StartOption) (config *StartConfig) {
+	config = &StartConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return
+}
+
+// contextKey 上下文键类型
+type contextKey int
+
+const (
+	spanKey contextKey = iota
+	remoteSpanContextKey
+)
+
+// ContextWithSpan 将跨度存入上下文
+func ContextWithSpan(ctx context.Context, span Span) (newCtx context.Context) {
+	return context.WithValue(ctx, spanKey, span)
+}
+
+// SpanFromContext 从上下文中获取跨度，不存在时返回空跨度
+func SpanFromContext(ctx context.Context) (span Span) {
+	if span, ok := ctx.Value(spanKey).(Span); ok && span != nil {
+		return span
+	}
+	return noopSpan{sc: remoteSpanContext(ctx)}
+}
+
+// ContextWithRemoteSpanContext 将远程跨度上下文存入上下文，作为后续创建的跨度的父跨度
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) (newCtx context.Context) {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteSpanContextKey, sc)
+}
+
+// SpanContextFromContext 从上下文中获取当前的跨度上下文，本进程的跨度优先于远程跨度上下文
+func SpanContextFromContext(ctx context.Context) (sc SpanContext) {
+	if span, ok := ctx.Value(spanKey).(Span); ok && span != nil {
+		if sc = span.SpanContext(); sc.IsValid() {
+			return
+		}
+	}
+	return remoteSpanContext(ctx)
+}
+
+// remoteSpanContext 从上下文中获取远程跨度上下文
+func remoteSpanContext(ctx context.Context) (sc SpanContext) {
+	sc, _ = ctx.Value(remoteSpanContextKey).(SpanContext)
+	return
+}
+
+// globalTracer 全局追踪器
+var globalTracer atomic.Value
+
+// tracerHolder 保证 atomic.Value 中存储的类型一致
+type tracerHolder struct {
+	tracer Tracer
+}
+
+// SetTracer 设置全局追踪器，传入 nil 时恢复为空追踪器
+func SetTracer(tracer Tracer) {
+	if tracer == nil {
+		tracer = NoopTracer{}
+	}
+	globalTracer.Store(tracerHolder{tracer: tracer})
+}
+
+// GetTracer 获取全局追踪器，默认为空追踪器
+func GetTracer() (tracer Tracer) {
+	if holder, ok := globalTracer.Load().(tracerHolder); ok {
+		return holder.tracer
+	}
+	return NoopTracer{}
+}
+
+// NoopTracer 空追踪器，不记录任何跨度，但会透传上下文中的跨度上下文
+type NoopTracer struct{}
+
+// Start 创建跨度
+func (NoopTracer) Start(ctx context.Context, name string, opts ...StartOption) (newCtx context.Context, span Span) {
+	span = noopSpan{sc: SpanContextFromContext(ctx)}
+	return ContextWithSpan(ctx, span), span
+}
+
+// noopSpan 空跨度
+type noopSpan struct {
+	sc SpanContext
+}
+
+// SpanContext 获取跨度上下文
+func (s noopSpan) SpanContext() (sc SpanContext) {
+	return s.sc
+}
+
+// SetAttributes 设置属性
+func (noopSpan) SetAttributes(attrs ...Attribute) {}
+
+// SetStatus 设置状态
+func (noopSpan) SetStatus(code StatusCode, description string) {}
+
+// RecordError 记录错误
+func (noopSpan) RecordError(err error) {}
+
+// End 结束跨度
+func (noopSpan) End() {}
+
+// EndSpan 根据错误设置跨度状态并结束跨度，err 为 nil 时不改变状态
+func EndSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(StatusError, err.Error())
+	}
+	span.End()
+}
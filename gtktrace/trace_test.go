@@ -0,0 +1,130 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 09:36:18
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 09:36:18
+ * @Description:
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtktrace_test
+
+import (
+	"context"
+	"errors"
+	"github.com/liusuxian/go-toolkit/gtktrace"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"testing"
+)
+
+func TestTraceparent(t *testing.T) {
+	assert := assert.New(t)
+	sc, err := gtktrace.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoError(err)
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal("00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(sc.IsSampled())
+	assert.True(sc.Remote)
+	assert.Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", gtktrace.FormatTraceparent(sc))
+
+	for _, v := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err = gtktrace.ParseTraceparent(v)
+		assert.Error(err, v)
+	}
+}
+
+func TestRecordingTracer(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		exporter = gtktrace.NewInMemoryExporter()
+		tracer   = gtktrace.NewRecordingTracer(exporter)
+		header   = http.Header{}
+	)
+	// 从请求头中解析远程父跨度
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set("tracestate", "vendor=1")
+	ctx := gtktrace.Extract(context.Background(), gtktrace.HeaderCarrier(header))
+	ctx, parent := tracer.Start(ctx, "server", gtktrace.WithSpanKind(gtktrace.SpanKindServer))
+	_, child := tracer.Start(ctx, "client", gtktrace.WithSpanKind(gtktrace.SpanKindClient), gtktrace.WithAttributes(gtktrace.Attr("k", "v")))
+	// 注入到消息头
+	carrier := gtktrace.MapCarrier{}
+	gtktrace.Inject(gtktrace.ContextWithSpan(ctx, child), carrier)
+	assert.Equal(gtktrace.FormatTraceparent(child.SpanContext()), carrier["traceparent"])
+	assert.Equal("vendor=1", carrier["tracestate"])
+	gtktrace.EndSpan(child, errors.New("boom"))
+	gtktrace.EndSpan(parent, nil)
+	parent.End()
+
+	spans := exporter.Spans()
+	if assert.Len(spans, 2) {
+		assert.Equal("client", spans[0].Name)
+		assert.Equal(gtktrace.SpanKindClient, spans[0].Kind)
+		assert.Equal(parent.SpanContext(), spans[0].Parent)
+		assert.Equal(gtktrace.StatusError, spans[0].Status)
+		assert.Equal([]string{"boom"}, spans[0].Errors)
+		assert.Equal("v", spans[0].Attributes["k"])
+		assert.Equal("server", spans[1].Name)
+		assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", spans[1].SpanContext.TraceID.String())
+		assert.Equal("00f067aa0ba902b7", spans[1].Parent.SpanID.String())
+		assert.True(spans[1].Parent.Remote)
+	}
+	// 未采样的父跨度只传播上下文
+	exporter.Reset()
+	ctx = gtktrace.Extract(context.Background(), gtktrace.MapCarrier{"Traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"})
+	_, span := tracer.Start(ctx, "unsampled")
+	span.End()
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID.String())
+	assert.Empty(exporter.Spans())
+}
+
+func TestNoopTracer(t *testing.T) {
+	assert := assert.New(t)
+	assert.IsType(gtktrace.NoopTracer{}, gtktrace.GetTracer())
+	// 空追踪器透传远程跨度上下文
+	ctx := gtktrace.Extract(context.Background(), gtktrace.MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+	ctx, span := gtktrace.GetTracer().Start(ctx, "noop")
+	span.End()
+	carrier := gtktrace.MapCarrier{}
+	gtktrace.Inject(ctx, carrier)
+	assert.Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", carrier["traceparent"])
+	// 没有跨度上下文时不注入
+	carrier = gtktrace.MapCarrier{}
+	gtktrace.Inject(context.Background(), carrier)
+	assert.Empty(carrier)
+}
+
+func TestOTelTracer(t *testing.T) {
+	var (
+		assert   = assert.New(t)
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		tracer   = gtktrace.NewOTelTracer(provider.Tracer("test"))
+	)
+	defer provider.Shutdown(context.Background())
+
+	ctx := gtktrace.Extract(context.Background(), gtktrace.MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+	ctx, span := tracer.Start(ctx, "consume", gtktrace.WithSpanKind(gtktrace.SpanKindConsumer), gtktrace.WithAttributes(gtktrace.Attr("n", 1)))
+	carrier := gtktrace.MapCarrier{}
+	gtktrace.Inject(ctx, carrier)
+	gtktrace.EndSpan(span, errors.New("boom"))
+
+	spans := recorder.Ended()
+	if assert.Len(spans, 1) {
+		assert.Equal("consume", spans[0].Name())
+		assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+		assert.Equal("00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+		assert.Equal("Error", spans[0].Status().Code.String())
+		assert.Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-"+spans[0].SpanContext().SpanID().String()+"-01", carrier["traceparent"])
+	}
+}
@@ -0,0 +1,203 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-23 09:36:18
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-23 09:36:18
+ * @Description: 内置的记录追踪器和导出器，适用于测试或不接入 OTel SDK 的场景
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package gtktrace
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SpanData 已结束的跨度数据
+type SpanData struct {
+	Name              string         `json:"name"`               // 名称
+	SpanContext       SpanContext    `json:"span_context"`       // 跨度上下文
+	Parent            SpanContext    `json:"parent"`             // 父跨度上下文，根跨度时无效
+	Kind              SpanKind       `json:"kind"`               // 跨度类型
+	StartTime         time.Time      `json:"start_time"`         // 开始时间
+	EndTime           time.Time      `json:"end_time"`           // 结束时间
+	Attributes        map[string]any `json:"attributes"`         // 属性
+	Links             []SpanContext  `json:"links,omitempty"`    // 关联的跨度
+	Errors            []string       `json:"errors,omitempty"`   // 记录的错误
+	Status            StatusCode     `json:"status"`             // 状态
+	StatusDescription string         `json:"status_description"` // 状态描述
+}
+
+// Exporter 跨度导出器，在跨度结束时调用，需要保证并发安全
+type Exporter interface {
+	Export(span *SpanData) // 导出跨度
+}
+
+// ExporterFunc 函数形式的跨度导出器
+type ExporterFunc func(span *SpanData)
+
+// Export 导出跨度
+func (f ExporterFunc) Export(span *SpanData) {
+	f(span)
+}
+
+// NoopExporter 空导出器，丢弃所有跨度
+type NoopExporter struct{}
+
+// Export 导出跨度
+func (NoopExporter) Export(span *SpanData) {}
+
+// InMemoryExporter 内存导出器，保存所有已结束的跨度，适用于测试
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []*SpanData
+}
+
+// NewInMemoryExporter 创建内存导出器
+func NewInMemoryExporter() (exporter *InMemoryExporter) {
+	return &InMemoryExporter{}
+}
+
+// Export 导出跨度
+func (e *InMemoryExporter) Export(span *SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans 获取已结束的跨度，按结束顺序排列
+func (e *InMemoryExporter) Spans() (spans []*SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.spans)
+}
+
+// Reset 清空已保存的跨度
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
+
+// RecordingTracer 记录追踪器，为每个跨度生成 W3C 兼容的ID，结束时交给导出器
+//
+//	根跨度总是采样；有父跨度时沿用父跨度的采样标志，未采样的跨度只传播上下文，不会导出
+type RecordingTracer struct {
+	exporter Exporter
+}
+
+// NewRecordingTracer 创建记录追踪器，exporter 为 nil 时使用空导出器
+func NewRecordingTracer(exporter Exporter) (tracer *RecordingTracer) {
+	if exporter == nil {
+		exporter = NoopExporter{}
+	}
+	return &RecordingTracer{exporter: exporter}
+}
+
+// Start 创建跨度
+func (t *RecordingTracer) Start(ctx context.Context, name string, opts ...StartOption) (newCtx context.Context, span Span) {
+	var (
+		config = NewStartConfig(opts...)
+		parent = SpanContextFromContext(ctx)
+		sc     = SpanContext{TraceFlags: FlagsSampled}
+	)
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.TraceFlags = parent.TraceFlags
+		sc.TraceState = parent.TraceState
+	} else {
+		parent = SpanContext{}
+		rand.Read(sc.TraceID[:])
+	}
+	rand.Read(sc.SpanID[:])
+	if !sc.IsSampled() {
+		span = noopSpan{sc: sc}
+		return ContextWithSpan(ctx, span), span
+	}
+	rs := &recordingSpan{
+		exporter: t.exporter,
+		data: &SpanData{
+			Name:        name,
+			SpanContext: sc,
+			Parent:      parent,
+			Kind:        config.Kind,
+			StartTime:   time.Now(),
+			Attributes:  make(map[string]any, len(config.Attributes)),
+			Links:       config.Links,
+		},
+	}
+	rs.SetAttributes(config.Attributes...)
+	return ContextWithSpan(ctx, rs), rs
+}
+
+// recordingSpan 记录跨度
+type recordingSpan struct {
+	mu       sync.Mutex
+	exporter Exporter
+	data     *SpanData
+	ended    bool
+}
+
+// SpanContext 获取跨度上下文
+func (s *recordingSpan) SpanContext() (sc SpanContext) {
+	return s.data.SpanContext
+}
+
+// SetAttributes 设置属性
+func (s *recordingSpan) SetAttributes(attrs ...Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	for _, attr := range attrs {
+		s.data.Attributes[attr.Key] = attr.Value
+	}
+}
+
+// SetStatus 设置状态，OK 状态不会被覆盖
+func (s *recordingSpan) SetStatus(code StatusCode, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended || s.data.Status == StatusOK {
+		return
+	}
+	s.data.Status = code
+	if code == StatusError {
+		s.data.StatusDescription = description
+	}
+}
+
+// RecordError 记录错误
+func (s *recordingSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.data.Errors = append(s.data.Errors, fmt.Sprintf("%v", err))
+}
+
+// End 结束跨度
+func (s *recordingSpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := *s.data
+	data.Attributes = maps.Clone(s.data.Attributes)
+	s.mu.Unlock()
+	s.exporter.Export(&data)
+}
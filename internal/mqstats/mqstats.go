@@ -0,0 +1,77 @@
+/*
+ * @Author: liusuxian 382185882@qq.com
+ * @Date: 2026-10-26 10:14:52
+ * @LastEditors: liusuxian 382185882@qq.com
+ * @LastEditTime: 2026-10-26 10:14:52
+ * @Description: 消息队列统计计数器，gtkmq 和 gtkkafka 共用
+ *
+ * Copyright (c) 2026 by liusuxian email: 382185882@qq.com, All Rights Reserved.
+ */
+package mqstats
+
+import (
+	"github.com/liusuxian/go-toolkit/gtkhttp"
+	"sync/atomic"
+)
+
+// Counter 消息收发统计计数器，并发安全
+type Counter struct {
+	sent          atomic.Uint64
+	sendFailed    atomic.Uint64
+	consumed      atomic.Uint64
+	consumeFailed atomic.Uint64
+}
+
+// Snapshot 统计数据
+type Snapshot struct {
+	Sent          uint64 // 发送成功的消息数
+	SendFailed    uint64 // 发送失败的消息数
+	Consumed      uint64 // 消费成功的消息数
+	ConsumeFailed uint64 // 消费失败的消息数
+}
+
+// RecordSend 记录发送结果
+func (c *Counter) RecordSend(err error) {
+	if err != nil {
+		c.sendFailed.Add(1)
+		return
+	}
+	c.sent.Add(1)
+}
+
+// RecordConsume 记录消费结果，n 为本次消费的消息数
+func (c *Counter) RecordConsume(n int, err error) {
+	if err != nil {
+		c.consumeFailed.Add(uint64(n))
+		return
+	}
+	c.consumed.Add(uint64(n))
+}
+
+// Snapshot 获取统计数据
+func (c *Counter) Snapshot() (s Snapshot) {
+	return Snapshot{
+		Sent:          c.sent.Load(),
+		SendFailed:    c.sendFailed.Load(),
+		Consumed:      c.consumed.Load(),
+		ConsumeFailed: c.consumeFailed.Load(),
+	}
+}
+
+// Families 将统计数据转换为计数器指标，指标名称以 namespace 开头，如 gtkmq_sent_messages_total
+func Families(namespace string, labels map[string]string, s Snapshot) (families []*gtkhttp.MetricFamily) {
+	counter := func(name, help string, value uint64) (family *gtkhttp.MetricFamily) {
+		return &gtkhttp.MetricFamily{
+			Name:    namespace + "_" + name,
+			Help:    help,
+			Type:    gtkhttp.MetricTypeCounter,
+			Samples: []*gtkhttp.MetricSample{{Labels: labels, Value: float64(value)}},
+		}
+	}
+	return []*gtkhttp.MetricFamily{
+		counter("sent_messages_total", "Total number of messages sent successfully.", s.Sent),
+		counter("send_failed_messages_total", "Total number of messages failed to send.", s.SendFailed),
+		counter("consumed_messages_total", "Total number of messages consumed successfully.", s.Consumed),
+		counter("consume_failed_messages_total", "Total number of messages failed to consume after retries.", s.ConsumeFailed),
+	}
+}